@@ -2,13 +2,16 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	. "plaso2graph/master/src/Entity"
 	. "plaso2graph/master/src/Extractor"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,12 +21,20 @@ import (
 var (
 	source        = flag.String("source", "data/output.json", "Source CSV File generated by plaso")
 	outputDir     = flag.String("output", "output/", "Output Json File")
-	extractorName = flag.String("extractor", "neo4j", "Type of Extractor to use. (default: neo4j, csv, json, xml)")
+	extractorName = flag.String("extractor", "neo4j", "Type of Extractor to use. (default: neo4j, neo4j-import, cypher, csv, sqlite, graphml, gexf, maltego, stix, timesketch, json, xml)")
 	verbose       = flag.Bool("verbose", false, "Verbose mode")
 	username      = flag.String("username", "neo4j", "Username for Neo4j")
 	password      = flag.Bool("password", false, "Prompt for password")
 	url           = flag.String("url", "bolt://localhost:7687", "Url of Neo4j")
 	computer      = flag.String("computer", "", "Defaulting 'computer' field to this value for artefacts that don't have it")
+	transcripts   = flag.String("transcripts", "", "Directory containing PowerShell transcripts (PowerShell_transcript.*.txt)")
+	transcriptsTZ = flag.String("transcripts-timezone", "", "Time zone of the machines which wrote the transcripts (ex: \"Europe/Paris\"), their times are kept local otherwise")
+	alerts        = flag.String("alerts", "", "Hayabusa (JSONL) or Chainsaw (JSON) output, or a directory containing them")
+	zeek          = flag.String("zeek", "", "Zeek conn.log, dns.log and http.log (TSV or JSON), or a directory containing them")
+	firewall      = flag.String("firewall", "", "Firewall log exported as CSV with a header line, or a directory containing them")
+	stixBundle    = flag.String("stix-bundle", "case", "STIX bundles written by the stix extractor: one for the case or one per computer (case, computer)")
+	maxEvents     = flag.Int("max-events", 0, "Maximum number of events of the source, checked before parsing (0 for no limit)")
+	firewallCols  = flag.String("firewall-columns", "", "Columns of the firewall CSV (ex: \"ip_source=SrcAddr,ip_destination=DstAddr,timestamp=Time\"), common names are detected")
 )
 
 func compare(a string, b string) bool {
@@ -37,13 +48,91 @@ func ValidateArgs() {
 		log.Fatal()
 	}
 
+	if *transcripts != "" {
+		if _, err := os.Stat(*transcripts); err != nil {
+			fmt.Printf("Transcripts directory \"%s\" Does not exist\n", *transcripts)
+			log.Fatal()
+		}
+	}
+
+	if *alerts != "" {
+		if _, err := os.Stat(*alerts); err != nil {
+			fmt.Printf("Alerts \"%s\" Does not exist\n", *alerts)
+			log.Fatal()
+		}
+	}
+
+	if *transcriptsTZ != "" {
+		if _, err := time.LoadLocation(*transcriptsTZ); err != nil {
+			fmt.Printf("Time zone \"%s\" Does not exist\n", *transcriptsTZ)
+			log.Fatal()
+		}
+	}
+
+	if *zeek != "" {
+		if _, err := os.Stat(*zeek); err != nil {
+			fmt.Printf("Zeek logs \"%s\" Does not exist\n", *zeek)
+			log.Fatal()
+		}
+	}
+
+	if *firewall != "" {
+		if _, err := os.Stat(*firewall); err != nil {
+			fmt.Printf("Firewall logs \"%s\" Does not exist\n", *firewall)
+			log.Fatal()
+		}
+	}
+
+	if *maxEvents < 0 {
+		fmt.Printf("Maximum number of events \"%d\" must be positive\n", *maxEvents)
+		log.Fatal()
+	}
+
+	// The relationships are computed once the source is parsed, a case too large for the machine fails before
+	if *maxEvents > 0 {
+		if events := countLines(*source); events > *maxEvents {
+			fmt.Printf("Source file \"%s\" has %d events, more than -max-events %d: split it by computer or by period\n", *source, events, *maxEvents)
+			log.Fatal()
+		}
+	}
+
 	if _, err := os.Stat(*outputDir); err == nil {
 		fmt.Printf("Output file \"%s\" already exists exist\n", *outputDir)
 	}
 
-	if compare(*extractorName, "neo4j") && compare(*extractorName, "maltego") {
-		fmt.Println("Extractor must be one from: neo4j, maltego.")
+	valid := false
+	for _, name := range Extractors {
+		if compare(*extractorName, name) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		fmt.Printf("Extractor must be one from: %s.\n", strings.Join(Extractors, ", "))
 		fmt.Println("Extractor: ", *extractorName)
+		log.Fatal()
+	}
+}
+
+// countLines count the lines of a file, the events of a plaso json_line output
+func countLines(path string) int {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	count := 0
+	buf := make([]byte, 1024*1024)
+	for {
+		n, err := file.Read(buf)
+		count += bytes.Count(buf[:n], []byte{'\n'})
+		if err == io.EOF {
+			return count
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 }
 
@@ -58,6 +147,13 @@ func ProcessArgs() map[string]interface{} {
 	args["username"] = *username
 	args["url"] = *url
 	args["computer"] = *computer
+	args["transcripts"] = *transcripts
+	args["transcripts-timezone"] = *transcriptsTZ
+	args["alerts"] = *alerts
+	args["zeek"] = *zeek
+	args["firewall"] = *firewall
+	args["firewall-columns"] = *firewallCols
+	args["stix-bundle"] = *stixBundle
 
 	if *password {
 		var tmp string
@@ -228,6 +324,48 @@ func MergeEntities(data []interface{}) []interface{} {
 		case []Process:
 			data[i] = MergeProcesses(data[i].([]Process), 1000000)
 			break
+		case []Computer:
+			data[i] = MergeComputers(data[i].([]Computer))
+			break
+		case []Connection:
+			// Sysmon batches its network events, the same flow may be logged minutes after Zeek saw it
+			data[i] = MergeConnections(data[i].([]Connection), 120000000000)
+			break
+		case []Device:
+			// MountPoints2 writes the GUID and the drive letter of a volume when it is mounted
+			data[i] = MergeDevices(data[i].([]Device), 60000000)
+			break
+		}
+	}
+	return data
+}
+
+// AddNetworkUsages add the SRUM network usages as a Process per application and interface, with the bytes of every hour
+func AddNetworkUsages(data []interface{}) []interface{} {
+	var processes []Process
+	index := map[string]int{}
+	for i, v := range data {
+		switch v.(type) {
+		case []NetworkUsage:
+			for _, u := range MergeNetworkUsages(v.([]NetworkUsage)) {
+				key := u.Computer + "|" + u.FullPath + "|" + strconv.Itoa(u.InterfaceLUID)
+				if j, found := index[key]; found {
+					processes[j] = AddProcessNetworkUsage(processes[j], u)
+					continue
+				}
+				index[key] = len(processes)
+				processes = append(processes, NewProcessFromNetworkUsage(u))
+			}
+			data[i] = *new([]NetworkUsage)
+			break
+		}
+	}
+
+	for i, v := range data {
+		switch v.(type) {
+		case []Process:
+			data[i] = UnionProcesses(v.([]Process), processes)
+			break
 		}
 	}
 	return data
@@ -257,6 +395,17 @@ func ProcessFile(path string, args map[string]interface{}) {
 		*new([]Connection),
 		*new([]Event),
 		*new([]Registry),
+		*new([]SecurityControlChange),
+		*new([]Detection),
+		*new([]AntiForensics),
+		*new([]Device),
+		*new([]FileAccess),
+		*new([]Network),
+		*new([]NetworkUsage),
+		*new([]Command),
+		*new([]Cookie),
+		*new([]Alert),
+		*new([]DnsQuery),
 	}
 
 	if args["computer"].(string) != "" {
@@ -298,8 +447,8 @@ func ProcessFile(path string, args map[string]interface{}) {
 				//fmt.Println("len(data) = ", len(data[1].([]File)))
 				toExtract = MergeEntities(toExtract)
 				//fmt.Println("Extracting entities")
+				wg.Add(1)
 				go func(toExtract []interface{}, args map[string]interface{}) {
-					wg.Add(1)
 					Extract(toExtract, args)
 					wg.Done()
 					//fmt.Println("Data extracted")
@@ -321,8 +470,25 @@ func ProcessFile(path string, args map[string]interface{}) {
 	data = ParseEntities(data, lines, args)
 	lines = *new([]PlasoLog)
 
+	if args["transcripts"].(string) != "" {
+		data = ParseTranscripts(data, FindTranscripts(args["transcripts"].(string)), args)
+	}
+
+	if args["alerts"].(string) != "" {
+		data = ParseAlerts(data, FindAlerts(args["alerts"].(string)), args)
+	}
+
+	if args["zeek"].(string) != "" {
+		data = ParseZeekLogs(data, FindZeekLogs(args["zeek"].(string)), args)
+	}
+
+	if args["firewall"].(string) != "" {
+		data = ParseFirewallLogs(data, FindFirewallLogs(args["firewall"].(string)), args)
+	}
+
 	wg.Wait()
 	//We Extract the last entities
+	data = AddNetworkUsages(data)
 	data = MergeEntities(data)
 	Extract(data, args)
 	data = nil
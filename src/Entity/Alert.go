@@ -0,0 +1,399 @@
+package Entity
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alert is a detection of a Sigma based triage tool (Hayabusa, Chainsaw) on an evtx record
+type Alert struct {
+	Date      time.Time
+	Timestamp int // Nanoseconds, like the Events built from the same records
+
+	Tool    string // Hayabusa or Chainsaw
+	Title   string // Rule title
+	RuleID  string
+	Level   string   // informational, low, medium, high or critical
+	Tags    []string // MITRE ATT&CK tactics and techniques
+	Details string
+
+	// Detected evtx record
+	RecordID int
+	EventID  int
+	Channel  string
+	User     string
+	Computer string
+	Evidence []string
+}
+
+var (
+	// AlertLevelMap normalize the abbreviated levels of Hayabusa
+	AlertLevelMap = map[string]string{
+		"info": "informational",
+		"low":  "low",
+		"med":  "medium",
+		"high": "high",
+		"crit": "critical",
+	}
+
+	// HayabusaChannelMap expand the channels abbreviated by Hayabusa (default output profiles)
+	HayabusaChannelMap = map[string]string{
+		"Sec":         "Security",
+		"Sys":         "System",
+		"App":         "Application",
+		"Sysmon":      "Microsoft-Windows-Sysmon/Operational",
+		"PwSh":        "Microsoft-Windows-PowerShell/Operational",
+		"PwShClassic": "Windows PowerShell",
+		"Defender":    "Microsoft-Windows-Windows Defender/Operational",
+		"TaskSch":     "Microsoft-Windows-TaskScheduler/Operational",
+		"Firewall":    "Microsoft-Windows-Windows Firewall With Advanced Security/Firewall",
+		"WinRM":       "Microsoft-Windows-WinRM/Operational",
+		"BITS":        "Microsoft-Windows-Bits-Client/Operational",
+	}
+
+	// Keys holding the user of the detected record, in order of preference
+	hayabusaUserKeys = []string{"TgtUser", "User", "SrcUser", "TargetUser"}
+	chainsawUserKeys = []string{"TargetUserName", "SubjectUserName", "User"}
+
+	alertTimeFormats = []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999 -07:00",
+		"2006-01-02 15:04:05.999999999 -0700",
+		"2006-01-02 15:04:05.999999999",
+	}
+)
+
+// alertKey identify the same rule matching the same record (Hayabusa and Chainsaw outputs may overlap)
+type alertKey struct {
+	tool     string
+	title    string
+	recordID int
+	channel  string
+	computer string
+}
+
+func getAlertKey(a Alert) alertKey {
+	return alertKey{a.Tool, a.Title, a.RecordID, a.Channel, a.Computer}
+}
+
+// indexAlerts return the position of every alert of a slice by rule and record, updated by addAlert
+func indexAlerts(alerts []Alert) map[alertKey]int {
+	index := map[alertKey]int{}
+	for i, a := range alerts {
+		index[getAlertKey(a)] = i
+	}
+	return index
+}
+
+func addAlert(alerts []Alert, index map[alertKey]int, a Alert) []Alert {
+	if a.Title == "" {
+		return alerts
+	}
+
+	key := getAlertKey(a)
+	if i, ok := index[key]; ok {
+		alerts[i].Evidence = append(alerts[i].Evidence, a.Evidence...)
+	} else {
+		index[key] = len(alerts)
+		alerts = append(alerts, a)
+	}
+	return alerts
+}
+
+func UnionAlerts(dest []Alert, src []Alert) []Alert {
+	index := indexAlerts(dest)
+	for _, a := range src {
+		dest = addAlert(dest, index, a)
+	}
+	return dest
+}
+
+// IsAlertFile tell if a file may be a Hayabusa or Chainsaw JSON output
+func IsAlertFile(path string) bool {
+	extension := strings.ToLower(filepath.Ext(path))
+	return extension == ".json" || extension == ".jsonl"
+}
+
+// FindAlerts list the alert files of a path, a single file or a directory and its sub directories
+func FindAlerts(path string) []string {
+	var paths []string
+
+	info, err := os.Stat(path)
+	handleErr(err)
+	if !info.IsDir() {
+		return []string{path}
+	}
+
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && IsAlertFile(p) {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	handleErr(err)
+	return paths
+}
+
+func parseAlertTime(value string) (time.Time, bool) {
+	for _, format := range alertTimeFormats {
+		if t, err := time.Parse(format, strings.TrimSpace(value)); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// getJsonInt return a number of a decoded json document, which may be a string or an object with a "#text" value (evtx attributes)
+func getJsonInt(v interface{}) int {
+	switch value := v.(type) {
+	case float64:
+		return int(value)
+	case string:
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+		break
+	case map[string]interface{}:
+		return getJsonInt(value["#text"])
+	}
+	return 0
+}
+
+func getJsonString(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	b, err := json.Marshal(v)
+	handleErr(err)
+	return string(b)
+}
+
+func getJsonStrings(v interface{}) []string {
+	var res []string
+	if values, ok := v.([]interface{}); ok {
+		for _, value := range values {
+			res = append(res, getJsonString(value))
+		}
+	}
+	return res
+}
+
+// getJsonObject follow a path of keys in a decoded json document
+func getJsonObject(v interface{}, keys ...string) map[string]interface{} {
+	for _, key := range keys {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// getAlertUser return the first user found in the fields of a record, without its domain and lowercased like the users of the Event Logs
+func getAlertUser(fields map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		user := strings.ToLower(getJsonString(fields[key]))
+		if index := strings.LastIndex(user, "\\"); index != -1 {
+			user = user[index+1:]
+		}
+		if user != "" && user != "-" && user != "n/a" {
+			return user
+		}
+	}
+	return ""
+}
+
+// formatAlertDetails format the details of a Hayabusa alert ("Key: Value ¦ Key: Value" like its CSV output)
+func formatAlertDetails(details map[string]interface{}) string {
+	var keys []string
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var res []string
+	for _, key := range keys {
+		res = append(res, key+": "+getJsonString(details[key]))
+	}
+	return strings.Join(res, " ¦ ")
+}
+
+// NewAlertFromHayabusa handle a detection of the Hayabusa json-timeline output
+func NewAlertFromHayabusa(record map[string]interface{}) Alert {
+	var a Alert
+
+	a.Tool = "Hayabusa"
+	a.Title = getJsonString(record["RuleTitle"])
+	a.RuleID = getJsonString(record["RuleID"])
+	a.Level = getJsonString(record["Level"])
+	if level, ok := AlertLevelMap[a.Level]; ok {
+		a.Level = level
+	}
+	a.Tags = append(getJsonStrings(record["MitreTactics"]), getJsonStrings(record["MitreTags"])...)
+	a.Tags = append(a.Tags, getJsonStrings(record["OtherTags"])...)
+
+	a.RecordID = getJsonInt(record["RecordID"])
+	a.EventID = getJsonInt(record["EventID"])
+	a.Channel = getJsonString(record["Channel"])
+	if channel, ok := HayabusaChannelMap[a.Channel]; ok {
+		a.Channel = channel
+	}
+	a.Computer = getJsonString(record["Computer"])
+
+	if details := getJsonObject(record, "Details"); details != nil {
+		a.Details = formatAlertDetails(details)
+		a.User = getAlertUser(details, hayabusaUserKeys)
+	} else {
+		a.Details = getJsonString(record["Details"])
+	}
+
+	if t, ok := parseAlertTime(getJsonString(record["Timestamp"])); ok {
+		a.Date = t
+		a.Timestamp = int(t.UnixNano())
+	}
+
+	a.Evidence = append(a.Evidence, getJsonString(record))
+	return a
+}
+
+// NewAlertsFromChainsaw handle a detection of the Chainsaw hunt json output, aggregated detections have one alert per record
+func NewAlertsFromChainsaw(record map[string]interface{}) []Alert {
+	var alerts []Alert
+
+	var documents []interface{}
+	if document, ok := record["document"]; ok {
+		documents = append(documents, document)
+	}
+	if aggregated, ok := record["documents"].([]interface{}); ok {
+		documents = append(documents, aggregated...)
+	}
+
+	for _, document := range documents {
+		var a Alert
+
+		a.Tool = "Chainsaw"
+		a.Title = getJsonString(record["name"])
+		a.RuleID = getJsonString(record["id"])
+		a.Level = getJsonString(record["level"])
+		for _, tag := range getJsonStrings(record["tags"]) {
+			if strings.HasPrefix(tag, "attack.") {
+				a.Tags = append(a.Tags, strings.TrimPrefix(tag, "attack."))
+			}
+		}
+
+		system := getJsonObject(document, "data", "Event", "System")
+		a.RecordID = getJsonInt(system["EventRecordID"])
+		a.EventID = getJsonInt(system["EventID"])
+		a.Channel = getJsonString(system["Channel"])
+		a.Computer = getJsonString(system["Computer"])
+
+		if eventData := getJsonObject(document, "data", "Event", "EventData"); eventData != nil {
+			a.User = getAlertUser(eventData, chainsawUserKeys)
+		}
+
+		if t, ok := parseAlertTime(getJsonString(record["timestamp"])); ok {
+			a.Date = t
+			a.Timestamp = int(t.UnixNano())
+		}
+
+		a.Evidence = append(a.Evidence, getJsonString(document))
+		alerts = append(alerts, a)
+	}
+	return alerts
+}
+
+// newAlertsFromRecord detect the tool which wrote a detection
+func newAlertsFromRecord(record map[string]interface{}) []Alert {
+	if _, ok := record["RuleTitle"]; ok {
+		return []Alert{NewAlertFromHayabusa(record)}
+	}
+	if _, ok := record["name"]; ok {
+		return NewAlertsFromChainsaw(record)
+	}
+	return nil
+}
+
+// ParseAlertFile parse a Hayabusa (JSONL or JSON) or Chainsaw (JSON array or JSONL) output
+func ParseAlertFile(path string) []Alert {
+	var alerts []Alert
+	index := map[alertKey]int{}
+
+	file, err := os.Open(path)
+	handleErr(err)
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+
+	// Chainsaw writes a json array, Hayabusa and Chainsaw --jsonl a stream of objects
+	for {
+		var value interface{}
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			break
+		}
+		handleErr(err)
+
+		switch v := value.(type) {
+		case []interface{}:
+			for _, record := range v {
+				if m, ok := record.(map[string]interface{}); ok {
+					for _, a := range newAlertsFromRecord(m) {
+						alerts = addAlert(alerts, index, a)
+					}
+				}
+			}
+			break
+		case map[string]interface{}:
+			for _, a := range newAlertsFromRecord(v) {
+				alerts = addAlert(alerts, index, a)
+			}
+			break
+		}
+	}
+	return alerts
+}
+
+// NewUserFromAlert create the User of the detected record
+func NewUserFromAlert(a Alert) *User {
+	if a.User == "" {
+		return nil
+	}
+	return &User{FullName: strings.ToLower(a.User)}
+}
+
+// ParseAlerts parse Hayabusa and Chainsaw outputs into Alert, User and Computer entities
+func ParseAlerts(data []interface{}, paths []string, args map[string]interface{}) []interface{} {
+	for _, path := range paths {
+		var users []User
+		var computers []Computer
+
+		alerts := ParseAlertFile(path)
+		for _, a := range alerts {
+			users = AddUser(users, NewUserFromAlert(a))
+			if a.Computer != "" {
+				computers = AddComputer(computers, Computer{Name: a.Computer})
+			}
+		}
+
+		for _, entities := range []interface{}{alerts, users, computers} {
+			data = unionEntities(data, entities, args["computer"].(string))
+		}
+	}
+	return data
+}
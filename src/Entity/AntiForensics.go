@@ -0,0 +1,157 @@
+package Entity
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+type AntiForensics struct {
+	Date      time.Time
+	Timestamp int
+	Computer  string
+
+	Type    string // "Log Cleared", "Audit Policy Changed", "CrashOnAuditFail Changed" or "Object Auditing Changed"
+	Title   string
+	Channel string // Cleared channel or audited channel
+	Setting string // Audit subcategory or audited object
+	Changes string
+
+	// Auditing removed or added by the change (used to compute log coverage gaps)
+	AuditingRemoved bool
+	AuditingAdded   bool
+
+	// Log coverage gap created by the change, computed by the Linker
+	CreatesGap bool
+	GapSource  string
+	GapStart   time.Time // Zero when the gap starts before the logs
+	GapEnd     time.Time // Zero when auditing was not added back
+
+	User        string
+	UserDomain  string
+	UserSID     string
+	LogonID     string
+	ProcessName string
+	Evidence    []string
+}
+
+var (
+	AuditPolicyChangeMap = map[string]string{
+		"%%8448": "Success removed",
+		"%%8449": "Success added",
+		"%%8450": "Failure removed",
+		"%%8451": "Failure added",
+	}
+)
+
+func AddAntiForensics(afs []AntiForensics, af AntiForensics) []AntiForensics {
+	if af.Title != "" {
+		afs = append(afs, af)
+	}
+	return afs
+}
+
+func UnionAntiForensics(dest []AntiForensics, src []AntiForensics) []AntiForensics {
+	for _, af := range src {
+		dest = AddAntiForensics(dest, af)
+	}
+	return dest
+}
+
+func constructAntiForensics(evtx EvtxLog) AntiForensics {
+	var af AntiForensics
+
+	af.Computer = evtx.System.Computer
+	t, err := time.Parse(time.RFC3339Nano, evtx.System.TimeCreated.SystemTime)
+	handleErr(err)
+	af.Date = t
+	af.Timestamp = int(t.UnixMicro())
+
+	xmlString, err := xml.Marshal(evtx)
+	handleErr(err)
+	af.Evidence = append(af.Evidence, string(xmlString))
+
+	return af
+}
+
+// NewAntiForensicsFromLogCleared handle Security (1102) and System (104) "Log Cleared" events
+func NewAntiForensicsFromLogCleared(evtx EvtxLog) AntiForensics {
+	af := constructAntiForensics(evtx)
+
+	if evtx.UserData == nil || evtx.UserData.LogFileCleared == nil {
+		return af
+	}
+	cleared := evtx.UserData.LogFileCleared
+
+	af.Type = "Log Cleared"
+	af.User = strings.ToLower(cleared.SubjectUserName)
+	af.UserDomain = strings.ToLower(cleared.SubjectDomainName)
+	af.UserSID = cleared.SubjectUserSid
+	af.LogonID = cleared.SubjectLogonId
+
+	// 1102 is only raised when the Security log is cleared and does not name the channel
+	af.Channel = cleared.Channel
+	if af.Channel == "" {
+		af.Channel = evtx.System.Channel
+	}
+	af.Changes = cleared.BackupPath
+
+	af.Title = "User " + af.User + " cleared the " + af.Channel + " log."
+	return af
+}
+
+func NewAntiForensicsFromSecurity(evtx EvtxLog) AntiForensics {
+	af := constructAntiForensics(evtx)
+
+	af.User = strings.ToLower(GetDataValue(evtx, "SubjectUserName"))
+	af.UserDomain = strings.ToLower(GetDataValue(evtx, "SubjectDomainName"))
+	af.UserSID = GetDataValue(evtx, "SubjectUserSid")
+	af.LogonID = GetDataValue(evtx, "SubjectLogonId")
+	af.Channel = evtx.System.Channel
+
+	switch evtx.System.EventID {
+	case 4719:
+		af.Type = "Audit Policy Changed"
+		af.Setting = GetDataValue(evtx, "SubcategoryGuid")
+
+		var changes []string
+		for _, change := range strings.Split(GetDataValue(evtx, "AuditPolicyChanges"), ",") {
+			change = strings.TrimSpace(change)
+			if translated, ok := AuditPolicyChangeMap[change]; ok {
+				change = translated
+			}
+			if strings.HasSuffix(change, "removed") {
+				af.AuditingRemoved = true
+			}
+			if strings.HasSuffix(change, "added") {
+				af.AuditingAdded = true
+			}
+			changes = append(changes, change)
+		}
+		af.Changes = strings.Join(changes, ", ")
+		af.Title = "User " + af.User + " changed audit policy " + af.Setting + " (" + af.Changes + ")."
+		break
+	case 4906:
+		af.Type = "CrashOnAuditFail Changed"
+		af.Changes = GetDataValue(evtx, "CrashOnAuditFailValue")
+		af.Title = "User " + af.User + " changed CrashOnAuditFail value to " + af.Changes + "."
+		break
+	case 4907:
+		af.Type = "Object Auditing Changed"
+		af.Setting = GetDataValue(evtx, "ObjectName")
+		af.Changes = GetDataValue(evtx, "OldSd") + " -> " + GetDataValue(evtx, "NewSd")
+		af.ProcessName = strings.ToLower(GetDataValue(evtx, "ProcessName"))
+		af.Title = "User " + af.User + " changed auditing settings of " + af.Setting + "."
+		break
+	}
+
+	return af
+}
+
+// NewUserFromAntiForensics create the User responsible for the action (if known)
+func NewUserFromAntiForensics(af AntiForensics) *User {
+	if af.User == "" || af.User == "not found." {
+		return nil
+	}
+	return &User{FullName: af.User, Domain: af.UserDomain, SID: af.UserSID}
+}
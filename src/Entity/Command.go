@@ -0,0 +1,267 @@
+package Entity
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Command is a command typed in a PowerShell session recorded by a transcript (Start-Transcript or Transcription policy)
+type Command struct {
+	Date      time.Time
+	Timestamp int
+	LocalTime bool // Date is the local time of the machine, its time zone is unknown (-transcripts-timezone)
+	Computer  string
+
+	Commandline      string
+	Output           string
+	WorkingDirectory string
+
+	// Transcript header
+	User            string
+	UserDomain      string
+	RunAsUser       string
+	RunAsUserDomain string
+	HostApplication string
+	ProcessID       int
+	TranscriptPath  string
+	Evidence        []string
+}
+
+const (
+	transcriptSeparator  = "**********************"
+	transcriptTimeFormat = "20060102150405"
+)
+
+var (
+	// "PS C:\Users\bob> whoami"
+	transcriptPromptRegex = regexp.MustCompile(`^PS ([^>]*)> ?(.*)$`)
+)
+
+func AddCommand(commands []Command, c Command) []Command {
+	if c.Commandline != "" {
+		commands = append(commands, c)
+	}
+	return commands
+}
+
+func UnionCommands(dest []Command, src []Command) []Command {
+	for _, c := range src {
+		dest = AddCommand(dest, c)
+	}
+	return dest
+}
+
+// IsTranscript tell if a file is named like a PowerShell transcript (PowerShell_transcript.<machine>.<random>.<date>.txt)
+func IsTranscript(path string) bool {
+	filename := strings.ToLower(filepath.Base(path))
+	return strings.HasPrefix(filename, "powershell_transcript.") && strings.HasSuffix(filename, ".txt")
+}
+
+// FindTranscripts list the PowerShell transcripts stored in a directory and its sub directories
+func FindTranscripts(dir string) []string {
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && IsTranscript(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	handleErr(err)
+	return paths
+}
+
+// splitTranscriptUser split "DOMAIN\user" into user and domain, lowercased like the users of the Event Logs
+func splitTranscriptUser(value string) (string, string) {
+	value = strings.ToLower(value)
+	splitted := strings.Split(value, "\\")
+	if len(splitted) > 1 {
+		return splitted[1], splitted[0]
+	}
+	return value, ""
+}
+
+// parseTranscriptTime parse a local time of the machine in its time zone and convert it to UTC.
+// Without time zone (nil) the local time is kept as is.
+func parseTranscriptTime(value string, location *time.Location) (time.Time, bool) {
+	if location == nil {
+		t, err := time.Parse(transcriptTimeFormat, strings.TrimSpace(value))
+		return t, err == nil
+	}
+	t, err := time.ParseInLocation(transcriptTimeFormat, strings.TrimSpace(value), location)
+	return t.UTC(), err == nil
+}
+
+// newCommandFromTranscriptHeader create the Command template holding the session described by a transcript header
+func newCommandFromTranscriptHeader(header map[string]string, path string, location *time.Location) Command {
+	var c Command
+
+	c.TranscriptPath = path
+	c.User, c.UserDomain = splitTranscriptUser(header["username"])
+	c.RunAsUser, c.RunAsUserDomain = splitTranscriptUser(header["runas user"])
+	c.HostApplication = header["host application"]
+	if pid, err := strconv.Atoi(header["process id"]); err == nil {
+		c.ProcessID = pid
+	}
+
+	// "Machine: PC1 (Microsoft Windows NT 10.0.19044.0)"
+	c.Computer = header["machine"]
+	if index := strings.Index(c.Computer, " ("); index != -1 {
+		c.Computer = c.Computer[:index]
+	}
+
+	// The transcript times are local times of the machine
+	c.LocalTime = location == nil
+	if t, ok := parseTranscriptTime(header["start time"], location); ok {
+		c.Date = t
+		c.Timestamp = int(t.UnixMicro())
+	}
+	return c
+}
+
+// ParseTranscript parse the header and the commands of a PowerShell transcript.
+// Commands are timestamped with their "Command start time" if the invocation header is enabled, with the session start time otherwise.
+// The times are converted from the time zone of the machine (location), or kept as local times when it is nil.
+func ParseTranscript(path string, location *time.Location) []Command {
+	var commands []Command
+
+	file, err := os.Open(path)
+	handleErr(err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	buf := make([]byte, 0, 128*1024)
+	scanner.Buffer(buf, 2048*1024)
+
+	header := map[string]string{}
+	inHeader := false
+	headerDone := false
+	var session Command
+	var current *Command
+	var output []string
+	var date time.Time
+	var timestamp int
+
+	flush := func() {
+		if current != nil {
+			current.Output = strings.TrimSpace(strings.Join(output, "\n"))
+			commands = AddCommand(commands, *current)
+		}
+		current = nil
+		output = nil
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimRight(strings.TrimPrefix(scanner.Text(), "\ufeff"), "\r")
+
+		if line == transcriptSeparator {
+			if !headerDone && inHeader {
+				headerDone = true
+				session = newCommandFromTranscriptHeader(header, path, location)
+				date = session.Date
+				timestamp = session.Timestamp
+			}
+			continue
+		}
+
+		// A transcript may contain several sessions when -Append is used
+		if strings.HasSuffix(line, "transcript start") {
+			flush()
+			header = map[string]string{}
+			inHeader = true
+			headerDone = false
+			continue
+		}
+
+		if !headerDone {
+			if index := strings.Index(line, ": "); index != -1 && inHeader {
+				header[strings.ToLower(line[:index])] = strings.TrimSpace(line[index+2:])
+			} else if strings.HasSuffix(line, ":") && inHeader {
+				header[strings.ToLower(strings.TrimSuffix(line, ":"))] = ""
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "Command start time: ") {
+			flush()
+			if t, ok := parseTranscriptTime(strings.TrimPrefix(line, "Command start time: "), location); ok {
+				date = t
+				timestamp = int(t.UnixMicro())
+			}
+			continue
+		}
+
+		if strings.HasSuffix(line, "transcript end") {
+			flush()
+			continue
+		}
+
+		if matches := transcriptPromptRegex.FindStringSubmatch(line); len(matches) == 3 {
+			flush()
+			c := session
+			c.Date = date
+			c.Timestamp = timestamp
+			c.WorkingDirectory = matches[1]
+			c.Commandline = strings.TrimSpace(matches[2])
+			c.Evidence = []string{line}
+			current = &c
+			continue
+		}
+
+		if current != nil {
+			output = append(output, line)
+			current.Evidence = append(current.Evidence, line)
+		}
+	}
+	flush()
+	handleErr(scanner.Err())
+
+	return commands
+}
+
+// NewUserFromCommand create the User who started the PowerShell session
+func NewUserFromCommand(c Command) *User {
+	if c.User == "" {
+		return nil
+	}
+	return &User{FullName: c.User, Domain: c.UserDomain}
+}
+
+// ParseTranscripts parse PowerShell transcripts into Command, User and Computer entities.
+// The Computer of a transcript is its NetBIOS name, it is removed by MergeComputers when the FQDN is known.
+func ParseTranscripts(data []interface{}, paths []string, args map[string]interface{}) []interface{} {
+	var location *time.Location
+	if args["transcripts-timezone"] != nil && args["transcripts-timezone"].(string) != "" {
+		var err error
+		location, err = time.LoadLocation(args["transcripts-timezone"].(string))
+		handleErr(err)
+	}
+
+	for _, path := range paths {
+		var users []User
+		var computers []Computer
+
+		commands := ParseTranscript(path, location)
+		for _, c := range commands {
+			users = AddUser(users, NewUserFromCommand(c))
+			if c.RunAsUser != "" && c.RunAsUser != c.User {
+				users = AddUser(users, &User{FullName: c.RunAsUser, Domain: c.RunAsUserDomain})
+			}
+			if c.Computer != "" {
+				computers = AddComputer(computers, Computer{Name: c.Computer})
+			}
+		}
+
+		for _, entities := range []interface{}{commands, users, computers} {
+			data = unionEntities(data, entities, args["computer"].(string))
+		}
+	}
+	return data
+}
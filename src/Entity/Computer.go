@@ -1,10 +1,13 @@
 package Entity
 
-import ()
+import (
+	"strings"
+)
 
 type Computer struct {
-	Name   string
-	Domain string
+	Name    string
+	Domain  string
+	LogGaps []string // Log coverage gaps of the computer, computed by the Linker
 }
 
 func containsComputer(cs []Computer, c Computer) bool {
@@ -30,6 +33,32 @@ func UnionComputers(dest []Computer, src []Computer) []Computer {
 	return dest
 }
 
+// isNetBIOSName tell if a Computer is named by the NetBIOS name of a known FQDN ("WS01" and "ws01.corp.local")
+func isNetBIOSName(cs []Computer, c Computer) bool {
+	if c.Name == "" || strings.Contains(c.Name, ".") {
+		return false
+	}
+	prefix := strings.ToUpper(c.Name) + "."
+	for _, v := range cs {
+		if strings.HasPrefix(strings.ToUpper(v.Name), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeComputers remove the Computers named by their NetBIOS name (transcripts, Lnk tracker) when the FQDN of the Event Logs is known,
+// the Linker links the entities of these Computers to the FQDN
+func MergeComputers(cs []Computer) []Computer {
+	var res []Computer
+	for _, c := range cs {
+		if !isNetBIOSName(cs, c) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
 func GetComputer(data []PlasoLog) []Computer {
 	var res []Computer
 
@@ -1,6 +1,8 @@
 package Entity
 
 import (
+	"encoding/xml"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +25,25 @@ type Connection struct {
 
 	ProcessName string
 	ProcessId   int
+
+	// Network telemetry (Zeek, firewall logs)
+	Source        []string // Sysmon, Zeek, Firewall...
+	Uid           string   // Zeek connection identifier, shared by conn.log, dns.log and http.log
+	Service       string
+	State         string
+	Action        string // Firewall action (allow, deny...)
+	Duration      float64
+	BytesSent     int
+	BytesReceived int
+
+	// Zeek http.log, the method, user agent and status are the ones of the first request of the connection
+	HttpMethod    string
+	HttpHost      string
+	HttpUris      []string // Every URI requested on the connection (keep-alive)
+	HttpUserAgent string
+	HttpStatus    int
+
+	Evidence []string
 }
 
 type Host struct {
@@ -30,6 +51,180 @@ type Host struct {
 	IP     string
 }
 
+// connectionKey is the 5-tuple of a flow without its protocol, which may be unknown (compared by sameConnection)
+type connectionKey struct {
+	sourceIP        string
+	sourcePort      int
+	destinationIP   string
+	destinationPort int
+}
+
+var (
+	// ProtocolNumberMap map the IANA protocol numbers used by some firewalls to their names
+	ProtocolNumberMap = map[string]string{
+		"1":  "icmp",
+		"6":  "tcp",
+		"17": "udp",
+		"58": "icmp6",
+	}
+)
+
+// normalizeProtocol return the lower case name of a transport protocol
+func normalizeProtocol(protocol string) string {
+	protocol = strings.ToLower(strings.TrimSpace(protocol))
+	if name, ok := ProtocolNumberMap[protocol]; ok {
+		return name
+	}
+	return protocol
+}
+
+// sameConnection tell if two descriptions of a connection are the same flow: same Zeek uid,
+// or same 5-tuple (the protocol is ignored if unknown) seen in a window of approx nanoseconds
+func sameConnection(a Connection, b Connection, approx int) bool {
+	if a.Uid != "" && b.Uid != "" {
+		return a.Uid == b.Uid
+	}
+
+	if a.SourceIP != b.SourceIP || a.DestinationIP != b.DestinationIP || a.SourcePort != b.SourcePort || a.DestinationPort != b.DestinationPort {
+		return false
+	}
+	if a.Protocol != "" && b.Protocol != "" && normalizeProtocol(a.Protocol) != normalizeProtocol(b.Protocol) {
+		return false
+	}
+	return b.Timestamp-approx < a.Timestamp && a.Timestamp < b.Timestamp+approx
+}
+
+func mergeConnection(dest Connection, src Connection) Connection {
+	if src.Timestamp != 0 && (dest.Timestamp == 0 || src.Timestamp < dest.Timestamp) {
+		dest.Timestamp = src.Timestamp
+		dest.Date = src.Date
+	}
+
+	if dest.Protocol == "" {
+		dest.Protocol = src.Protocol
+	}
+	if dest.Computer == "" {
+		dest.Computer = src.Computer
+	}
+	if dest.User == "" {
+		dest.User = src.User
+		dest.UserDomain = src.UserDomain
+	}
+	if dest.ProcessName == "" {
+		dest.ProcessName = src.ProcessName
+		dest.ProcessId = src.ProcessId
+	}
+	if dest.Uid == "" {
+		dest.Uid = src.Uid
+	}
+	if dest.Service == "" {
+		dest.Service = src.Service
+	}
+	if dest.State == "" {
+		dest.State = src.State
+	}
+	if dest.Action == "" {
+		dest.Action = src.Action
+	}
+	if dest.Duration == 0 {
+		dest.Duration = src.Duration
+	}
+	if dest.BytesSent == 0 {
+		dest.BytesSent = src.BytesSent
+	}
+	if dest.BytesReceived == 0 {
+		dest.BytesReceived = src.BytesReceived
+	}
+	if dest.HttpHost == "" {
+		dest.HttpMethod = src.HttpMethod
+		dest.HttpHost = src.HttpHost
+		dest.HttpUserAgent = src.HttpUserAgent
+		dest.HttpStatus = src.HttpStatus
+	}
+	for _, uri := range src.HttpUris {
+		found := false
+		for _, v := range dest.HttpUris {
+			if v == uri {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dest.HttpUris = append(dest.HttpUris, uri)
+		}
+	}
+
+	for _, source := range src.Source {
+		found := false
+		for _, v := range dest.Source {
+			if v == source {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dest.Source = append(dest.Source, source)
+		}
+	}
+
+	dest.Evidence = append(dest.Evidence, src.Evidence...)
+	return dest
+}
+
+// MergeConnections merge the endpoint (Sysmon) and network (Zeek, firewall) descriptions of the same flows.
+// The descriptions are merged by Zeek uid, then compared by 5-tuple in a window of approx nanoseconds.
+func MergeConnections(connections []Connection, approx int) []Connection {
+	var merged []Connection
+	uids := map[string]int{}
+	for _, c := range connections {
+		if c.Uid != "" {
+			if i, ok := uids[c.Uid]; ok {
+				merged[i] = mergeConnection(merged[i], c)
+				continue
+			}
+			uids[c.Uid] = len(merged)
+		}
+		merged = append(merged, c)
+	}
+
+	buckets := map[connectionKey][]int{}
+	for i, c := range merged {
+		key := connectionKey{c.SourceIP, c.SourcePort, c.DestinationIP, c.DestinationPort}
+		buckets[key] = append(buckets[key], i)
+	}
+
+	// In a bucket sorted by time, a flow is merged with the next descriptions until the window is over
+	removed := make([]bool, len(merged))
+	for _, bucket := range buckets {
+		sort.SliceStable(bucket, func(a, b int) bool {
+			return merged[bucket[a]].Timestamp < merged[bucket[b]].Timestamp
+		})
+		for k, i := range bucket {
+			if removed[i] {
+				continue
+			}
+			for _, j := range bucket[k+1:] {
+				if merged[j].Timestamp-merged[i].Timestamp >= approx {
+					break
+				}
+				if !removed[j] && sameConnection(merged[i], merged[j], approx) {
+					merged[i] = mergeConnection(merged[i], merged[j])
+					removed[j] = true
+				}
+			}
+		}
+	}
+
+	// The order of the first descriptions is kept
+	var res []Connection
+	for i, c := range merged {
+		if !removed[i] {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
 func UnionConnections(dest []Connection, src []Connection) []Connection {
 	for _, c := range src {
 		dest = AddConnection(dest, c)
@@ -91,5 +286,9 @@ func NewConnectionFromSysmon3(evtx EvtxLog) Connection {
 	}
 
 	c.Computer = evtx.System.Computer
+	c.Source = []string{"Sysmon"}
+	xmlString, err := xml.Marshal(evtx)
+	handleErr(err)
+	c.Evidence = append(c.Evidence, string(xmlString))
 	return c
 }
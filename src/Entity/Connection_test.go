@@ -0,0 +1,60 @@
+package Entity
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSameConnection(t *testing.T) {
+	approx := int(2 * time.Second)
+	flow := Connection{SourceIP: "10.0.0.1", SourcePort: 49152, DestinationIP: "93.184.216.34", DestinationPort: 443, Protocol: "tcp", Timestamp: int(10 * time.Second)}
+
+	tests := []struct {
+		name string
+		a    Connection
+		b    Connection
+		same bool
+	}{
+		{"same flow", flow, flow, true},
+		{"same uid", Connection{Uid: "C1"}, Connection{Uid: "C1", SourceIP: "10.0.0.2"}, true},
+		{"different uids", Connection{Uid: "C1", SourceIP: "10.0.0.1"}, Connection{Uid: "C2", SourceIP: "10.0.0.1"}, false},
+		{"uid and 5-tuple", Connection{Uid: "C1", SourceIP: "10.0.0.1", SourcePort: 49152, DestinationIP: "93.184.216.34", DestinationPort: 443, Timestamp: flow.Timestamp}, flow, true},
+		{"in the window", flow, Connection{SourceIP: "10.0.0.1", SourcePort: 49152, DestinationIP: "93.184.216.34", DestinationPort: 443, Timestamp: flow.Timestamp + approx - 1}, true},
+		{"out of the window", flow, Connection{SourceIP: "10.0.0.1", SourcePort: 49152, DestinationIP: "93.184.216.34", DestinationPort: 443, Timestamp: flow.Timestamp + approx}, false},
+		{"another source port", flow, Connection{SourceIP: "10.0.0.1", SourcePort: 49153, DestinationIP: "93.184.216.34", DestinationPort: 443, Timestamp: flow.Timestamp}, false},
+		{"another destination", flow, Connection{SourceIP: "10.0.0.1", SourcePort: 49152, DestinationIP: "93.184.216.35", DestinationPort: 443, Timestamp: flow.Timestamp}, false},
+		{"protocol number", flow, Connection{SourceIP: "10.0.0.1", SourcePort: 49152, DestinationIP: "93.184.216.34", DestinationPort: 443, Protocol: "6", Timestamp: flow.Timestamp}, true},
+		{"unknown protocol", flow, Connection{SourceIP: "10.0.0.1", SourcePort: 49152, DestinationIP: "93.184.216.34", DestinationPort: 443, Timestamp: flow.Timestamp}, true},
+		{"another protocol", flow, Connection{SourceIP: "10.0.0.1", SourcePort: 49152, DestinationIP: "93.184.216.34", DestinationPort: 443, Protocol: "udp", Timestamp: flow.Timestamp}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if same := sameConnection(test.a, test.b, approx); same != test.same {
+				t.Errorf("sameConnection(%+v, %+v) = %v, want %v", test.a, test.b, same, test.same)
+			}
+			if same := sameConnection(test.b, test.a, approx); same != test.same {
+				t.Errorf("sameConnection(%+v, %+v) = %v, want %v", test.b, test.a, same, test.same)
+			}
+		})
+	}
+}
+
+func TestMergeConnectionsHttpUris(t *testing.T) {
+	conn := Connection{Uid: "C1", SourceIP: "10.0.0.1", DestinationIP: "93.184.216.34", Source: []string{"zeek"}}
+	first := Connection{Uid: "C1", HttpMethod: "GET", HttpHost: "example.com", HttpUris: []string{"/"}, HttpStatus: 200}
+	second := Connection{Uid: "C1", HttpMethod: "POST", HttpHost: "example.com", HttpUris: []string{"/login"}, HttpStatus: 302}
+	again := Connection{Uid: "C1", HttpMethod: "GET", HttpHost: "example.com", HttpUris: []string{"/"}, HttpStatus: 200}
+
+	merged := MergeConnections([]Connection{conn, first, second, again}, 0)
+	if len(merged) != 1 {
+		t.Fatalf("%d connections, want 1", len(merged))
+	}
+	if !reflect.DeepEqual(merged[0].HttpUris, []string{"/", "/login"}) {
+		t.Errorf("HttpUris = %v, want [/ /login]", merged[0].HttpUris)
+	}
+	if merged[0].HttpMethod != "GET" || merged[0].HttpStatus != 200 {
+		t.Errorf("request %s %d, want the first one GET 200", merged[0].HttpMethod, merged[0].HttpStatus)
+	}
+}
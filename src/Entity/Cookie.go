@@ -0,0 +1,145 @@
+package Entity
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Cookie is a cookie stored by a browser (converted to a User -[COOKIE]-> Domain relationship)
+type Cookie struct {
+	Date          time.Time
+	Timestamp     int
+	TimestampDesc string
+	LastAccess    time.Time
+
+	Browser    string
+	Host       string // Domain of the cookie without the leading "."
+	Name       string
+	Path       string
+	Url        string
+	Secure     bool
+	HttpOnly   bool
+	Persistent bool
+
+	User     string
+	Computer string
+	Evidence []string
+}
+
+var (
+	// "Cookie:bob@example.com/" in MSIE index.dat and WebCache cookie containers
+	msieCookieRegex = regexp.MustCompile(`^Cookie:([^@]*)@([^/]*)(/.*)?$`)
+)
+
+// findCookie find the same cookie reported for another of its timestamps (creation, last access, expiration)
+func findCookie(cookies []Cookie, c Cookie) int {
+	for i, v := range cookies {
+		if v.Host == c.Host && v.Name == c.Name && v.Path == c.Path && v.Browser == c.Browser && v.User == c.User && v.Computer == c.Computer {
+			return i
+		}
+	}
+	return -1
+}
+
+func mergeCookie(dest Cookie, src Cookie) Cookie {
+	if src.Timestamp != 0 && (dest.Timestamp == 0 || src.Timestamp < dest.Timestamp) {
+		dest.Timestamp = src.Timestamp
+		dest.Date = src.Date
+		dest.TimestampDesc = src.TimestampDesc
+	}
+
+	if src.LastAccess.After(dest.LastAccess) {
+		dest.LastAccess = src.LastAccess
+	}
+
+	dest.Evidence = append(dest.Evidence, src.Evidence...)
+	return dest
+}
+
+func AddCookie(cookies []Cookie, c Cookie) []Cookie {
+	if c.Host == "" {
+		return cookies
+	}
+
+	i := findCookie(cookies, c)
+	if i == -1 {
+		cookies = append(cookies, c)
+	} else {
+		cookies[i] = mergeCookie(cookies[i], c)
+	}
+	return cookies
+}
+
+func UnionCookies(dest []Cookie, src []Cookie) []Cookie {
+	for _, c := range src {
+		dest = AddCookie(dest, c)
+	}
+	return dest
+}
+
+// setCookieTime assign the plaso timestamp to the cookie, the expiration time is not an activity of the user
+func setCookieTime(c Cookie, pl PlasoLog) Cookie {
+	if pl.Timestamp == 0 || strings.Contains(strings.ToLower(pl.TimestampDesc), "expiration") {
+		return c
+	}
+
+	var utc, _ = time.LoadLocation("UTC")
+	t := time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	c.Date = t
+	c.Timestamp = int(pl.Timestamp)
+	c.TimestampDesc = pl.TimestampDesc
+	if strings.Contains(strings.ToLower(pl.TimestampDesc), "access") {
+		c.LastAccess = t
+	}
+	return c
+}
+
+// NewCookieFromBrowser handle Chrome (and Chromium based browsers) and Firefox cookies
+func NewCookieFromBrowser(pl PlasoLog) Cookie {
+	var c Cookie
+
+	c.Browser = getBrowser(pl)
+	c.Host = strings.TrimPrefix(strings.ToLower(pl.Host), ".")
+	c.Name = pl.CookieName
+	c.Path = pl.Path
+	c.Url = pl.Url
+	c.Secure = pl.Secure
+	c.HttpOnly = pl.HttpOnly
+	c.Persistent = pl.Persistent
+	c = setCookieTime(c, pl)
+
+	u := NewUserFromPath(pl.Filename)
+	if u != nil {
+		c.User = u.FullName
+	}
+
+	c.Evidence = append(c.Evidence, pl.Message)
+	return c
+}
+
+// NewCookieFromMsie handle the "Cookie:" entries of MSIE index.dat and WebCache
+func NewCookieFromMsie(pl PlasoLog) Cookie {
+	var c Cookie
+
+	matches := msieCookieRegex.FindStringSubmatch(pl.Url)
+	if len(matches) != 4 {
+		return c
+	}
+
+	c.Browser = getBrowser(pl)
+	c.User = strings.ToLower(matches[1])
+	c.Host = strings.TrimPrefix(strings.ToLower(matches[2]), ".")
+	c.Path = matches[3]
+	c.Url = pl.Url
+	c = setCookieTime(c, pl)
+
+	if c.User == "" {
+		if u := NewUserFromPath(pl.Filename); u != nil {
+			c.User = u.FullName
+		}
+	}
+
+	c.Evidence = append(c.Evidence, pl.Message)
+	return c
+}
@@ -0,0 +1,124 @@
+package Entity
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+type Detection struct {
+	Date      time.Time
+	Timestamp int
+	Computer  string
+
+	Type        string // "Malware Detected" or "Malware Action Taken"
+	Title       string
+	DetectionID string
+	ThreatName  string
+	Severity    string
+	Category    string
+	Action      string
+
+	// File and Process named in the detection
+	FullPath    string
+	Filename    string
+	ProcessName string
+
+	User       string
+	UserDomain string
+	Evidence   []string
+}
+
+func AddDetection(ds []Detection, d Detection) []Detection {
+	if d.Title != "" {
+		ds = append(ds, d)
+	}
+	return ds
+}
+
+func UnionDetections(dest []Detection, src []Detection) []Detection {
+	for _, d := range src {
+		dest = AddDetection(dest, d)
+	}
+	return dest
+}
+
+// parseDefenderPath extract the first path from a Defender "Path" field.
+// ex: "file:_C:\Users\bob\evil.exe;process:_pid:1234" or "containerfile:_C:\a.zip->(Zip)"
+func parseDefenderPath(path string) string {
+	for _, resource := range strings.Split(path, ";") {
+		index := strings.Index(resource, ":_")
+		if index == -1 || !strings.Contains(resource[:index], "file") {
+			continue
+		}
+		resource = resource[index+2:]
+		if i := strings.Index(resource, "->"); i != -1 {
+			resource = resource[:i]
+		}
+		return resource
+	}
+	return ""
+}
+
+func NewDetectionFromDefender(evtx EvtxLog) Detection {
+	var d Detection
+
+	d.Computer = evtx.System.Computer
+	t, err := time.Parse(time.RFC3339Nano, evtx.System.TimeCreated.SystemTime)
+	handleErr(err)
+	d.Date = t
+	d.Timestamp = int(t.UnixMicro())
+
+	switch evtx.System.EventID {
+	case 1116:
+		d.Type = "Malware Detected"
+	case 1117:
+		d.Type = "Malware Action Taken"
+	default:
+		return d
+	}
+
+	d.DetectionID = GetDataValue(evtx, "Detection ID")
+	d.ThreatName = GetDataValue(evtx, "Threat Name")
+	d.Severity = GetDataValue(evtx, "Severity Name")
+	d.Category = GetDataValue(evtx, "Category Name")
+	d.Action = GetDataValue(evtx, "Action Name")
+
+	d.FullPath = strings.ToLower(parseDefenderPath(GetDataValue(evtx, "Path")))
+	d.Filename = getFilename(d.FullPath)
+
+	tmp := GetDataValue(evtx, "Process Name")
+	if tmp != "Not Found." && tmp != "Unknown" {
+		d.ProcessName = strings.ToLower(tmp)
+	}
+
+	tmp = GetDataValue(evtx, "Detection User")
+	if tmp != "Not Found." {
+		splitted := strings.Split(tmp, "\\")
+		if len(splitted) > 1 {
+			d.UserDomain = strings.ToLower(splitted[0])
+			d.User = strings.ToLower(splitted[1])
+		} else {
+			d.User = strings.ToLower(tmp)
+		}
+	}
+
+	d.Title = "Windows Defender detected " + d.ThreatName + " in " + d.FullPath + "."
+	if d.Type == "Malware Action Taken" {
+		d.Title = "Windows Defender took action " + d.Action + " on " + d.ThreatName + " in " + d.FullPath + "."
+	}
+
+	xmlString, err := xml.Marshal(evtx)
+	handleErr(err)
+	d.Evidence = append(d.Evidence, string(xmlString))
+
+	return d
+}
+
+// NewUserFromDetection create the User found in the "Detection User" field (if any)
+func NewUserFromDetection(d Detection) *User {
+	if d.User == "" {
+		return nil
+	}
+	return &User{FullName: d.User, Domain: d.UserDomain}
+}
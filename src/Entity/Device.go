@@ -0,0 +1,336 @@
+package Entity
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+type Device struct {
+	Vendor      string
+	Product     string
+	Revision    string
+	Serial      string
+	VolumeGUID  string
+	DriveLetter string
+	VolumeLabel string
+
+	FirstConnected          time.Time
+	FirstConnectedTimestamp int
+	LastConnected           time.Time
+	LastConnectedTimestamp  int
+
+	Users    []string // Users who mounted the volume (MountPoints2)
+	Computer string
+	Evidence []string
+}
+
+var (
+	// setupapi.dev.log: "Device Install (Hardware initiated) - USBSTOR\Disk&Ven_SanDisk&Prod_Cruzer&Rev_1.26\20043512345678&0"
+	setupApiDeviceRegex = regexp.MustCompile(`(?i)USBSTOR[\\#]Disk&Ven_([^&]*)&Prod_([^&]*)&Rev_([^\\#]*)[\\#]([^\\#\s]+)`)
+	// Windows Portable Devices: "...#_??_USBSTOR#DISK&VEN_SANDISK&PROD_CRUZER&REV_1.26#20043512345678&0#"
+	portableDeviceRegex = regexp.MustCompile(`(?i)USBSTOR#Disk&Ven_([^&]*)&Prod_([^&]*)&Rev_([^#]*)#([^#]+)#`)
+	// Windows Portable Devices: "FriendlyName: [REG_SZ] E:\"
+	friendlyNameRegex = regexp.MustCompile(`FriendlyName: \[REG_SZ\] ([^\[]*)`)
+)
+
+// findDevice find a device on the serial of the hardware, the GUID of the volume,
+// or the drive letter when the volumes have no GUID (drive letters are reused)
+func findDevice(devices []Device, device Device) int {
+	for i, d := range devices {
+		if d.Computer != device.Computer {
+			continue
+		}
+		if d.Serial != "" && device.Serial != "" {
+			if d.Serial == device.Serial {
+				return i
+			}
+			continue
+		}
+		if d.VolumeGUID != "" || device.VolumeGUID != "" {
+			if d.VolumeGUID == device.VolumeGUID {
+				return i
+			}
+			continue
+		}
+		if d.DriveLetter != "" && d.DriveLetter == device.DriveLetter {
+			return i
+		}
+	}
+	return -1
+}
+
+func mergeDevice(dest Device, src Device) Device {
+	if dest.Vendor == "" {
+		dest.Vendor = src.Vendor
+	}
+
+	if dest.Product == "" {
+		dest.Product = src.Product
+	}
+
+	if dest.Revision == "" {
+		dest.Revision = src.Revision
+	}
+
+	if dest.Serial == "" {
+		dest.Serial = src.Serial
+	}
+
+	if dest.VolumeGUID == "" {
+		dest.VolumeGUID = src.VolumeGUID
+	}
+
+	if dest.DriveLetter == "" {
+		dest.DriveLetter = src.DriveLetter
+	}
+
+	if dest.VolumeLabel == "" {
+		dest.VolumeLabel = src.VolumeLabel
+	}
+
+	if src.FirstConnectedTimestamp != 0 && (dest.FirstConnectedTimestamp == 0 || src.FirstConnectedTimestamp < dest.FirstConnectedTimestamp) {
+		dest.FirstConnected = src.FirstConnected
+		dest.FirstConnectedTimestamp = src.FirstConnectedTimestamp
+	}
+
+	if src.LastConnectedTimestamp > dest.LastConnectedTimestamp {
+		dest.LastConnected = src.LastConnected
+		dest.LastConnectedTimestamp = src.LastConnectedTimestamp
+	}
+
+	for _, user := range src.Users {
+		found := false
+		for _, u := range dest.Users {
+			if u == user {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dest.Users = append(dest.Users, user)
+		}
+	}
+
+	dest.Evidence = append(dest.Evidence, src.Evidence...)
+	return dest
+}
+
+func AddDevice(devices []Device, d Device) []Device {
+	if d.Serial == "" && d.VolumeGUID == "" && d.DriveLetter == "" {
+		return devices
+	}
+
+	i := findDevice(devices, d)
+	if i == -1 {
+		devices = append(devices, d)
+	} else {
+		devices[i] = mergeDevice(devices[i], d)
+	}
+	return devices
+}
+
+func UnionDevices(dest []Device, src []Device) []Device {
+	for _, d := range src {
+		dest = AddDevice(dest, d)
+	}
+	return dest
+}
+
+// findMountedDevice find the device mounted on a drive letter when a volume was mounted,
+// the device with the closest last connection wins
+func findMountedDevice(devices []Device, volume Device, approx int) int {
+	found := -1
+	delta := approx + 1
+	for i, d := range devices {
+		if d.Computer != volume.Computer || d.DriveLetter == "" || d.VolumeGUID != "" || d.LastConnectedTimestamp == 0 {
+			continue
+		}
+		if d.FirstConnectedTimestamp != 0 && volume.LastConnectedTimestamp < d.FirstConnectedTimestamp-approx {
+			continue
+		}
+
+		v := d.LastConnectedTimestamp - volume.LastConnectedTimestamp
+		if v < 0 {
+			v = -v
+		}
+		if v < delta {
+			found = i
+			delta = v
+		}
+	}
+	return found
+}
+
+// MergeDevices merge the volumes only known by their GUID (MountPoints2) into the device mounted on a drive letter at the same time.
+// MountedDevices would map the GUID to the serial but plaso does not decode its binary values.
+func MergeDevices(devices []Device, approx int) []Device {
+	var merged []Device
+	var volumes []Device
+	for _, d := range devices {
+		if d.Serial == "" && d.DriveLetter == "" && d.VolumeGUID != "" {
+			volumes = append(volumes, d)
+		} else {
+			merged = append(merged, d)
+		}
+	}
+
+	for _, v := range volumes {
+		i := findMountedDevice(merged, v, approx)
+		if i == -1 {
+			merged = append(merged, v)
+		} else {
+			merged[i] = mergeDevice(merged[i], v)
+		}
+	}
+	return merged
+}
+
+// trimDevicePrefix remove prefixes like "Ven_", "Prod_" or "Rev_" from USBSTOR identifiers
+func trimDevicePrefix(value string, prefix string) string {
+	if strings.HasPrefix(strings.ToLower(value), strings.ToLower(prefix)) {
+		return value[len(prefix):]
+	}
+	return value
+}
+
+// trimDeviceSerial remove the "&0" suffix added by windows to the serial number of a USB device.
+// The serial is upper cased as the Windows Portable Devices keys are.
+func trimDeviceSerial(serial string) string {
+	if i := strings.LastIndex(serial, "&"); i != -1 {
+		serial = serial[:i]
+	}
+	return strings.ToUpper(serial)
+}
+
+// setConnectionTime assign the plaso timestamp to the first or last connection time of the device
+func setConnectionTime(d Device, pl PlasoLog) Device {
+	if pl.Timestamp == 0 {
+		return d
+	}
+
+	var utc, _ = time.LoadLocation("UTC")
+	t := time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	if strings.Contains(strings.ToLower(pl.TimestampDesc), "first") {
+		d.FirstConnected = t
+		d.FirstConnectedTimestamp = int(pl.Timestamp)
+	} else {
+		d.LastConnected = t
+		d.LastConnectedTimestamp = int(pl.Timestamp)
+	}
+	return d
+}
+
+func NewDeviceFromUSBStor(pl PlasoLog) Device {
+	var d Device
+
+	d.Vendor = trimDevicePrefix(pl.Vendor, "Ven_")
+	d.Product = trimDevicePrefix(pl.Product, "Prod_")
+	d.Revision = trimDevicePrefix(pl.Revision, "Rev_")
+	d.Serial = trimDeviceSerial(pl.Serial)
+	d = setConnectionTime(d, pl)
+
+	d.Evidence = append(d.Evidence, pl.Message)
+	return d
+}
+
+func NewDeviceFromUSB(pl PlasoLog) Device {
+	var d Device
+
+	d.Vendor = pl.Vendor
+	d.Product = pl.Product
+	d.Serial = trimDeviceSerial(pl.Serial)
+	d = setConnectionTime(d, pl)
+
+	d.Evidence = append(d.Evidence, pl.Message)
+	return d
+}
+
+func NewDeviceFromSetupApi(pl PlasoLog) Device {
+	var d Device
+
+	// Device Install (Hardware initiated) - USBSTOR\Disk&Ven_SanDisk&Prod_Cruzer&Rev_1.26\20043512345678&0
+	if !strings.HasPrefix(pl.EntryType, "Device Install") {
+		return d
+	}
+	matches := setupApiDeviceRegex.FindStringSubmatch(pl.EntryType)
+	if len(matches) != 5 {
+		return d
+	}
+
+	d.Vendor = matches[1]
+	d.Product = matches[2]
+	d.Revision = matches[3]
+	d.Serial = trimDeviceSerial(matches[4])
+
+	var utc, _ = time.LoadLocation("UTC")
+	d.FirstConnected = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	d.FirstConnectedTimestamp = int(pl.Timestamp)
+
+	d.Evidence = append(d.Evidence, pl.Message)
+	return d
+}
+
+func NewDeviceFromMountPoints2(pl PlasoLog) Device {
+	var d Device
+
+	// Volumes are named by GUID "{...}", remote drives start with "#" and drives are letters
+	switch {
+	case strings.HasPrefix(pl.Name, "{"):
+		d.VolumeGUID = strings.ToLower(pl.Name)
+		break
+	case strings.HasPrefix(pl.Name, "#"):
+		// Remote Drive, not a device
+		return d
+	case len(pl.Name) > 0:
+		d.DriveLetter = strings.ToUpper(pl.Name[:1]) + ":"
+		if d.DriveLetter == "C:" {
+			// System volume, not a device
+			return Device{}
+		}
+		break
+	}
+	d.VolumeLabel = pl.Label
+
+	u := NewUserFromPath(pl.Filename)
+	if u != nil {
+		d.Users = append(d.Users, u.FullName)
+	}
+
+	var utc, _ = time.LoadLocation("UTC")
+	d.LastConnected = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	d.LastConnectedTimestamp = int(pl.Timestamp)
+
+	d.Evidence = append(d.Evidence, pl.Message)
+	return d
+}
+
+// NewDeviceFromPortableDevice map a "Windows Portable Devices" key to the serial and drive letter of a USB device.
+// ex: ...\Windows Portable Devices\Devices\WPDBUSENUMROOT#UMB#2&37C186B&1&STORAGE#VOLUME#_??_USBSTOR#DISK&VEN_SANDISK&PROD_CRUZER&REV_1.26#20043512345678&0#
+func NewDeviceFromPortableDevice(pl PlasoLog) Device {
+	var d Device
+
+	matches := portableDeviceRegex.FindStringSubmatch(pl.KeyPath)
+	if len(matches) != 5 {
+		return d
+	}
+
+	d.Vendor = matches[1]
+	d.Product = matches[2]
+	d.Revision = matches[3]
+	d.Serial = trimDeviceSerial(matches[4])
+
+	// FriendlyName is either the drive letter ("E:\") or the volume label
+	matches = friendlyNameRegex.FindStringSubmatch(pl.Values)
+	if len(matches) == 2 {
+		name := strings.TrimSpace(matches[1])
+		if len(name) >= 2 && name[1] == ':' {
+			d.DriveLetter = strings.ToUpper(name[:2])
+		} else {
+			d.VolumeLabel = name
+		}
+	}
+
+	d.Evidence = append(d.Evidence, pl.Message)
+	return d
+}
@@ -0,0 +1,59 @@
+package Entity
+
+import (
+	"testing"
+)
+
+func TestMergeDevices(t *testing.T) {
+	minute := 60000000
+	cruzer := Device{Serial: "20043512345678", DriveLetter: "E:", FirstConnectedTimestamp: 10 * minute, LastConnectedTimestamp: 20 * minute, Computer: "pc"}
+	kingston := Device{Serial: "001CC0EC3450", DriveLetter: "E:", FirstConnectedTimestamp: 30 * minute, LastConnectedTimestamp: 40 * minute, Computer: "pc"}
+
+	tests := []struct {
+		name    string
+		devices []Device
+		volume  Device
+		serial  string // Serial of the device given the GUID of the volume, empty if none
+	}{
+		{
+			"mounted with the device",
+			[]Device{cruzer},
+			Device{VolumeGUID: "{a}", LastConnectedTimestamp: 20*minute + 5, Computer: "pc"},
+			cruzer.Serial,
+		},
+		{
+			"drive letter reused",
+			[]Device{cruzer, kingston},
+			Device{VolumeGUID: "{a}", LastConnectedTimestamp: 40*minute - 5, Computer: "pc"},
+			kingston.Serial,
+		},
+		{
+			"out of the window",
+			[]Device{cruzer},
+			Device{VolumeGUID: "{a}", LastConnectedTimestamp: 25 * minute, Computer: "pc"},
+			"",
+		},
+		{
+			"another computer",
+			[]Device{cruzer},
+			Device{VolumeGUID: "{a}", LastConnectedTimestamp: 20 * minute, Computer: "other"},
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			devices := MergeDevices(append(test.devices, test.volume), minute)
+
+			serial := ""
+			for _, d := range devices {
+				if d.VolumeGUID == test.volume.VolumeGUID {
+					serial = d.Serial
+				}
+			}
+			if serial != test.serial {
+				t.Errorf("volume merged into %q, want %q", serial, test.serial)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package Entity
+
+import (
+	"time"
+)
+
+// DnsQuery is a DNS request seen on the network (Zeek dns.log). The answers give a domain to the Host nodes.
+type DnsQuery struct {
+	Date      time.Time
+	Timestamp int // Nanoseconds, like the Connections
+
+	Query        string
+	QueryType    string // A, AAAA, CNAME, TXT...
+	ResponseCode string // NOERROR, NXDOMAIN...
+	Answers      []string
+
+	SourceIP        string
+	SourcePort      int
+	DestinationIP   string // Resolver
+	DestinationPort int
+	Protocol        string
+	Uid             string // Zeek connection identifier
+
+	Computer string
+	Evidence []string
+}
+
+// dnsQueryKey identify the same query of the same client (a query may be logged once per resolver answer)
+type dnsQueryKey struct {
+	uid       string
+	query     string
+	queryType string
+	timestamp int
+}
+
+// indexDnsQueries return the position of every query of a slice with a Zeek uid, updated by addDnsQuery
+func indexDnsQueries(queries []DnsQuery) map[dnsQueryKey]int {
+	index := map[dnsQueryKey]int{}
+	for i, q := range queries {
+		if q.Uid != "" {
+			index[dnsQueryKey{q.Uid, q.Query, q.QueryType, q.Timestamp}] = i
+		}
+	}
+	return index
+}
+
+func addDnsQuery(queries []DnsQuery, index map[dnsQueryKey]int, q DnsQuery) []DnsQuery {
+	if q.Query == "" {
+		return queries
+	}
+
+	key := dnsQueryKey{q.Uid, q.Query, q.QueryType, q.Timestamp}
+	i, found := index[key]
+	if q.Uid == "" || !found {
+		if q.Uid != "" {
+			index[key] = len(queries)
+		}
+		queries = append(queries, q)
+		return queries
+	}
+
+	for _, answer := range q.Answers {
+		found := false
+		for _, v := range queries[i].Answers {
+			if v == answer {
+				found = true
+				break
+			}
+		}
+		if !found {
+			queries[i].Answers = append(queries[i].Answers, answer)
+		}
+	}
+	queries[i].Evidence = append(queries[i].Evidence, q.Evidence...)
+	return queries
+}
+
+func UnionDnsQueries(dest []DnsQuery, src []DnsQuery) []DnsQuery {
+	index := indexDnsQueries(dest)
+	for _, q := range src {
+		dest = addDnsQuery(dest, index, q)
+	}
+	return dest
+}
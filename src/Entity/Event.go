@@ -4,6 +4,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -15,6 +16,10 @@ type Event struct {
 	Computer  string
 	Evidence  []string
 
+	// Evtx record (Alerts are linked by record ID, computer and channel)
+	RecordID int
+	Channel  string
+
 	// Event Information
 	Title                 string
 	Type                  string
@@ -56,6 +61,8 @@ func constructEvent(evtx EvtxLog) Event {
 	e := Event{}
 
 	e.Computer = evtx.System.Computer
+	e.RecordID, _ = strconv.Atoi(evtx.System.EventRecordID)
+	e.Channel = evtx.System.Channel
 	t, err := time.Parse(time.RFC3339Nano, evtx.System.TimeCreated.SystemTime)
 	handleErr(err)
 	e.Date = t
@@ -0,0 +1,69 @@
+package Entity
+
+// Confidence that a Process was really executed according to the artefact it comes from
+const (
+	ConfidenceConfirmed = "Confirmed" // Process creation logged by the system
+	ConfidenceHigh      = "High"      // Artefact written when the program runs
+	ConfidenceMedium    = "Medium"    // Artefact usually written on execution, but also on installation or scan
+	ConfidenceLow       = "Low"       // Artefact only proves the presence of the program
+)
+
+var (
+	// ExecutionConfidenceMap map an execution artefact to the confidence of the execution it proves.
+	// ShimCache (AppCompatCache) is written when a file is shimmed or browsed and only proves presence,
+	// Amcache is also filled by the Program Compatibility Assistant scans and shell histories are written when the shell exits
+	// (the command may have failed, and the timestamps are only recorded with HISTTIMEFORMAT).
+	ExecutionConfidenceMap = map[string]string{
+		"Security 4688": ConfidenceConfirmed,
+		"Sysmon 1":      ConfidenceConfirmed,
+		"Sudo":          ConfidenceConfirmed,
+		"Prefetch":      ConfidenceHigh,
+		"BAM":           ConfidenceHigh,
+		"UserAssist":    ConfidenceHigh,
+		"SRUM":          ConfidenceHigh,
+		"KnowledgeC":    ConfidenceHigh,
+		"Cron":          ConfidenceHigh,
+		"RunMRU":        ConfidenceMedium,
+		"Amcache":       ConfidenceMedium,
+		"Shell History": ConfidenceMedium,
+		"Lnk":           ConfidenceLow,
+		"ShellBags":     ConfidenceLow,
+		"ShimCache":     ConfidenceLow,
+	}
+
+	confidenceRank = map[string]int{
+		"":                  0,
+		ConfidenceLow:       1,
+		ConfidenceMedium:    2,
+		ConfidenceHigh:      3,
+		ConfidenceConfirmed: 4,
+	}
+)
+
+// setExecutionEvidence record the artefact a Process comes from and the confidence of its execution
+func setExecutionEvidence(p Process, artefact string) Process {
+	p.ExecutionArtefacts = []string{artefact}
+	p.ExecutionConfidence = ExecutionConfidenceMap[artefact]
+	return p
+}
+
+// mergeExecutionEvidence keep every artefact of the merged processes and the highest confidence
+func mergeExecutionEvidence(dest Process, src Process) Process {
+	for _, artefact := range src.ExecutionArtefacts {
+		found := false
+		for _, a := range dest.ExecutionArtefacts {
+			if a == artefact {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dest.ExecutionArtefacts = append(dest.ExecutionArtefacts, artefact)
+		}
+	}
+
+	if confidenceRank[src.ExecutionConfidence] > confidenceRank[dest.ExecutionConfidence] {
+		dest.ExecutionConfidence = src.ExecutionConfidence
+	}
+	return dest
+}
@@ -2,6 +2,7 @@ package Entity
 
 import (
 	"encoding/xml"
+	"fmt"
 	"strings"
 	"time"
 )
@@ -18,12 +19,190 @@ type File struct {
 	PeType        string
 	Computer      string
 	Evidence      []string
+
+	// Lnk and Jump Lists
+	LinkPath           string // Path of the LNK or Jump List file
+	User               string // User who opened the file
+	CreationTime       time.Time
+	ModificationTime   time.Time
+	AccessTime         time.Time
+	Size               int
+	VolumeSerial       string
+	VolumeLabel        string
+	DriveType          string
+	MachineID          string // NetBIOS name of the machine where the target is stored
+	DroidVolumeID      string
+	DroidFileID        string
+	BirthDroidVolumeID string
+	BirthDroidFileID   string
+
+	// Amcache
+	Sha1        string
+	Publisher   string
+	Product     string
+	Description string
+	Version     string
+
+	// macOS FSEvents
+	FSEventFlags []string // Changes recorded by fseventsd (Created, Modified, Renamed, Removed...)
+	FSEventID    int      // Highest event identifier of the changes
+}
+
+var (
+	DriveTypeMap = map[int]string{
+		0: "Unknown",
+		1: "No Root Directory",
+		2: "Removable",
+		3: "Fixed",
+		4: "Remote",
+		5: "CDROM",
+		6: "RAM Disk",
+	}
+)
+
+// findLinkFile find a file opened through the same LNK or Jump List (one plaso line per target timestamp)
+func findLinkFile(files []File, f File) int {
+	for i, v := range files {
+		if v.LinkPath == f.LinkPath && v.FullPath == f.FullPath && v.Computer == f.Computer {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeFile merge two descriptions of the same file (LNK, Jump List or Amcache entry)
+func mergeFile(dest File, src File) File {
+	if dest.Timestamp == 0 || (src.Timestamp != 0 && src.Timestamp < dest.Timestamp) {
+		dest.Timestamp = src.Timestamp
+		dest.Date = src.Date
+		dest.TimestampDesc = src.TimestampDesc
+	}
+
+	if dest.CreationTime.IsZero() {
+		dest.CreationTime = src.CreationTime
+	}
+
+	if dest.ModificationTime.IsZero() {
+		dest.ModificationTime = src.ModificationTime
+	}
+
+	if dest.AccessTime.IsZero() {
+		dest.AccessTime = src.AccessTime
+	}
+
+	if dest.Size == 0 {
+		dest.Size = src.Size
+	}
+
+	if dest.VolumeSerial == "" {
+		dest.VolumeSerial = src.VolumeSerial
+	}
+
+	if dest.VolumeLabel == "" {
+		dest.VolumeLabel = src.VolumeLabel
+	}
+
+	if dest.DriveType == "" {
+		dest.DriveType = src.DriveType
+	}
+
+	if dest.MachineID == "" {
+		dest.MachineID = src.MachineID
+	}
+
+	if dest.DroidVolumeID == "" {
+		dest.DroidVolumeID = src.DroidVolumeID
+		dest.DroidFileID = src.DroidFileID
+	}
+
+	if dest.BirthDroidVolumeID == "" {
+		dest.BirthDroidVolumeID = src.BirthDroidVolumeID
+		dest.BirthDroidFileID = src.BirthDroidFileID
+	}
+
+	if dest.Publisher == "" {
+		dest.Publisher = src.Publisher
+	}
+
+	if dest.Product == "" {
+		dest.Product = src.Product
+	}
+
+	if dest.Description == "" {
+		dest.Description = src.Description
+	}
+
+	if dest.Version == "" {
+		dest.Version = src.Version
+	}
+
+	for _, flag := range src.FSEventFlags {
+		found := false
+		for _, v := range dest.FSEventFlags {
+			if v == flag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dest.FSEventFlags = append(dest.FSEventFlags, flag)
+		}
+	}
+
+	if src.FSEventID > dest.FSEventID {
+		dest.FSEventID = src.FSEventID
+	}
+
+	dest.Evidence = append(dest.Evidence, src.Evidence...)
+	return dest
+}
+
+// findAmCacheFile find a file described by the same Amcache entry (one plaso line per entry timestamp)
+func findAmCacheFile(files []File, f File) int {
+	for i, v := range files {
+		if v.Sha1 == f.Sha1 && v.FullPath == f.FullPath && v.Computer == f.Computer {
+			return i
+		}
+	}
+	return -1
+}
+
+// findFSEventFile find the changes recorded by fseventsd on the same path
+func findFSEventFile(files []File, f File) int {
+	for i, v := range files {
+		if len(v.FSEventFlags) > 0 && v.FullPath == f.FullPath && v.Computer == f.Computer {
+			return i
+		}
+	}
+	return -1
 }
 
 func AddFile(files []File, f File) []File {
-	if f.Filename != "" {
-		files = append(files, f)
+	if f.Filename == "" {
+		return files
+	}
+
+	if f.LinkPath != "" {
+		if i := findLinkFile(files, f); i != -1 {
+			files[i] = mergeFile(files[i], f)
+			return files
+		}
 	}
+
+	if f.Sha1 != "" {
+		if i := findAmCacheFile(files, f); i != -1 {
+			files[i] = mergeFile(files[i], f)
+			return files
+		}
+	}
+
+	if len(f.FSEventFlags) > 0 {
+		if i := findFSEventFile(files, f); i != -1 {
+			files[i] = mergeFile(files[i], f)
+			return files
+		}
+	}
+	files = append(files, f)
 	return files
 }
 
@@ -103,3 +282,154 @@ func NewFileFromPE(pl PlasoLog) File {
 
 	return file
 }
+
+// getLinkTarget return the path of the target of a LNK file
+func getLinkTarget(pl PlasoLog) string {
+	if pl.LocalPath != "" {
+		return pl.LocalPath
+	}
+	// <My Computer> C:\Users\bob\Desktop\report.docx
+	if pl.LinkTarget != "" {
+		target := pl.LinkTarget
+		if strings.HasPrefix(target, "<") {
+			if index := strings.Index(target, "> "); index != -1 {
+				target = target[index+2:]
+			}
+		}
+		return target
+	}
+	return pl.NetworkPath
+}
+
+// setTargetTime assign the plaso timestamp to the target time it describes
+func setTargetTime(f File, pl PlasoLog) File {
+	if pl.Timestamp == 0 {
+		return f
+	}
+
+	switch pl.TimestampDesc {
+	case "Creation Time":
+		f.CreationTime = f.Date
+		break
+	case "Content Modification Time":
+		f.ModificationTime = f.Date
+		break
+	case "Last Access Time":
+		f.AccessTime = f.Date
+		break
+	}
+	return f
+}
+
+func NewFileFromLink(pl PlasoLog) File {
+	var f = *new(File)
+	f.FullPath = getLinkTarget(pl)
+	f.Filename = getFilename(f.FullPath)
+	f.Extension = getExtension(f.Filename)
+	f.LinkPath = pl.Filename
+
+	var utc, _ = time.LoadLocation("UTC")
+	f.Timestamp = int(pl.Timestamp)
+	f.Date = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	f.TimestampDesc = pl.TimestampDesc
+	f = setTargetTime(f, pl)
+
+	f.Size = pl.FileSize
+	if pl.DriveSerialNumber != 0 {
+		f.VolumeSerial = fmt.Sprintf("0x%08X", pl.DriveSerialNumber)
+	}
+	f.VolumeLabel = pl.VolumeLabel
+	if f.FullPath != "" && pl.LocalPath != "" {
+		f.DriveType = DriveTypeMap[pl.DriveType]
+	}
+	f.MachineID = strings.ToUpper(pl.MachineIdentifier)
+	f.DroidVolumeID = pl.DroidVolumeIdentifier
+	f.DroidFileID = pl.DroidFileIdentifier
+	f.BirthDroidVolumeID = pl.BirthDroidVolumeIdentifier
+	f.BirthDroidFileID = pl.BirthDroidFileIdentifier
+
+	u := NewUserFromPath(pl.Filename)
+	if u != nil {
+		f.User = u.FullName
+	}
+
+	f.Evidence = append(f.Evidence, pl.Message)
+
+	return f
+}
+
+// NewFileFromDestList create a File from an entry of the DestList stream of an automatic destinations Jump List
+func NewFileFromDestList(pl PlasoLog) File {
+	var f = *new(File)
+	f.FullPath = pl.Path
+	f.Filename = getFilename(f.FullPath)
+	f.Extension = getExtension(f.Filename)
+	f.LinkPath = pl.Filename
+
+	var utc, _ = time.LoadLocation("UTC")
+	f.Timestamp = int(pl.Timestamp)
+	f.Date = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	f.TimestampDesc = pl.TimestampDesc
+	if pl.Timestamp != 0 {
+		f.AccessTime = f.Date
+	}
+
+	f.MachineID = strings.ToUpper(pl.Hostname)
+	f.DroidVolumeID = pl.DroidVolumeIdentifier
+	f.DroidFileID = pl.DroidFileIdentifier
+	f.BirthDroidVolumeID = pl.BirthDroidVolumeIdentifier
+	f.BirthDroidFileID = pl.BirthDroidFileIdentifier
+
+	u := NewUserFromPath(pl.Filename)
+	if u != nil {
+		f.User = u.FullName
+	}
+
+	f.Evidence = append(f.Evidence, pl.Message)
+
+	return f
+}
+
+// getAmCachePath return the path of an Amcache entry (full_path in recent plaso versions)
+func getAmCachePath(pl PlasoLog) string {
+	if pl.FullPath != "" {
+		return pl.FullPath
+	}
+	return pl.Path
+}
+
+func NewFileFromAmCache(pl PlasoLog) File {
+	var f = *new(File)
+	f.FullPath = getAmCachePath(pl)
+	f.Filename = getFilename(f.FullPath)
+	f.Extension = getExtension(f.Filename)
+
+	var utc, _ = time.LoadLocation("UTC")
+	f.Timestamp = int(pl.Timestamp)
+	f.Date = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	f.TimestampDesc = pl.TimestampDesc
+	f = setTargetTime(f, pl)
+
+	// Amcache stores the SHA1 prefixed with "0000"
+	f.Sha1 = strings.ToLower(pl.Sha1)
+	if len(f.Sha1) == 44 && strings.HasPrefix(f.Sha1, "0000") {
+		f.Sha1 = f.Sha1[4:]
+	}
+	f.Size = pl.FileSize
+	f.Publisher = pl.CompanyName
+	f.Product = pl.ProductName
+	f.Description = pl.FileDescription
+	f.Version = pl.FileVersion
+
+	f.Evidence = append(f.Evidence, pl.Message)
+
+	return f
+}
+
+// NewComputerFromFile create the Computer where the target of a LNK or Jump List is stored (if known)
+func NewComputerFromFile(f File) *Computer {
+	if f.MachineID == "" {
+		return nil
+	}
+	return &Computer{Name: f.MachineID}
+}
@@ -0,0 +1,218 @@
+package Entity
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileAccess is a File or Folder accessed by a User according to MRU lists (converted to a User -[ACCESSED]-> File relationship)
+type FileAccess struct {
+	Date      time.Time
+	Timestamp int
+	User      string
+	Computer  string
+
+	FullPath string
+	Filename string
+	IsFolder bool
+	Source   string // RecentDocs, OpenSaveMRU, LastVisitedMRU, RunMRU, TypedPaths, WordWheelQuery, ShellBags or MRU
+	MruOrder int
+	Evidence []string
+}
+
+var (
+	// MruSourceMap map a part of the registry key path to the MRU list it belongs to
+	MruSourceMap = map[string]string{
+		"RecentDocs":         "RecentDocs",
+		"OpenSavePidlMRU":    "OpenSaveMRU",
+		"OpenSaveMRU":        "OpenSaveMRU",
+		"LastVisitedPidlMRU": "LastVisitedMRU",
+		"LastVisitedMRU":     "LastVisitedMRU",
+		"RunMRU":             "RunMRU",
+		"TypedPaths":         "TypedPaths",
+		"WordWheelQuery":     "WordWheelQuery",
+		"BagMRU":             "ShellBags",
+	}
+)
+
+func AddFileAccess(accesses []FileAccess, a FileAccess) []FileAccess {
+	if a.Filename != "" {
+		accesses = append(accesses, a)
+	}
+	return accesses
+}
+
+func UnionFileAccesses(dest []FileAccess, src []FileAccess) []FileAccess {
+	for _, a := range src {
+		dest = AddFileAccess(dest, a)
+	}
+	return dest
+}
+
+func getMruSource(keyPath string) string {
+	for key, source := range MruSourceMap {
+		if strings.Contains(keyPath, "\\"+key) {
+			return source
+		}
+	}
+	return "MRU"
+}
+
+// parseMruValue extract the path of an MRU entry value. ex:
+// "Shell item path: <My Computer> C:\Users" or "Path: notepad.exe, Shell item path: <My Computer> C:\Users\bob"
+// "Path: report.docx, Shell item: [report.lnk]" or "C:\Users\bob\report.docx"
+func parseMruValue(value string) string {
+	if index := strings.Index(value, "Shell item path: "); index != -1 {
+		value = value[index+len("Shell item path: "):]
+		// Remove the root shell item "<My Computer> "
+		if strings.HasPrefix(value, "<") {
+			if index = strings.Index(value, "> "); index != -1 {
+				value = value[index+2:]
+			}
+		}
+		return strings.TrimSpace(value)
+	}
+
+	if strings.HasPrefix(value, "Path: ") {
+		value = strings.TrimPrefix(value, "Path: ")
+		if index := strings.Index(value, ", Shell item"); index != -1 {
+			value = value[:index]
+		}
+	}
+	return strings.TrimSpace(value)
+}
+
+// setMruDate date the most recent entry of an MRU list (the lowest MRU order) with the last write time of the key,
+// the key does not record when the other entries were accessed
+func setMruDate(accesses []FileAccess, pl PlasoLog) []FileAccess {
+	latest := -1
+	for i, a := range accesses {
+		if latest == -1 || a.MruOrder < accesses[latest].MruOrder {
+			latest = i
+		}
+	}
+	if latest != -1 {
+		var utc, _ = time.LoadLocation("UTC")
+		accesses[latest].Timestamp = int(pl.Timestamp)
+		accesses[latest].Date = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	}
+	return accesses
+}
+
+// NewFileAccessesFromMRU create a FileAccess for every entry of a MRUList, MRUListEx or BagMRU key.
+// Entries look like "Index: 1 [MRU Value a]: C:\Users\bob\report.docx"
+func NewFileAccessesFromMRU(pl PlasoLog) []FileAccess {
+	var accesses []FileAccess
+
+	source := getMruSource(pl.KeyPath)
+	if pl.DataType == "windows:registry:bagmru" {
+		source = "ShellBags"
+	}
+
+	var user string
+	if u := NewUserFromPath(pl.Filename); u != nil {
+		user = u.FullName
+	}
+
+	r, err := regexp.Compile(`^Index: (\d+) \[MRU Value [^\]]*\]: (.*)$`)
+	handleErr(err)
+	for _, entry := range pl.Entries {
+		matches := r.FindStringSubmatch(entry)
+		if len(matches) != 3 {
+			continue
+		}
+
+		var a FileAccess
+		a.Source = source
+		a.User = user
+		order, _ := strconv.Atoi(matches[1])
+		a.MruOrder = order
+
+		a.FullPath = parseMruValue(matches[2])
+		if source == "RunMRU" {
+			// RunMRU entries are commands ending with "\1", they are kept as is
+			a.FullPath = strings.TrimSuffix(a.FullPath, "\\1")
+			a.Filename = a.FullPath
+			a.Evidence = append(a.Evidence, pl.Message)
+			accesses = append(accesses, a)
+			continue
+		}
+		a.Filename = getFilename(a.FullPath)
+		if !strings.Contains(a.FullPath, "\\") {
+			// Only the filename is known (RecentDocs, WordWheelQuery)
+			a.FullPath = ""
+		}
+		if source != "WordWheelQuery" {
+			a.IsFolder = source == "ShellBags" || getExtension(a.Filename) == "" || strings.HasSuffix(pl.KeyPath, "\\Folder")
+		}
+
+		a.Evidence = append(a.Evidence, pl.Message)
+		accesses = append(accesses, a)
+	}
+
+	return setMruDate(accesses, pl)
+}
+
+// NewFileAccessesFromTypedPaths create a FileAccess for every entry of the TypedPaths key.
+// Entries look like "url1: C:\Windows\Temp"
+func NewFileAccessesFromTypedPaths(pl PlasoLog) []FileAccess {
+	var accesses []FileAccess
+
+	var user string
+	if u := NewUserFromPath(pl.Filename); u != nil {
+		user = u.FullName
+	}
+
+	r, err := regexp.Compile(`^url(\d+): (.*)$`)
+	handleErr(err)
+	for _, entry := range pl.Entries {
+		matches := r.FindStringSubmatch(entry)
+		if len(matches) != 3 {
+			continue
+		}
+
+		var a FileAccess
+		a.Source = "TypedPaths"
+		a.User = user
+		order, _ := strconv.Atoi(matches[1])
+		a.MruOrder = order
+		a.FullPath = strings.TrimSpace(matches[2])
+		a.Filename = getFilename(a.FullPath)
+		a.IsFolder = true
+
+		a.Evidence = append(a.Evidence, pl.Message)
+		accesses = append(accesses, a)
+	}
+
+	return setMruDate(accesses, pl)
+}
+
+// NewProcessFromRunMRU create a Process candidate from a command typed in the Run dialog
+func NewProcessFromRunMRU(a FileAccess) Process {
+	var process Process
+
+	if a.Source != "RunMRU" {
+		return process
+	}
+
+	process.Timestamp = a.Timestamp
+	process.CreatedTime = a.Date
+	process.Commandline = a.FullPath
+	process.User = a.User
+
+	// The executable is the first token of the command
+	fields := strings.Fields(process.Commandline)
+	if len(fields) > 0 {
+		process.Filename = strings.ToLower(getFilename(fields[0]))
+		if strings.Contains(fields[0], "\\") {
+			process.FullPath = strings.ToLower(fields[0])
+		}
+	}
+	process.Evidence = append(process.Evidence, a.Evidence...)
+	process = decodeCommandline(process)
+	process = setExecutionEvidence(process, "RunMRU")
+
+	return process
+}
@@ -0,0 +1,207 @@
+package Entity
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// FirewallColumnAliases map the Connection fields to the column names used by common firewall exports.
+	// Column names are compared in lower case without spaces, dots, dashes and underscores.
+	FirewallColumnAliases = map[string][]string{
+		"timestamp":        {"timestamp", "time", "date", "datetime", "ts", "receivetime", "eventtime", "generatedtime", "starttime"},
+		"ip_source":        {"ipsource", "src", "srcip", "srcaddr", "source", "sourceip", "sourceaddress", "sourceaddr", "idorigh"},
+		"port_source":      {"portsource", "sport", "srcport", "sourceport", "spt", "idorigp"},
+		"ip_destination":   {"ipdestination", "dst", "dstip", "dstaddr", "destination", "destinationip", "destinationaddress", "destinationaddr", "idresph"},
+		"port_destination": {"portdestination", "dport", "dstport", "destinationport", "dpt", "idrespp"},
+		"protocol":         {"protocol", "proto", "transport", "ipprotocol"},
+		"action":           {"action", "act", "disposition", "verdict", "result"},
+		"bytes_sent":       {"bytessent", "sentbyte", "sentbytes", "bytesout", "origbytes"},
+		"bytes_received":   {"bytesreceived", "rcvdbyte", "receivedbytes", "bytesin", "respbytes"},
+		"user":             {"user", "username", "srcuser", "sourceuser"},
+		"computer":         {"computer", "computername", "hostname"},
+	}
+
+	firewallTimeFormats = []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04:05.999999999",
+		"2006/01/02 15:04:05",
+		"01/02/2006 15:04:05",
+		"Jan 2 2006 15:04:05",
+	}
+)
+
+// normalizeColumn lower case a column name and remove its separators
+func normalizeColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	return strings.NewReplacer(" ", "", "_", "", ".", "", "-", "").Replace(name)
+}
+
+// ParseFirewallColumns parse a "field=column,field=column" mapping given on the command line
+func ParseFirewallColumns(value string) map[string]string {
+	columns := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		splitted := strings.SplitN(pair, "=", 2)
+		if len(splitted) == 2 {
+			columns[strings.TrimSpace(splitted[0])] = strings.TrimSpace(splitted[1])
+		}
+	}
+	return columns
+}
+
+// getFirewallColumns find the index of the column of every Connection field, the mapping given by the user take precedence over the aliases
+func getFirewallColumns(header []string, mapping map[string]string) map[string]int {
+	indexes := map[string]int{}
+	normalized := map[string]int{}
+	for i, name := range header {
+		normalized[normalizeColumn(name)] = i
+	}
+
+	for field, aliases := range FirewallColumnAliases {
+		if column, ok := mapping[field]; ok {
+			if i, ok := normalized[normalizeColumn(column)]; ok {
+				indexes[field] = i
+			}
+			continue
+		}
+		for _, alias := range aliases {
+			if i, ok := normalized[alias]; ok {
+				indexes[field] = i
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+// parseFirewallTime parse the time of a firewall log, as a date or an epoch in seconds or milliseconds
+func parseFirewallTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, format := range firewallTimeFormats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+
+	if t, ok := parseZeekTime(value); ok {
+		// Epochs in milliseconds
+		if t.Year() > 3000 {
+			i, _ := strconv.ParseInt(value, 10, 64)
+			t = time.UnixMilli(i).UTC()
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// NewConnectionFromFirewall map a row of a firewall CSV export to a Connection
+func NewConnectionFromFirewall(header []string, row []string, columns map[string]int) Connection {
+	var c Connection
+
+	get := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	if t, ok := parseFirewallTime(get("timestamp")); ok {
+		c.Date = t
+		c.Timestamp = int(t.UnixNano())
+	}
+
+	c.SourceIP = get("ip_source")
+	c.SourcePort, _ = strconv.Atoi(get("port_source"))
+	c.DestinationIP = get("ip_destination")
+	c.DestinationPort, _ = strconv.Atoi(get("port_destination"))
+	c.Protocol = normalizeProtocol(get("protocol"))
+	c.Action = strings.ToLower(get("action"))
+	c.BytesSent, _ = strconv.Atoi(get("bytes_sent"))
+	c.BytesReceived, _ = strconv.Atoi(get("bytes_received"))
+	c.User = get("user")
+	c.Computer = get("computer")
+	c.Initiated = true
+	c.Source = []string{"Firewall"}
+
+	var evidence []string
+	for i, name := range header {
+		if i < len(row) {
+			evidence = append(evidence, name+"="+row[i])
+		}
+	}
+	c.Evidence = append(c.Evidence, strings.Join(evidence, ", "))
+
+	// AddConnection ignore connections without addresses
+	if c.SourceIP == "" || c.DestinationIP == "" {
+		c.SourceIP = "Not Found."
+	}
+	return c
+}
+
+// ParseFirewallLog parse a firewall CSV export with a header line into Connection entities
+func ParseFirewallLog(path string, mapping map[string]string) []Connection {
+	var connections []Connection
+
+	file, err := os.Open(path)
+	handleErr(err)
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return connections
+	}
+	handleErr(err)
+	columns := getFirewallColumns(header, mapping)
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		handleErr(err)
+		connections = AddConnection(connections, NewConnectionFromFirewall(header, row, columns))
+	}
+	return connections
+}
+
+// FindFirewallLogs list the CSV files of a path, a single file or a directory and its sub directories
+func FindFirewallLogs(path string) []string {
+	var paths []string
+
+	info, err := os.Stat(path)
+	handleErr(err)
+	if !info.IsDir() {
+		return []string{path}
+	}
+
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.ToLower(filepath.Ext(p)) == ".csv" {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	handleErr(err)
+	return paths
+}
+
+// ParseFirewallLogs parse firewall CSV exports into Connection entities, the default computer is not assigned
+func ParseFirewallLogs(data []interface{}, paths []string, args map[string]interface{}) []interface{} {
+	mapping := ParseFirewallColumns(args["firewall-columns"].(string))
+	for _, path := range paths {
+		data = unionEntities(data, ParseFirewallLog(path, mapping), "")
+	}
+	return data
+}
@@ -0,0 +1,36 @@
+package Entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFirewallTime(t *testing.T) {
+	tests := []struct {
+		value string
+		time  time.Time
+		ok    bool
+	}{
+		{"2023-01-01T10:00:00Z", time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC), true},
+		{"2023-01-01T10:00:00+02:00", time.Date(2023, 1, 1, 8, 0, 0, 0, time.UTC), true},
+		{"2023-01-01 10:00:00.5", time.Date(2023, 1, 1, 10, 0, 0, 500000000, time.UTC), true},
+		{"2023/01/01 10:00:00", time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC), true},
+		{"01/02/2023 10:00:00", time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC), true},
+		{" Jan 2 2023 10:00:00 ", time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC), true},
+		{"1672567200", time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC), true},
+		{"1672567200.25", time.Date(2023, 1, 1, 10, 0, 0, 250000000, time.UTC), true},
+		{"1672567200000", time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC), true},
+		{"1672567200123", time.Date(2023, 1, 1, 10, 0, 0, 123000000, time.UTC), true},
+		{"", time.Time{}, false},
+		{"yesterday", time.Time{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			parsed, ok := parseFirewallTime(test.value)
+			if ok != test.ok || !parsed.Equal(test.time) {
+				t.Errorf("parseFirewallTime(%q) = %v, %v, want %v, %v", test.value, parsed, ok, test.time, test.ok)
+			}
+		})
+	}
+}
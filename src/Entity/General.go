@@ -18,6 +18,14 @@ func getExtension(filename string) string {
 	return ""
 }
 
+// getInt return the value of a json number decoded as an interface (fields which are not numbers in every plaso event)
+func getInt(v interface{}) int {
+	if f, ok := v.(float64); ok {
+		return int(f)
+	}
+	return -1
+}
+
 func handleErr(err error) {
 	if err != nil {
 		log.Panicln(err)
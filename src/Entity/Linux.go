@@ -0,0 +1,375 @@
+package Entity
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// utmp record types (man 5 utmp)
+const (
+	utmpBootTime    = 2
+	utmpUserProcess = 7
+	utmpDeadProcess = 8
+)
+
+var (
+	// sshd: "Accepted publickey for bob from 10.0.0.5 port 51234 ssh2"
+	sshAcceptedRegex = regexp.MustCompile(`^Accepted (\S+) for (\S+) from (\S+) port (\d+)`)
+	// sshd: "Failed password for invalid user admin from 10.0.0.5 port 51234 ssh2"
+	sshFailedRegex = regexp.MustCompile(`^Failed (\S+) for (?:invalid user )?(\S+) from (\S+) port (\d+)`)
+	// sshd, su, login...: "pam_unix(sshd:session): session closed for user bob"
+	pamSessionClosedRegex = regexp.MustCompile(`session closed for user (\S+)`)
+	// sudo: "bob : TTY=pts/0 ; PWD=/home/bob ; USER=root ; COMMAND=/usr/bin/id -a"
+	sudoRegex = regexp.MustCompile(`^\s*(\S+) : (?:.*?; )?PWD=(.*?) ; USER=(\S+) ; (?:.*?; )?COMMAND=(.*)$`)
+	// su: "pam_unix(su-l:session): session opened for user root(uid=0) by bob(uid=1000)"
+	suRegex = regexp.MustCompile(`session opened for user ([^\s(]+)(?:\(uid=\d+\))? by ([^\s(]*)`)
+	// cron: "(root) CMD (/usr/local/bin/backup.sh)"
+	cronRegex = regexp.MustCompile(`^\((\S+)\) CMD \((.*)\)$`)
+	// systemd: "Started Daily apt upgrade and clean activities." or "Started ssh.service - OpenBSD Secure Shell server."
+	systemdUnitRegex    = regexp.MustCompile(`^Started (\S+\.(?:service|timer))(?: - (.*?))?\.?$`)
+	systemdStartedRegex = regexp.MustCompile(`^Started (.*?)\.?$`)
+	// systemd-logind: "New session 12 of user bob."
+	logindSessionRegex = regexp.MustCompile(`^New session (\S+) of user (\S+?)\.?$`)
+	// useradd: "new user: name=bob, UID=1001, GID=1001, home=/home/bob, shell=/bin/bash"
+	useraddRegex = regexp.MustCompile(`^new user: name=([^,]+)`)
+)
+
+// getLinuxUserFromPath return the owner of a file stored in a home directory ("/home/bob/.bash_history", "/root/.bash_history")
+func getLinuxUserFromPath(filename string) string {
+	splitted := strings.Split(filename, "/")
+	for i, v := range splitted {
+		if v == "home" && i+1 < len(splitted)-1 {
+			return splitted[i+1]
+		}
+		if v == "root" && i+1 == len(splitted)-1 {
+			return "root"
+		}
+	}
+	return ""
+}
+
+// getSyslogBody return the message of a syslog line without the "host reporter[pid]:" prefix
+func getSyslogBody(pl PlasoLog) string {
+	if pl.Body != "" {
+		return strings.TrimSpace(pl.Body)
+	}
+	if index := strings.Index(pl.Message, "]: "); index != -1 {
+		return strings.TrimSpace(pl.Message[index+3:])
+	}
+	return strings.TrimSpace(pl.Message)
+}
+
+// constructEventFromSyslog create an Event with the fields shared by the Linux logs, the timestamp is in nanoseconds like the evtx Events
+func constructEventFromSyslog(pl PlasoLog) Event {
+	var e Event
+
+	var utc, _ = time.LoadLocation("UTC")
+	e.Date = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	e.Timestamp = int(pl.Timestamp) * 1000
+	e.Computer = pl.Hostname
+	e.Evidence = append(e.Evidence, pl.Message)
+	return e
+}
+
+// newProcessFromLinuxCommand create a Process from a command line typed in a shell or run by sudo and cron
+func newProcessFromLinuxCommand(pl PlasoLog, commandline string, artefact string) Process {
+	var process Process
+
+	process.Commandline = strings.TrimSpace(commandline)
+	fields := strings.Fields(process.Commandline)
+	if len(fields) == 0 {
+		return process
+	}
+	process.FullPath = fields[0]
+	process.Filename = path.Base(fields[0])
+
+	var utc, _ = time.LoadLocation("UTC")
+	process.Timestamp = int(pl.Timestamp)
+	process.CreatedTime = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	process.Evidence = append(process.Evidence, pl.Message)
+
+	process = setExecutionEvidence(process, artefact)
+	return process
+}
+
+// NewComputerFromSyslog create the Computer which wrote a syslog line
+func NewComputerFromSyslog(pl PlasoLog) *Computer {
+	if pl.Hostname == "" {
+		return nil
+	}
+	return &Computer{Name: pl.Hostname}
+}
+
+// NewEventFromSshd handle the successful and failed logons of sshd and the closed sessions
+func NewEventFromSshd(pl PlasoLog) Event {
+	var e Event
+	body := getSyslogBody(pl)
+
+	if matches := sshAcceptedRegex.FindStringSubmatch(body); len(matches) == 5 {
+		e = constructEventFromSyslog(pl)
+		e.Type = "Logon"
+		e.UserSource = matches[2]
+		e.Title = "User " + e.UserSource + " logged in with " + matches[1] + " from " + matches[3] + "."
+	} else if matches := sshFailedRegex.FindStringSubmatch(body); len(matches) == 5 {
+		e = constructEventFromSyslog(pl)
+		e.Type = "Failed Logon"
+		e.UserSource = matches[2]
+		e.Title = "User " + e.UserSource + " Failed to log in with " + matches[1] + " from " + matches[3] + "."
+	} else if matches := pamSessionClosedRegex.FindStringSubmatch(body); len(matches) == 2 {
+		e = constructEventFromSyslog(pl)
+		e.Type = "Logoff"
+		e.UserSource = matches[1]
+		e.Title = "User " + e.UserSource + " logged off."
+	}
+	return e
+}
+
+// NewConnectionFromSshd create the inbound connection of a successful or failed ssh logon
+func NewConnectionFromSshd(pl PlasoLog) Connection {
+	var c Connection
+	body := getSyslogBody(pl)
+
+	matches := sshAcceptedRegex.FindStringSubmatch(body)
+	if len(matches) != 5 {
+		matches = sshFailedRegex.FindStringSubmatch(body)
+	}
+	if len(matches) != 5 {
+		// AddConnection ignore connections without addresses
+		c.SourceIP = "Not Found."
+		return c
+	}
+
+	var utc, _ = time.LoadLocation("UTC")
+	c.Date = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	c.Timestamp = int(pl.Timestamp) * 1000
+	c.SourceIP = matches[3]
+	c.SourcePort, _ = strconv.Atoi(matches[4])
+	c.DestinationPort = 22
+	c.Protocol = "tcp"
+	c.Initiated = false
+	c.User = matches[2]
+	c.Computer = pl.Hostname
+	c.ProcessName = "sshd"
+	c.ProcessId = pl.Pid
+	c.Source = []string{"Syslog"}
+	c.Evidence = append(c.Evidence, pl.Message)
+	return c
+}
+
+// NewUserFromSyslog create the User involved in an authentication line (sshd, sudo, su, systemd-logind, useradd)
+func NewUserFromSyslog(pl PlasoLog) *User {
+	body := getSyslogBody(pl)
+
+	for _, r := range []*regexp.Regexp{sshAcceptedRegex, logindSessionRegex, sudoRegex, suRegex, useraddRegex} {
+		matches := r.FindStringSubmatch(body)
+		if len(matches) < 2 {
+			continue
+		}
+		name := matches[1]
+		switch r {
+		case sshAcceptedRegex, logindSessionRegex, suRegex:
+			name = matches[2]
+			break
+		}
+		if name == "" {
+			return nil
+		}
+		return &User{FullName: name, Username: name}
+	}
+	return nil
+}
+
+// NewProcessFromSudo handle the commands run through sudo, the Process is run by the user who called sudo
+func NewProcessFromSudo(pl PlasoLog) Process {
+	var process Process
+
+	matches := sudoRegex.FindStringSubmatch(getSyslogBody(pl))
+	if len(matches) != 5 {
+		return process
+	}
+
+	process = newProcessFromLinuxCommand(pl, matches[4], "Sudo")
+	process.User = matches[1]
+	process.Computer = pl.Hostname
+	return process
+}
+
+// NewEventFromSudo record the privilege escalation of a sudo command
+func NewEventFromSudo(pl PlasoLog) Event {
+	var e Event
+
+	matches := sudoRegex.FindStringSubmatch(getSyslogBody(pl))
+	if len(matches) != 5 {
+		return e
+	}
+
+	e = constructEventFromSyslog(pl)
+	// Like the evtx Events, the actor is the destination user and the target the source user
+	e.Type = "Sudo"
+	e.UserDestination = matches[1]
+	e.UserSource = matches[3]
+	e.Title = "User " + matches[1] + " ran " + strings.TrimSpace(matches[4]) + " as " + matches[3] + "."
+	return e
+}
+
+// NewEventFromSu record the user switch of su
+func NewEventFromSu(pl PlasoLog) Event {
+	var e Event
+
+	matches := suRegex.FindStringSubmatch(getSyslogBody(pl))
+	if len(matches) != 3 {
+		return e
+	}
+
+	e = constructEventFromSyslog(pl)
+	e.Type = "Switch User"
+	e.UserDestination = matches[2]
+	e.UserSource = matches[1]
+	e.Title = "User " + matches[2] + " switched to " + matches[1] + "."
+	return e
+}
+
+// NewEventFromLogind handle the sessions opened by systemd-logind (console, ssh, graphical logons)
+func NewEventFromLogind(pl PlasoLog) Event {
+	var e Event
+
+	matches := logindSessionRegex.FindStringSubmatch(getSyslogBody(pl))
+	if len(matches) != 3 {
+		return e
+	}
+
+	e = constructEventFromSyslog(pl)
+	e.Type = "Logon"
+	e.UserSource = matches[2]
+	e.Title = "User " + e.UserSource + " logged in (session " + matches[1] + ")."
+	return e
+}
+
+// NewScheduledTaskFromCron handle the cron jobs run, from the cron syslog parser or a raw CRON line
+func NewScheduledTaskFromCron(pl PlasoLog) ScheduledTask {
+	var task ScheduledTask
+
+	task.User = pl.Username
+	task.Application = pl.Command
+	if task.Application == "" {
+		matches := cronRegex.FindStringSubmatch(getSyslogBody(pl))
+		if len(matches) != 3 {
+			return task
+		}
+		task.User = matches[1]
+		task.Application = matches[2]
+	}
+
+	task.Trigger = "cron"
+	task.Computer = pl.Hostname
+	task.Evidence = append(task.Evidence, pl.Message)
+	return task
+}
+
+// NewProcessFromCron create the Process of a cron job run
+func NewProcessFromCron(task ScheduledTask, pl PlasoLog) Process {
+	var process Process
+	if task.Application == "" {
+		return process
+	}
+
+	process = newProcessFromLinuxCommand(pl, task.Application, "Cron")
+	process.User = task.User
+	process.Computer = task.Computer
+	return process
+}
+
+// NewServiceFromSystemd handle the units started by systemd
+func NewServiceFromSystemd(pl PlasoLog) Service {
+	var service Service
+	body := getSyslogBody(pl)
+
+	if matches := systemdUnitRegex.FindStringSubmatch(body); len(matches) == 3 {
+		service.Name = matches[1]
+	} else if matches := systemdStartedRegex.FindStringSubmatch(body); len(matches) == 2 {
+		service.Name = matches[1]
+	} else {
+		return service
+	}
+
+	// Sessions and user managers are logons, not services
+	if strings.HasPrefix(service.Name, "session-") || strings.HasPrefix(service.Name, "user@") || strings.HasPrefix(service.Name, "Session ") || strings.HasPrefix(service.Name, "User Manager") {
+		return Service{}
+	}
+
+	service.ServiceType = "systemd"
+	service.Computer = pl.Hostname
+	service.Evidence = append(service.Evidence, pl.Message)
+	return service
+}
+
+// NewEventFromUtmp handle the logons and logoffs of utmp, wtmp and btmp
+func NewEventFromUtmp(pl PlasoLog) Event {
+	var e Event
+
+	switch getInt(pl.UtmpType) {
+	case utmpUserProcess:
+		e = constructEventFromSyslog(pl)
+		e.Computer = ""
+		e.Type = "Logon"
+		e.UserSource = pl.Username
+		e.Title = "User " + e.UserSource + " logged in on " + pl.Terminal
+		if pl.IpAddress != "" && pl.IpAddress != "0.0.0.0" {
+			e.Title += " from " + pl.IpAddress
+		}
+		e.Title += "."
+		break
+	case utmpDeadProcess:
+		e = constructEventFromSyslog(pl)
+		e.Computer = ""
+		e.Type = "Logoff"
+		e.UserSource = pl.Username
+		e.Title = "Session on " + pl.Terminal + " closed."
+		break
+	case utmpBootTime:
+		e = constructEventFromSyslog(pl)
+		e.Computer = ""
+		e.Type = "Boot"
+		e.Title = "System boot."
+		break
+	}
+	return e
+}
+
+// NewConnectionFromUtmp create the inbound connection of a remote utmp logon (ssh, telnet)
+func NewConnectionFromUtmp(pl PlasoLog) Connection {
+	var c Connection
+	if getInt(pl.UtmpType) != utmpUserProcess || pl.IpAddress == "" || pl.IpAddress == "0.0.0.0" || pl.IpAddress == "::" {
+		c.SourceIP = "Not Found."
+		return c
+	}
+
+	var utc, _ = time.LoadLocation("UTC")
+	c.Date = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	c.Timestamp = int(pl.Timestamp) * 1000
+	c.SourceIP = pl.IpAddress
+	c.Initiated = false
+	c.User = pl.Username
+	c.ProcessId = pl.Pid
+	c.Source = []string{"utmp"}
+	c.Evidence = append(c.Evidence, pl.Message)
+	return c
+}
+
+// NewUserFromUtmp create the User of a utmp logon
+func NewUserFromUtmp(pl PlasoLog) *User {
+	if getInt(pl.UtmpType) != utmpUserProcess || pl.Username == "" {
+		return nil
+	}
+	return &User{FullName: pl.Username, Username: pl.Username}
+}
+
+// NewProcessFromShellHistory handle bash and zsh history commands, the user is the owner of the history file
+func NewProcessFromShellHistory(pl PlasoLog) Process {
+	process := newProcessFromLinuxCommand(pl, pl.Command, "Shell History")
+	process.User = getLinuxUserFromPath(pl.Filename)
+	return process
+}
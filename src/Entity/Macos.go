@@ -0,0 +1,174 @@
+package Entity
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// FSEventFlagsMap map the flags of a fseventsd record to the change they describe
+	FSEventFlagsMap = map[int]string{
+		0x00000001: "Created",
+		0x00000002: "Removed",
+		0x00000004: "InodeMetadataModified",
+		0x00000008: "Renamed",
+		0x00000010: "Modified",
+		0x00000020: "Exchange",
+		0x00000040: "FinderInfoModified",
+		0x00000080: "DirectoryCreated",
+		0x00000100: "PermissionChanged",
+		0x00000200: "ExtendedAttributeModified",
+		0x00000400: "ExtendedAttributeRemoved",
+		0x00000800: "DocumentCreated",
+		0x00001000: "DocumentRevision",
+		0x00002000: "UnmountPending",
+		0x00004000: "ItemCloned",
+		0x00010000: "NotificationClone",
+		0x00020000: "ItemTruncated",
+		0x00040000: "DirectoryEvent",
+		0x00080000: "LastHardLinkRemoved",
+		0x00100000: "IsHardLink",
+		0x00400000: "IsSymbolicLink",
+		0x00800000: "IsFile",
+		0x01000000: "IsDirectory",
+		0x02000000: "Mount",
+		0x04000000: "Unmount",
+		0x20000000: "EndOfTransaction",
+	}
+
+	// Unified log processes whose messages have the same format as their syslog lines
+	unifiedLogReporters = map[string]string{
+		"sshd":         "sshd",
+		"sshd-session": "sshd",
+		"sudo":         "sudo",
+		"su":           "su",
+	}
+)
+
+// getFSEventFlags decode the flags of a fseventsd record, sorted by value
+func getFSEventFlags(flags int) []string {
+	var values []int
+	for value := range FSEventFlagsMap {
+		if flags&value != 0 {
+			values = append(values, value)
+		}
+	}
+	sort.Ints(values)
+
+	var res []string
+	for _, value := range values {
+		res = append(res, FSEventFlagsMap[value])
+	}
+	return res
+}
+
+// NewFileFromFSEvents create a File from a fseventsd record. The records are not timestamped,
+// plaso use the modification time of the fseventsd file, so the changes of a path are merged.
+func NewFileFromFSEvents(pl PlasoLog) File {
+	var f File
+
+	f.FullPath = pl.Path
+	if f.FullPath != "" && !strings.HasPrefix(f.FullPath, "/") {
+		f.FullPath = "/" + f.FullPath
+	}
+	f.Filename = path.Base(f.FullPath)
+	if f.Filename == "/" || f.Filename == "." {
+		f.Filename = ""
+		return f
+	}
+	f.Extension = getExtension(f.Filename)
+
+	f.FSEventFlags = getFSEventFlags(getInt(pl.Flags))
+	if len(f.FSEventFlags) == 0 {
+		f.FSEventFlags = []string{"None"}
+	}
+	f.FSEventID = pl.EventIdentifier
+
+	var utc, _ = time.LoadLocation("UTC")
+	f.Timestamp = int(pl.Timestamp)
+	f.Date = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	f.TimestampDesc = pl.TimestampDesc
+
+	if u := NewUserFromPath(f.FullPath); u != nil {
+		f.User = u.FullName
+	}
+
+	f.Evidence = append(f.Evidence, pl.Message)
+	return f
+}
+
+// NewScheduledTaskFromLaunchd handle the launch agents and daemons plists, they are run at boot or logon by launchd
+func NewScheduledTaskFromLaunchd(pl PlasoLog) ScheduledTask {
+	var task ScheduledTask
+
+	task.Application = pl.Program
+	task.Comment = pl.Name
+	if task.Application == "" {
+		task.Application = pl.Name
+	}
+
+	switch {
+	case strings.Contains(pl.Filename, "LaunchDaemons"):
+		task.Trigger = "launchd (LaunchDaemons)"
+		break
+	case strings.Contains(pl.Filename, "LaunchAgents"):
+		task.Trigger = "launchd (LaunchAgents)"
+		break
+	default:
+		task.Trigger = "launchd"
+	}
+
+	task.User = pl.UserName
+	if task.User == "" {
+		if u := NewUserFromPath(pl.Filename); u != nil {
+			task.User = u.FullName
+		}
+	}
+
+	task.Evidence = append(task.Evidence, pl.Message)
+	return task
+}
+
+// NewUserFromKeychain create the account of a password stored in a keychain (application and internet passwords)
+func NewUserFromKeychain(pl PlasoLog) *User {
+	if pl.AccountName == "" {
+		return nil
+	}
+
+	u := User{FullName: pl.AccountName, Username: pl.AccountName}
+	u.Comments = "Keychain: " + pl.EntryName
+	if pl.Where != "" && pl.Where != pl.EntryName {
+		u.Comments += " (" + pl.Where + ")"
+	}
+	return &u
+}
+
+// NewProcessFromKnowledgeC handle the application usage of the KnowledgeC database (/app/inFocus, /app/usage)
+func NewProcessFromKnowledgeC(pl PlasoLog) Process {
+	var process Process
+
+	process.Filename = pl.BundleIdentifier
+	process.FullPath = pl.BundleIdentifier
+
+	var utc, _ = time.LoadLocation("UTC")
+	process.Timestamp = int(pl.Timestamp)
+	process.CreatedTime = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	process.Evidence = append(process.Evidence, pl.Message)
+
+	if u := NewUserFromPath(pl.Filename); u != nil {
+		process.User = u.FullName
+	}
+
+	process = setExecutionEvidence(process, "KnowledgeC")
+	return process
+}
+
+// getUnifiedLogReporter return the syslog reporter of a unified log event, so its message is parsed like a syslog line
+func getUnifiedLogReporter(pl PlasoLog) string {
+	if pl.ProcessImagePath == "" {
+		return ""
+	}
+	return unifiedLogReporters[path.Base(pl.ProcessImagePath)]
+}
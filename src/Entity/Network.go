@@ -0,0 +1,205 @@
+package Entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Network is a network interface and profile a Computer was connected to according to SRUM
+type Network struct {
+	InterfaceLUID int
+	InterfaceType string
+	ProfileID     int
+	ProfileFlags  int
+
+	FirstConnected          time.Time
+	FirstConnectedTimestamp int
+	LastSeen                time.Time // Last SRUM sample of the connection
+	LastSeenTimestamp       int
+
+	UserSID  string
+	Computer string
+	Evidence []string
+}
+
+// NetworkUsage is the traffic of an application on a network interface, summed over the hourly SRUM samples.
+// The usages are kept until every sample is parsed, then added as Processes (NewProcessFromNetworkUsage).
+type NetworkUsage struct {
+	FullPath      string
+	InterfaceLUID int
+	BytesSent     int
+	BytesReceived int
+
+	Start          time.Time // First sample
+	StartTimestamp int
+	End            time.Time // Last sample
+	EndTimestamp   int
+
+	Computer string
+	Evidence []string
+}
+
+var (
+	// InterfaceTypeMap map the IANA interface type stored in the upper 16 bits of an interface LUID
+	InterfaceTypeMap = map[int]string{
+		6:   "Ethernet",
+		23:  "PPP",
+		24:  "Loopback",
+		71:  "Wireless",
+		131: "Tunnel",
+		243: "Mobile Broadband",
+		244: "Mobile Broadband",
+	}
+)
+
+func findNetwork(networks []Network, n Network) int {
+	for i, v := range networks {
+		if v.Computer == n.Computer && v.InterfaceLUID == n.InterfaceLUID && v.ProfileID == n.ProfileID {
+			return i
+		}
+	}
+	return -1
+}
+
+func mergeNetwork(dest Network, src Network) Network {
+	if src.FirstConnectedTimestamp != 0 && (dest.FirstConnectedTimestamp == 0 || src.FirstConnectedTimestamp < dest.FirstConnectedTimestamp) {
+		dest.FirstConnected = src.FirstConnected
+		dest.FirstConnectedTimestamp = src.FirstConnectedTimestamp
+	}
+
+	if src.LastSeenTimestamp > dest.LastSeenTimestamp {
+		dest.LastSeen = src.LastSeen
+		dest.LastSeenTimestamp = src.LastSeenTimestamp
+	}
+
+	if dest.UserSID == "" {
+		dest.UserSID = src.UserSID
+	}
+
+	dest.Evidence = append(dest.Evidence, src.Evidence...)
+	return dest
+}
+
+// AddNetwork add a Network, SRUM records of the same interface and profile are merged
+func AddNetwork(networks []Network, n Network) []Network {
+	if n.InterfaceLUID == 0 {
+		return networks
+	}
+
+	i := findNetwork(networks, n)
+	if i == -1 {
+		networks = append(networks, n)
+	} else {
+		networks[i] = mergeNetwork(networks[i], n)
+	}
+	return networks
+}
+
+func UnionNetworks(dest []Network, src []Network) []Network {
+	for _, n := range src {
+		dest = AddNetwork(dest, n)
+	}
+	return dest
+}
+
+func NewNetworkFromSRUM(pl PlasoLog) Network {
+	var n Network
+
+	n.InterfaceLUID = pl.InterfaceLuid
+	n.InterfaceType = InterfaceTypeMap[pl.InterfaceLuid>>48]
+	n.ProfileID = pl.L2ProfileIdentifier
+	n.ProfileFlags = pl.L2ProfileFlags
+	n.UserSID = pl.UserIdentifier
+
+	var utc, _ = time.LoadLocation("UTC")
+	t := time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	if strings.Contains(strings.ToLower(pl.TimestampDesc), "connected") {
+		n.FirstConnected = t
+		n.FirstConnectedTimestamp = int(pl.Timestamp)
+	} else {
+		n.LastSeen = t
+		n.LastSeenTimestamp = int(pl.Timestamp)
+	}
+
+	n.Evidence = append(n.Evidence, pl.Message)
+	return n
+}
+
+// networkUsageKey identify the samples of an application on an interface during the same hour
+type networkUsageKey struct {
+	computer      string
+	fullPath      string
+	interfaceLUID int
+	hour          int
+}
+
+func getNetworkUsageKey(u NetworkUsage) networkUsageKey {
+	return networkUsageKey{u.Computer, u.FullPath, u.InterfaceLUID, u.StartTimestamp / 3600000000}
+}
+
+func mergeNetworkUsage(dest NetworkUsage, src NetworkUsage) NetworkUsage {
+	dest.BytesSent += src.BytesSent
+	dest.BytesReceived += src.BytesReceived
+
+	if src.StartTimestamp < dest.StartTimestamp {
+		dest.Start = src.Start
+		dest.StartTimestamp = src.StartTimestamp
+	}
+
+	if src.EndTimestamp > dest.EndTimestamp {
+		dest.End = src.End
+		dest.EndTimestamp = src.EndTimestamp
+	}
+
+	dest.Evidence = append(dest.Evidence, src.Evidence...)
+	return dest
+}
+
+// MergeNetworkUsages sum the SRUM samples of the same application and interface per hour, once all the sources are parsed
+func MergeNetworkUsages(usages []NetworkUsage) []NetworkUsage {
+	var merged []NetworkUsage
+	index := map[networkUsageKey]int{}
+	for _, u := range usages {
+		key := getNetworkUsageKey(u)
+		if i, found := index[key]; found {
+			merged[i] = mergeNetworkUsage(merged[i], u)
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, u)
+	}
+	return merged
+}
+
+// AddNetworkUsage add a SRUM sample, the samples are summed later by MergeNetworkUsages
+func AddNetworkUsage(usages []NetworkUsage, u NetworkUsage) []NetworkUsage {
+	if u.FullPath != "" {
+		usages = append(usages, u)
+	}
+	return usages
+}
+
+func UnionNetworkUsages(dest []NetworkUsage, src []NetworkUsage) []NetworkUsage {
+	for _, u := range src {
+		dest = AddNetworkUsage(dest, u)
+	}
+	return dest
+}
+
+func NewNetworkUsageFromSRUM(pl PlasoLog) NetworkUsage {
+	var u NetworkUsage
+
+	u.FullPath = strings.ToLower(pl.Application)
+	u.InterfaceLUID = pl.InterfaceLuid
+	u.BytesSent = pl.BytesSent
+	u.BytesReceived = pl.BytesReceived
+
+	var utc, _ = time.LoadLocation("UTC")
+	u.Start = time.UnixMicro(int64(pl.Timestamp)).In(utc)
+	u.StartTimestamp = int(pl.Timestamp)
+	u.End = u.Start
+	u.EndTimestamp = u.StartTimestamp
+
+	u.Evidence = append(u.Evidence, pl.Message)
+	return u
+}
@@ -0,0 +1,64 @@
+package Entity
+
+import (
+	"testing"
+)
+
+func TestMergeNetworkUsages(t *testing.T) {
+	hour := 3600000000
+	app := `c:\windows\system32\svchost.exe`
+
+	tests := []struct {
+		name   string
+		usages []NetworkUsage
+		sent   []int // Bytes sent of each interval
+	}{
+		{
+			"same hour",
+			[]NetworkUsage{
+				{FullPath: app, InterfaceLUID: 1, BytesSent: 10, StartTimestamp: hour},
+				{FullPath: app, InterfaceLUID: 1, BytesSent: 5, StartTimestamp: hour + 60000000},
+			},
+			[]int{15},
+		},
+		{
+			"another hour",
+			[]NetworkUsage{
+				{FullPath: app, InterfaceLUID: 1, BytesSent: 10, StartTimestamp: hour},
+				{FullPath: app, InterfaceLUID: 1, BytesSent: 5, StartTimestamp: 2 * hour},
+				{FullPath: app, InterfaceLUID: 1, BytesSent: 1, StartTimestamp: hour + 1},
+			},
+			[]int{11, 5},
+		},
+		{
+			"another interface",
+			[]NetworkUsage{
+				{FullPath: app, InterfaceLUID: 1, BytesSent: 10, StartTimestamp: hour},
+				{FullPath: app, InterfaceLUID: 2, BytesSent: 5, StartTimestamp: hour},
+			},
+			[]int{10, 5},
+		},
+		{
+			"another computer",
+			[]NetworkUsage{
+				{FullPath: app, InterfaceLUID: 1, BytesSent: 10, StartTimestamp: hour, Computer: "pc"},
+				{FullPath: app, InterfaceLUID: 1, BytesSent: 5, StartTimestamp: hour, Computer: "other"},
+			},
+			[]int{10, 5},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged := MergeNetworkUsages(test.usages)
+			if len(merged) != len(test.sent) {
+				t.Fatalf("%d intervals, want %d", len(merged), len(test.sent))
+			}
+			for i, u := range merged {
+				if u.BytesSent != test.sent[i] {
+					t.Errorf("interval %d sent %d bytes, want %d", i, u.BytesSent, test.sent[i])
+				}
+			}
+		})
+	}
+}
@@ -40,6 +40,17 @@ type EvtxLog struct {
 			Name string `xml:"Name,attr"`
 		} `xml:"Data"`
 	} `xml:"EventData"`
+	UserData *struct {
+		// Event Log Cleared (1102, 104)
+		LogFileCleared *struct {
+			SubjectUserSid    string `xml:"SubjectUserSid"`
+			SubjectUserName   string `xml:"SubjectUserName"`
+			SubjectDomainName string `xml:"SubjectDomainName"`
+			SubjectLogonId    string `xml:"SubjectLogonId"`
+			Channel           string `xml:"Channel"`
+			BackupPath        string `xml:"BackupPath"`
+		} `xml:"LogFileCleared"`
+	} `xml:"UserData"`
 }
 
 type PlasoLog struct {
@@ -62,11 +73,34 @@ type PlasoLog struct {
 	Executable string `json:"executable"`
 
 	// Link
-	EnvVarLocation string `json:"env_var_location"`
+	EnvVarLocation    string `json:"env_var_location"`
+	LocalPath         string `json:"local_path"`
+	NetworkPath       string `json:"network_path"`
+	LinkTarget        string `json:"link_target"`
+	DriveType         int    `json:"drive_type"`
+	DriveSerialNumber int    `json:"drive_serial_number"`
+	VolumeLabel       string `json:"volume_label"`
+	FileSize          int    `json:"file_size"`
+	MachineIdentifier string `json:"machine_identifier"`
+
+	// Link and Jump Lists (Distributed Link Tracking)
+	Hostname                   string `json:"hostname"`
+	DroidVolumeIdentifier      string `json:"droid_volume_identifier"`
+	DroidFileIdentifier        string `json:"droid_file_identifier"`
+	BirthDroidVolumeIdentifier string `json:"birth_droid_volume_identifier"`
+	BirthDroidFileIdentifier   string `json:"birth_droid_file_identifier"`
 
 	// Bam
 	BinaryPath string `json:"binary_path"`
 
+	// Amcache
+	FullPath        string `json:"full_path"`
+	Sha1            string `json:"sha1"`
+	CompanyName     string `json:"company_name"`
+	ProductName     string `json:"product_name"`
+	FileDescription string `json:"file_description"`
+	FileVersion     string `json:"file_version"`
+
 	//Registry
 	ValueName string `json:"value_name"`
 
@@ -103,22 +137,74 @@ type PlasoLog struct {
 	Host       string `json:"host"`
 	VisitCount int    `json:"visit_count"`
 
+	// Browser Downloads, Cookies and Cache
+	DisplayTitle    string `json:"display_title"`
+	Referrer        string `json:"referrer"`
+	MimeType        string `json:"mime_type"`
+	ReceivedBytes   int    `json:"received_bytes"`
+	TotalBytes      int    `json:"total_bytes"`
+	CookieName      string `json:"cookie_name"`
+	Secure          bool   `json:"secure"`
+	HttpOnly        bool   `json:"httponly"`
+	Persistent      bool   `json:"persistent"`
+	AccessCount     int    `json:"access_count"`
+	CachedFilename  string `json:"cached_filename"`
+	ResponseHeaders string `json:"response_headers"`
+
 	//MFT
 	PathHints     []string `json:"path_hints"`
 	IsAllocated   bool     `json:"is_allocated"`
 	AttributeType string   `json:"attribute_type"`
 
 	//SRUM
-	BackgroundBytesRead    int `json:"background_bytes_read"`
-	BackgroundBytesWritten int `json:"background_bytes_written"`
-	ForegroundBytesRead    int `json:"foreground_bytes_read"`
-	ForegroundBytesWritten int `json:"foreground_bytes_written"`
+	BackgroundBytesRead    int    `json:"background_bytes_read"`
+	BackgroundBytesWritten int    `json:"background_bytes_written"`
+	ForegroundBytesRead    int    `json:"foreground_bytes_read"`
+	ForegroundBytesWritten int    `json:"foreground_bytes_written"`
+	BytesSent              int    `json:"bytes_sent"`
+	BytesReceived          int    `json:"bytes_received"`
+	InterfaceLuid          int    `json:"interface_luid"`
+	L2ProfileIdentifier    int    `json:"l2_profile_identifier"`
+	L2ProfileFlags         int    `json:"l2_profile_flags"`
+	UserIdentifier         string `json:"user_identifier"`
 
 	// SAM
 	Username string `json:"username"`
 	FullName string `json:"fullname"`
 	Comments string `json:"comments"`
 
+	// USB
+	Vendor     string `json:"vendor"`
+	Product    string `json:"product"`
+	Revision   string `json:"revision"`
+	Serial     string `json:"serial"`
+	SubkeyName string `json:"subkey_name"`
+	Label      string `json:"label"`
+	EntryType  string `json:"entry_type"`
+	Values     string `json:"values"`
+
+	// Syslog, systemd journal, utmp and shell history
+	Body      string      `json:"body"`
+	Reporter  string      `json:"reporter"`
+	Pid       int         `json:"pid"`
+	Command   string      `json:"command"`
+	IpAddress string      `json:"ip_address"`
+	Terminal  string      `json:"terminal"`
+	UtmpType  interface{} `json:"type"`
+
+	// macOS FSEvents, launchd, keychain, KnowledgeC and unified logs
+	EventIdentifier  int         `json:"event_identifier"`
+	Flags            interface{} `json:"flags"`
+	Program          string      `json:"program"`
+	UserName         string      `json:"user_name"`
+	GroupName        string      `json:"group_name"`
+	AccountName      string      `json:"account_name"`
+	EntryName        string      `json:"entry_name"`
+	Where            string      `json:"where"`
+	TextDescription  string      `json:"text_description"`
+	BundleIdentifier string      `json:"bundle_identifier"`
+	ProcessImagePath string      `json:"process_image_path"`
+
 	//Evtx
 	EvtxLog *EvtxLog
 }
@@ -136,100 +222,238 @@ func GetDataValue(evtx EvtxLog, name string) string {
 
 func ParseEntities(data []interface{}, lines []PlasoLog, args map[string]interface{}) []interface{} {
 	for _, line := range lines {
-		tPs, tScriptblocks, tUsers, tGroups, tComputers, tDomains, tTasks, tServices, tWebhistories, tFiles, tConnections, tEvents, tRegistries := ParseEntity(line)
-		for i := range data {
-			switch data[i].(type) {
-			case []Process:
+		for _, entities := range ParseEntity(line) {
+			data = unionEntities(data, entities, args["computer"].(string))
+		}
+	}
+	return data
+}
+
+// unionEntities merge a slice of parsed entities into the slice of the same type in data.
+// Entities without computer are assigned the default one.
+func unionEntities(data []interface{}, entities interface{}, computer string) []interface{} {
+	for i := range data {
+		switch data[i].(type) {
+		case []Process:
+			if tPs, ok := entities.([]Process); ok {
 				for j := range tPs {
 					if tPs[j].Computer == "" {
-						tPs[j].Computer = args["computer"].(string)
+						tPs[j].Computer = computer
 					}
 				}
 				data[i] = UnionProcesses(data[i].([]Process), tPs)
-				break
-			case []User:
+			}
+			break
+		case []User:
+			if tUsers, ok := entities.([]User); ok {
 				data[i] = UnionUsers(data[i].([]User), tUsers)
-				break
-			case []Computer:
+			}
+			break
+		case []Computer:
+			if tComputers, ok := entities.([]Computer); ok {
 				data[i] = UnionComputers(data[i].([]Computer), tComputers)
-				break
-			case []Domain:
+			}
+			break
+		case []Domain:
+			if tDomains, ok := entities.([]Domain); ok {
 				data[i] = UnionDomains(data[i].([]Domain), tDomains)
-				break
-			case []ScheduledTask:
+			}
+			break
+		case []ScheduledTask:
+			if tTasks, ok := entities.([]ScheduledTask); ok {
 				for j := range tTasks {
 					if tTasks[j].Computer == "" {
-						tTasks[j].Computer = args["computer"].(string)
+						tTasks[j].Computer = computer
 					}
 				}
 				data[i] = UnionScheduledTasks(data[i].([]ScheduledTask), tTasks)
-				break
-			case []Service:
+			}
+			break
+		case []Service:
+			if tServices, ok := entities.([]Service); ok {
 				for j := range tServices {
 					if tServices[j].Computer == "" {
-						tServices[j].Computer = args["computer"].(string)
+						tServices[j].Computer = computer
 					}
 				}
 				data[i] = UnionServices(data[i].([]Service), tServices)
-				break
-			case []WebHistory:
+			}
+			break
+		case []WebHistory:
+			if tWebhistories, ok := entities.([]WebHistory); ok {
 				for j := range tWebhistories {
 					if tWebhistories[j].Computer == "" {
-						tWebhistories[j].Computer = args["computer"].(string)
+						tWebhistories[j].Computer = computer
 					}
 				}
-
 				data[i] = UnionWebHistories(data[i].([]WebHistory), tWebhistories)
-				break
-			case []File:
+			}
+			break
+		case []File:
+			if tFiles, ok := entities.([]File); ok {
 				for j := range tFiles {
 					if tFiles[j].Computer == "" {
-						tFiles[j].Computer = args["computer"].(string)
+						tFiles[j].Computer = computer
 					}
 				}
-
 				data[i] = UnionFiles(data[i].([]File), tFiles)
-				break
-			case []Connection:
+			}
+			break
+		case []Connection:
+			if tConnections, ok := entities.([]Connection); ok {
 				for j := range tConnections {
 					if tConnections[j].Computer == "" {
-						tConnections[j].Computer = args["computer"].(string)
+						tConnections[j].Computer = computer
 					}
 				}
-
 				data[i] = UnionConnections(data[i].([]Connection), tConnections)
-				break
-			case []Event:
+			}
+			break
+		case []Event:
+			if tEvents, ok := entities.([]Event); ok {
 				for j := range tEvents {
 					if tEvents[j].Computer == "" {
-						tEvents[j].Computer = args["computer"].(string)
+						tEvents[j].Computer = computer
 					}
 				}
-
 				data[i] = UnionEvents(data[i].([]Event), tEvents)
-				break
-			case []Registry:
+			}
+			break
+		case []Registry:
+			if tRegistries, ok := entities.([]Registry); ok {
 				for j := range tRegistries {
 					if tRegistries[j].Computer == "" {
-						tRegistries[j].Computer = args["computer"].(string)
+						tRegistries[j].Computer = computer
 					}
 				}
-
 				data[i] = UnionRegistries(data[i].([]Registry), tRegistries)
-				break
-			case []Group:
+			}
+			break
+		case []Group:
+			if tGroups, ok := entities.([]Group); ok {
 				data[i] = UnionGroups(data[i].([]Group), tGroups)
-				break
-			case []ScriptBlock:
+			}
+			break
+		case []ScriptBlock:
+			if tScriptblocks, ok := entities.([]ScriptBlock); ok {
 				for j := range tScriptblocks {
 					if tScriptblocks[j].Computer == "" {
-						tScriptblocks[j].Computer = args["computer"].(string)
+						tScriptblocks[j].Computer = computer
 					}
 				}
-
 				data[i] = UnionScriptBlocks(data[i].([]ScriptBlock), tScriptblocks)
-				break
 			}
+			break
+		case []SecurityControlChange:
+			if tChanges, ok := entities.([]SecurityControlChange); ok {
+				for j := range tChanges {
+					if tChanges[j].Computer == "" {
+						tChanges[j].Computer = computer
+					}
+				}
+				data[i] = UnionSecurityControlChanges(data[i].([]SecurityControlChange), tChanges)
+			}
+			break
+		case []Detection:
+			if tDetections, ok := entities.([]Detection); ok {
+				for j := range tDetections {
+					if tDetections[j].Computer == "" {
+						tDetections[j].Computer = computer
+					}
+				}
+				data[i] = UnionDetections(data[i].([]Detection), tDetections)
+			}
+			break
+		case []AntiForensics:
+			if tAntiForensics, ok := entities.([]AntiForensics); ok {
+				for j := range tAntiForensics {
+					if tAntiForensics[j].Computer == "" {
+						tAntiForensics[j].Computer = computer
+					}
+				}
+				data[i] = UnionAntiForensics(data[i].([]AntiForensics), tAntiForensics)
+			}
+			break
+		case []Device:
+			if tDevices, ok := entities.([]Device); ok {
+				for j := range tDevices {
+					if tDevices[j].Computer == "" {
+						tDevices[j].Computer = computer
+					}
+				}
+				data[i] = UnionDevices(data[i].([]Device), tDevices)
+			}
+			break
+		case []FileAccess:
+			if tAccesses, ok := entities.([]FileAccess); ok {
+				for j := range tAccesses {
+					if tAccesses[j].Computer == "" {
+						tAccesses[j].Computer = computer
+					}
+				}
+				data[i] = UnionFileAccesses(data[i].([]FileAccess), tAccesses)
+			}
+			break
+		case []Command:
+			if tCommands, ok := entities.([]Command); ok {
+				for j := range tCommands {
+					if tCommands[j].Computer == "" {
+						tCommands[j].Computer = computer
+					}
+				}
+				data[i] = UnionCommands(data[i].([]Command), tCommands)
+			}
+			break
+		case []DnsQuery:
+			if tQueries, ok := entities.([]DnsQuery); ok {
+				for j := range tQueries {
+					if tQueries[j].Computer == "" {
+						tQueries[j].Computer = computer
+					}
+				}
+				data[i] = UnionDnsQueries(data[i].([]DnsQuery), tQueries)
+			}
+			break
+		case []Alert:
+			if tAlerts, ok := entities.([]Alert); ok {
+				for j := range tAlerts {
+					if tAlerts[j].Computer == "" {
+						tAlerts[j].Computer = computer
+					}
+				}
+				data[i] = UnionAlerts(data[i].([]Alert), tAlerts)
+			}
+			break
+		case []Cookie:
+			if tCookies, ok := entities.([]Cookie); ok {
+				for j := range tCookies {
+					if tCookies[j].Computer == "" {
+						tCookies[j].Computer = computer
+					}
+				}
+				data[i] = UnionCookies(data[i].([]Cookie), tCookies)
+			}
+			break
+		case []Network:
+			if tNetworks, ok := entities.([]Network); ok {
+				for j := range tNetworks {
+					if tNetworks[j].Computer == "" {
+						tNetworks[j].Computer = computer
+					}
+				}
+				data[i] = UnionNetworks(data[i].([]Network), tNetworks)
+			}
+			break
+		case []NetworkUsage:
+			if tUsages, ok := entities.([]NetworkUsage); ok {
+				for j := range tUsages {
+					if tUsages[j].Computer == "" {
+						tUsages[j].Computer = computer
+					}
+				}
+				data[i] = UnionNetworkUsages(data[i].([]NetworkUsage), tUsages)
+			}
+			break
 		}
 	}
 	return data
@@ -260,7 +484,8 @@ func ParseEvtx(data string) *EvtxLog {
 	return &evtxLog
 }
 
-func ParseEntity(pl PlasoLog) ([]Process, []ScriptBlock, []User, []Group, []Computer, []Domain, []ScheduledTask, []Service, []WebHistory, []File, []Connection, []Event, []Registry) {
+// ParseEntity return the entities found in a plaso line, as a list of slices (one per entity type)
+func ParseEntity(pl PlasoLog) []interface{} {
 	var ps []Process
 	var scriptblocks []ScriptBlock
 	var users []User
@@ -274,6 +499,14 @@ func ParseEntity(pl PlasoLog) ([]Process, []ScriptBlock, []User, []Group, []Comp
 	var services []Service
 	var registries []Registry
 	var groups []Group
+	var securityChanges []SecurityControlChange
+	var detections []Detection
+	var antiForensics []AntiForensics
+	var devices []Device
+	var accesses []FileAccess
+	var networks []Network
+	var usages []NetworkUsage
+	var cookies []Cookie
 
 	switch pl.DataType {
 	case "windows:evtx:record":
@@ -290,6 +523,47 @@ func ParseEntity(pl PlasoLog) ([]Process, []ScriptBlock, []User, []Group, []Comp
 				events = AddEvent(events, event)
 			}
 
+		} else if strings.Contains(pl.EvtxLog.System.Provider.Name, "Windows Defender") {
+			computers = AddComputer(computers, NewComputerFromEvtx(*pl.EvtxLog))
+
+			switch pl.EvtxLog.System.EventID {
+			case 1116, 1117:
+				// Malware detected and action taken
+				detection := NewDetectionFromDefender(*pl.EvtxLog)
+				detections = AddDetection(detections, detection)
+				users = AddUser(users, NewUserFromDetection(detection))
+				break
+			case 5001, 5007, 1013:
+				// Real-time protection disabled, configuration changed and history deleted
+				change := NewSecurityControlChangeFromDefender(*pl.EvtxLog)
+				securityChanges = AddSecurityControlChange(securityChanges, change)
+				users = AddUser(users, NewUserFromSecurityControlChange(change))
+				break
+			}
+
+		} else if strings.Contains(pl.EvtxLog.System.Provider.Name, "Windows Firewall") {
+			computers = AddComputer(computers, NewComputerFromEvtx(*pl.EvtxLog))
+
+			switch pl.EvtxLog.System.EventID {
+			case 2004, 2005, 2006, 2033:
+				// Firewall rule added, modified, deleted and all rules deleted
+				change := NewSecurityControlChangeFromFirewall(*pl.EvtxLog)
+				securityChanges = AddSecurityControlChange(securityChanges, change)
+				break
+			}
+
+		} else if pl.EvtxLog.System.Channel == "Windows PowerShell" {
+			computers = AddComputer(computers, NewComputerFromEvtx(*pl.EvtxLog))
+
+			switch pl.EvtxLog.System.EventID {
+			case 400, 403, 600, 800:
+				// Engine and provider lifecycle, pipeline execution details
+				scriptblock := NewScriptBlockFromWindowsPowershell(*pl.EvtxLog)
+				scriptblocks = AddScriptBlock(scriptblocks, scriptblock)
+				users = AddUser(users, NewUserFromScriptBlock(scriptblock))
+				break
+			}
+
 		} else {
 
 			// Extract Users from Event Logs
@@ -311,6 +585,20 @@ func ParseEntity(pl PlasoLog) ([]Process, []ScriptBlock, []User, []Group, []Comp
 			}
 
 			switch pl.EvtxLog.System.EventID {
+			case 1102, 104:
+				// Security and System log cleared
+				if strings.Contains(pl.EvtxLog.System.Provider.Name, "Eventlog") {
+					af := NewAntiForensicsFromLogCleared(*pl.EvtxLog)
+					antiForensics = AddAntiForensics(antiForensics, af)
+					users = AddUser(users, NewUserFromAntiForensics(af))
+				}
+				break
+			case 4719, 4906, 4907:
+				// Audit policy, CrashOnAuditFail and object auditing changes
+				af := NewAntiForensicsFromSecurity(*pl.EvtxLog)
+				antiForensics = AddAntiForensics(antiForensics, af)
+				users = AddUser(users, NewUserFromAntiForensics(af))
+				break
 			case 4673:
 				log.Fatal("4673 : ", pl.Xml_string)
 				break
@@ -326,6 +614,7 @@ func ParseEntity(pl PlasoLog) ([]Process, []ScriptBlock, []User, []Group, []Comp
 				// Extract Scheduled Tasks from Event Logs
 				scriptblock := NewScriptBlockFrom4103(*pl.EvtxLog)
 				scriptblocks = AddScriptBlock(scriptblocks, scriptblock)
+				users = AddUser(users, NewUserFromScriptBlock(scriptblock))
 				break
 			case 4104:
 				// Handle Powershell Script Block
@@ -418,23 +707,80 @@ func ParseEntity(pl PlasoLog) ([]Process, []ScriptBlock, []User, []Group, []Comp
 		// Extract Process from LNK
 		process := NewProcessFromLink(pl)
 		ps = AddProcess(ps, process)
+
+		// Extract File opened through LNK and Custom Destinations Jump Lists
+		file := NewFileFromLink(pl)
+		files = AddFile(files, file)
+		if c := NewComputerFromFile(file); c != nil {
+			computers = AddComputer(computers, *c)
+		}
+		break
+
+	case "olecf:dest_list:entry":
+		// Extract File from Automatic Destinations Jump Lists
+		file := NewFileFromDestList(pl)
+		files = AddFile(files, file)
+		if c := NewComputerFromFile(file); c != nil {
+			computers = AddComputer(computers, *c)
+		}
+		break
+
+	case "windows:registry:usbstor":
+		devices = AddDevice(devices, NewDeviceFromUSBStor(pl))
+		break
+
+	case "windows:registry:usb":
+		devices = AddDevice(devices, NewDeviceFromUSB(pl))
+		break
+
+	case "windows:registry:mount_points2":
+		devices = AddDevice(devices, NewDeviceFromMountPoints2(pl))
+		break
+
+	case "setupapi:log:line":
+		devices = AddDevice(devices, NewDeviceFromSetupApi(pl))
+		break
+
+	case "windows:registry:key_value":
+		if strings.Contains(pl.KeyPath, "Windows Portable Devices\\Devices\\") {
+			devices = AddDevice(devices, NewDeviceFromPortableDevice(pl))
+		}
 		break
 
 	case "windows:registry:amcache":
-		// Extract Process from Amcache
-		process := NewProcessFromAmCache(pl)
-		ps = AddProcess(ps, process)
+		// Extract Process from Amcache (the PE link time is not an execution)
+		if pl.TimestampDesc != "Link Time" {
+			process := NewProcessFromAmCache(pl)
+			ps = AddProcess(ps, process)
+		}
+
+		// Extract File metadata (SHA1, size, publisher) from Amcache
+		files = AddFile(files, NewFileFromAmCache(pl))
 		break
 
 	case "windows:registry:appcompatcache":
-		// Extract Process from AppCompatCache
+		// Extract Process from ShimCache (AppCompatCache), the timestamp is the file modification time
 		process := NewProcessFromAppCompatCache(pl)
 		ps = AddProcess(ps, process)
 		break
 
-	case "windows:registry:bagmru":
-		//log.Fatal("BagMRU: Found but not parsed - ", pl.Xml_string)
-		// TODO: Handle MRU with File or Folder?
+	case "windows:registry:bagmru", "windows:registry:mrulist", "windows:registry:mrulistex":
+		// Extract File and Folder accessed from MRU lists, RunMRU commands are Process candidates
+		for _, access := range NewFileAccessesFromMRU(pl) {
+			if access.Source == "RunMRU" {
+				ps = AddProcess(ps, NewProcessFromRunMRU(access))
+			} else {
+				accesses = AddFileAccess(accesses, access)
+			}
+		}
+		break
+
+	case "windows:registry:typedurls":
+		if strings.Contains(pl.KeyPath, "TypedPaths") {
+			for _, access := range NewFileAccessesFromTypedPaths(pl) {
+				accesses = AddFileAccess(accesses, access)
+			}
+		}
 		break
 
 	case "windows:registry:bam":
@@ -442,25 +788,18 @@ func ParseEntity(pl PlasoLog) ([]Process, []ScriptBlock, []User, []Group, []Comp
 		ps = AddProcess(ps, process)
 		break
 
-	case "windows:registry:mrulist":
-		//log.Fatal("MRUList: Found but not parsed - ", pl.Xml_string)
-		break
-
-	case "windows:registry:mrulistex":
-		//log.Fatal("MRUListEx: Found but not parsed - ", pl.Xml_string)
-		break
-
 	case "windows:srum:application_usage":
 		process := NewProcessFromSRUM(pl)
 		ps = AddProcess(ps, process)
 		break
 
 	case "windows:srum:network_usage":
-		//log.Fatal("Network Usage: Found but not parsed - ", pl.Xml_string)
+		// Extract bytes sent and received by the application, summed per interface
+		usages = AddNetworkUsage(usages, NewNetworkUsageFromSRUM(pl))
 		break
 
 	case "windows:srum:network_connectivity":
-		//log.Fatal("Network Connectivity: Found but not parsed - ", pl.Xml_string)
+		networks = AddNetwork(networks, NewNetworkFromSRUM(pl))
 		break
 
 	case "windows:registry:run":
@@ -514,10 +853,107 @@ func ParseEntity(pl PlasoLog) ([]Process, []ScriptBlock, []User, []Group, []Comp
 		webhistories = AddWebHistory(webhistories, wh)
 		break
 	case "chrome:history:page_visited":
-		//Extract WebHistory from Chrome
+		//Extract WebHistory from Chrome and Chromium based browsers (Edge, Brave...)
 		wh := NewWebHistoryFromChrome(pl)
 		webhistories = AddWebHistory(webhistories, wh)
 		break
+	case "safari:history:visit", "safari:history:visit_sqlite":
+		wh := NewWebHistoryFromSafari(pl)
+		webhistories = AddWebHistory(webhistories, wh)
+		break
+	case "msiecf:url", "msie:webcache:container":
+		// Extract WebHistory, downloads and cookies from MSIE index.dat and WebCache (IE 10+, Edge Legacy)
+		webhistories = AddWebHistory(webhistories, NewWebHistoryFromMsie(pl))
+		webhistories = AddWebHistory(webhistories, NewWebHistoryFromMsieDownload(pl))
+		cookies = AddCookie(cookies, NewCookieFromMsie(pl))
+		break
+	case "chrome:history:file_downloaded", "firefox:downloads:download":
+		wh := NewWebHistoryFromDownload(pl)
+		webhistories = AddWebHistory(webhistories, wh)
+		break
+	case "chrome:cookie:entry", "firefox:cookie:entry":
+		cookies = AddCookie(cookies, NewCookieFromBrowser(pl))
+		break
+	case "macos:unified_logging:event":
+		// sshd, sudo and su log the same messages in the unified log than in syslog
+		pl.Reporter = getUnifiedLogReporter(pl)
+		if pl.Reporter == "" {
+			break
+		}
+		fallthrough
+
+	case "syslog:line", "syslog:ssh:login", "syslog:ssh:failed_connection", "syslog:ssh:opened_connection", "syslog:cron:task_run", "systemd:journal":
+		// Extract Linux logons, sudo, su, cron jobs and systemd units from syslog and the journal
+		if c := NewComputerFromSyslog(pl); c != nil {
+			computers = AddComputer(computers, *c)
+		}
+		users = AddUser(users, NewUserFromSyslog(pl))
+
+		switch {
+		case pl.DataType == "syslog:cron:task_run" || strings.EqualFold(pl.Reporter, "cron"):
+			task := NewScheduledTaskFromCron(pl)
+			if task.Application != "" {
+				tasks = AddScheduledTask(tasks, task)
+				ps = AddProcess(ps, NewProcessFromCron(task, pl))
+				users = AddUser(users, &User{FullName: task.User, Username: task.User})
+			}
+			break
+		case strings.HasPrefix(pl.DataType, "syslog:ssh:") || pl.Reporter == "sshd":
+			events = AddEvent(events, NewEventFromSshd(pl))
+			connections = AddConnection(connections, NewConnectionFromSshd(pl))
+			break
+		case pl.Reporter == "sudo":
+			ps = AddProcess(ps, NewProcessFromSudo(pl))
+			events = AddEvent(events, NewEventFromSudo(pl))
+			break
+		case pl.Reporter == "su":
+			events = AddEvent(events, NewEventFromSu(pl))
+			break
+		case pl.Reporter == "systemd-logind":
+			events = AddEvent(events, NewEventFromLogind(pl))
+			break
+		case pl.Reporter == "systemd":
+			services = AddService(services, NewServiceFromSystemd(pl))
+			break
+		}
+		break
+
+	case "linux:utmp:event", "mac:utmpx:event":
+		// Extract logons, logoffs and boots from utmp, wtmp, btmp and macOS utmpx
+		events = AddEvent(events, NewEventFromUtmp(pl))
+		users = AddUser(users, NewUserFromUtmp(pl))
+		connections = AddConnection(connections, NewConnectionFromUtmp(pl))
+		break
+
+	case "bash:history:command", "shell:zsh:history":
+		// Extract Process from shell histories
+		process := NewProcessFromShellHistory(pl)
+		ps = AddProcess(ps, process)
+		break
+
+	case "macos:fseventsd:record":
+		// Extract File changes recorded by fseventsd
+		files = AddFile(files, NewFileFromFSEvents(pl))
+		break
+
+	case "macos:launchd:entry":
+		// Extract launch agents and daemons as ScheduledTask
+		task := NewScheduledTaskFromLaunchd(pl)
+		if task.Application != "" {
+			tasks = AddScheduledTask(tasks, task)
+		}
+		break
+
+	case "mac:keychain:application", "mac:keychain:internet":
+		users = AddUser(users, NewUserFromKeychain(pl))
+		break
+
+	case "macos:knowledgec:application":
+		// Extract Process from the KnowledgeC application usage
+		process := NewProcessFromKnowledgeC(pl)
+		ps = AddProcess(ps, process)
+		break
+
 	case "fs:stat:ntfs":
 		//Extract File from MFT
 		//file := NewFileFromMFT(pl)
@@ -526,5 +962,5 @@ func ParseEntity(pl PlasoLog) ([]Process, []ScriptBlock, []User, []Group, []Comp
 
 	}
 
-	return ps, scriptblocks, users, groups, computers, domains, tasks, services, webhistories, files, connections, events, registries
+	return []interface{}{ps, scriptblocks, users, groups, computers, domains, tasks, services, webhistories, files, connections, events, registries, securityChanges, detections, antiForensics, devices, accesses, networks, usages, cookies}
 }
@@ -0,0 +1,228 @@
+package Entity
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+// Maximum number of encoded layers decoded in a script (a script may embed itself several times)
+const maxDecodeLayers = 5
+
+var (
+	// -EncodedCommand can be abbreviated to any of its prefixes (-e, -en, -enc...) or -ec
+	encodedCommandRegex = regexp.MustCompile(`(?i)(?:^|\s)[-/](?:ec|e(?:n(?:c(?:o(?:d(?:e(?:d(?:c(?:o(?:m(?:m(?:a(?:n(?:d)?)?)?)?)?)?)?)?)?)?)?)?)?)\s+["']?([A-Za-z0-9+/]{8,}={0,2})`)
+	fromBase64Regex     = regexp.MustCompile(`(?i)FromBase64String\(\s*["']([A-Za-z0-9+/]{8,}={0,2})["']\s*\)`)
+	compressionRegex    = regexp.MustCompile(`(?i)GzipStream|DeflateStream`)
+
+	// ObfuscationIndicators map a regular expression to its weight in the obfuscation score
+	ObfuscationIndicators = map[*regexp.Regexp]int{
+		regexp.MustCompile("`[a-zA-Z]"):                                        15, // Tick escaping (`I`E`X)
+		regexp.MustCompile(`["']\s*\+\s*["']`):                                 10, // String concatenation
+		regexp.MustCompile(`(?i)\[char\]\s*\d+`):                               15, // Char codes
+		regexp.MustCompile(`(?i)-join\b`):                                      10,
+		regexp.MustCompile(`(?i)["']\s*-f\s`):                                  10, // Format operator reordering
+		regexp.MustCompile(`(?i)-replace\b`):                                   5,
+		regexp.MustCompile(`(?i)\.replace\(`):                                  5,
+		regexp.MustCompile(`(?i)\biex\b|invoke-expression|\.invoke\(`):         15,
+		regexp.MustCompile(`(?i)FromBase64String`):                             15,
+		regexp.MustCompile(`(?i)GzipStream|DeflateStream|IO\.Compression`):     15,
+		regexp.MustCompile(`(?i)\$env:comspec|\$pshome\[|\$shellid\[`):         15, // IEX built from environment variables
+		regexp.MustCompile(`(?i)-bxor\b`):                                      10,
+		regexp.MustCompile(`(?i)(?:^|\s)[-/]w(?:indowstyle)?\s+h(?:idden)?\b`): 5,
+		regexp.MustCompile(`(?i)(?:^|\s)[-/]nop(?:rofile)?\b`):                 5,
+	}
+)
+
+// decodeUTF16 decode a little endian UTF-16 string (PowerShell encoded commands)
+func decodeUTF16(b []byte) string {
+	u16 := make([]uint16, len(b)/2)
+	for i := range u16 {
+		u16[i] = uint16(b[2*i]) | uint16(b[2*i+1])<<8
+	}
+	return string(utf16.Decode(u16))
+}
+
+// isUTF16 guess if a decoded buffer is UTF-16LE text (every odd byte of ASCII text is null)
+func isUTF16(b []byte) bool {
+	if len(b) < 2 || len(b)%2 != 0 {
+		return false
+	}
+	nulls := 0
+	for i := 1; i < len(b); i += 2 {
+		if b[i] == 0 {
+			nulls++
+		}
+	}
+	return nulls*2 >= len(b)/2
+}
+
+// isPrintable tell if a decoded buffer is text and not a binary payload
+func isPrintable(s string) bool {
+	if s == "" {
+		return false
+	}
+	printable := 0
+	for _, r := range s {
+		if r == '\n' || r == '\r' || r == '\t' || (r >= ' ' && r != 0x7f && r != 0xfffd) {
+			printable++
+		}
+	}
+	return printable*10 >= len([]rune(s))*9
+}
+
+// decompressBase64 decode a base64 payload compressed with GzipStream or DeflateStream
+func decompressBase64(b []byte) (string, bool) {
+	var reader io.Reader
+	if len(b) > 2 && b[0] == 0x1f && b[1] == 0x8b {
+		gz, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return "", false
+		}
+		reader = gz
+	} else {
+		reader = flate.NewReader(bytes.NewReader(b))
+	}
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil || len(decompressed) == 0 {
+		return "", false
+	}
+	if isUTF16(decompressed) {
+		return decodeUTF16(decompressed), true
+	}
+	return string(decompressed), true
+}
+
+// decodeBase64Stub decode the payload of a FromBase64String stub, decompressed if the script uses a compression stream
+func decodeBase64Stub(payload string, compressed bool) (string, bool) {
+	b, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", false
+	}
+
+	if compressed {
+		if text, ok := decompressBase64(b); ok && isPrintable(text) {
+			return text, true
+		}
+	}
+
+	var text string
+	if isUTF16(b) {
+		text = decodeUTF16(b)
+	} else {
+		text = string(b)
+	}
+	return text, isPrintable(text)
+}
+
+// DecodeEncodedCommand decode the base64 -EncodedCommand argument of a powershell command line
+func DecodeEncodedCommand(commandline string) (string, bool) {
+	matches := encodedCommandRegex.FindStringSubmatch(commandline)
+	if len(matches) != 2 {
+		return "", false
+	}
+
+	b, err := base64.StdEncoding.DecodeString(matches[1])
+	if err != nil {
+		return "", false
+	}
+	text := decodeUTF16(b)
+	return text, isPrintable(text)
+}
+
+// decodeLayers decode every encoded layer of a powershell script or command line, from the outermost to the innermost
+func decodeLayers(script string) []string {
+	var layers []string
+	decoded := script
+
+	for i := 0; i < maxDecodeLayers; i++ {
+		if text, ok := DecodeEncodedCommand(decoded); ok {
+			decoded = text
+			layers = append(layers, decoded)
+			continue
+		}
+
+		matches := fromBase64Regex.FindStringSubmatch(decoded)
+		if len(matches) != 2 {
+			break
+		}
+		text, ok := decodeBase64Stub(matches[1], compressionRegex.MatchString(decoded))
+		if !ok {
+			break
+		}
+		decoded = text
+		layers = append(layers, decoded)
+	}
+	return layers
+}
+
+// DecodePowershell decode every encoded layer of a powershell script or command line.
+// It return an empty string if nothing was decoded.
+func DecodePowershell(script string) string {
+	layers := decodeLayers(script)
+	if len(layers) == 0 {
+		return ""
+	}
+	return layers[len(layers)-1]
+}
+
+// ObfuscationScore compute an obfuscation score between 0 and 100 of a powershell script or command line
+func ObfuscationScore(script string) int {
+	if script == "" {
+		return 0
+	}
+
+	score := 0
+	for r, weight := range ObfuscationIndicators {
+		if r.MatchString(script) {
+			score += weight
+		}
+	}
+
+	// Scripts made mostly of special characters ("${;}=+$()" obfuscation)
+	special := 0
+	for _, c := range script {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != ' ' && c != '\n' && c != '\r' {
+			special++
+		}
+	}
+	if len(script) > 20 && special*2 > len(script) {
+		score += 20
+	}
+
+	// Encoded command line
+	if encodedCommandRegex.MatchString(script) {
+		score += 20
+	}
+
+	if score > 100 {
+		score = 100
+	}
+	return score
+}
+
+// decodeScript return the decoded text of a script and the highest obfuscation score of its layers
+func decodeScript(script string) (string, int) {
+	if strings.TrimSpace(script) == "" {
+		return "", 0
+	}
+
+	layers := decodeLayers(script)
+	score := ObfuscationScore(script)
+	for _, layer := range layers {
+		if layerScore := ObfuscationScore(layer); layerScore > score {
+			score = layerScore
+		}
+	}
+
+	if len(layers) == 0 {
+		return "", score
+	}
+	return layers[len(layers)-1], score
+}
@@ -0,0 +1,85 @@
+package Entity
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+	"unicode/utf16"
+)
+
+// encodeUTF16 encode a script like powershell -EncodedCommand does (base64 of UTF-16LE)
+func encodeUTF16(script string) string {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(script)) {
+		b = append(b, byte(u), byte(u>>8))
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// encodeGzip encode a script like the GzipStream stubs (base64 of the gzip compressed text)
+func encodeGzip(script string) string {
+	var buffer bytes.Buffer
+	w := gzip.NewWriter(&buffer)
+	w.Write([]byte(script))
+	w.Close()
+	return base64.StdEncoding.EncodeToString(buffer.Bytes())
+}
+
+func TestObfuscationScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		score  int
+	}{
+		{"empty", "", 0},
+		{"plain", "Get-ChildItem C:\\Users", 0},
+		{"tick escaping", "I`E`X (New-Object Net.WebClient)", 15},
+		{"string concatenation", "$a = 'Ne' + 'w-Object'", 10},
+		{"encoded command line", "powershell -nop -w hidden -enc " + encodeUTF16("Write-Host hello"), 30},
+		{"special characters", "${;}=+$()${;}=+$()${;}", 20},
+		{"capped", "iex ([char]65 -join '' -bxor `a 'a'+'b' FromBase64String GzipStream $env:comspec -replace .replace(", 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if score := ObfuscationScore(test.script); score != test.score {
+				t.Errorf("ObfuscationScore(%q) = %d, want %d", test.script, score, test.score)
+			}
+		})
+	}
+}
+
+func TestDecodeScript(t *testing.T) {
+	stub := "iex ([Text.Encoding]::Unicode.GetString([Convert]::FromBase64String('" + encodeUTF16("Write-Host stub") + "')))"
+	compressed := "iex (New-Object IO.StreamReader(New-Object IO.Compression.GzipStream([IO.MemoryStream][Convert]::FromBase64String('" +
+		encodeGzip("Write-Host compressed") + "'), [IO.Compression.CompressionMode]::Decompress))).ReadToEnd()"
+
+	tests := []struct {
+		name    string
+		script  string
+		decoded string
+		score   int
+	}{
+		{"empty", "  ", "", 0},
+		{"not encoded", "Get-Process", "", 0},
+		{"encoded command", "powershell.exe -EncodedCommand " + encodeUTF16("Write-Host hello"), "Write-Host hello", 20},
+		{"abbreviated encoded command", "powershell /e " + encodeUTF16("Write-Host hello"), "Write-Host hello", 20},
+		{"base64 stub", stub, "Write-Host stub", 30},
+		{"compressed stub", compressed, "Write-Host compressed", 45},
+		{"nested layers", "powershell -enc " + encodeUTF16(stub), "Write-Host stub", 30},
+		{"invalid base64", "powershell -enc " + "AAAA!!!!", "", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded, score := decodeScript(test.script)
+			if decoded != test.decoded {
+				t.Errorf("decodeScript(%q) decoded %q, want %q", test.script, decoded, test.decoded)
+			}
+			if score != test.score {
+				t.Errorf("decodeScript(%q) score %d, want %d", test.script, score, test.score)
+			}
+		})
+	}
+}
@@ -32,24 +32,36 @@ type Process struct {
 	Sha256Hash       string
 	Evidence         []string
 
+	// Evtx record of 4688 and Sysmon 1 (Alerts are linked by record ID, computer and channel)
+	RecordID int
+	Channel  string
+
+	// Decoded -EncodedCommand and base64 stubs of the Commandline
+	DecodedCommandline string
+	ObfuscationScore   int
+
+	// Execution Evidence
+	ExecutionArtefacts  []string // Artefacts the Process was found in (Prefetch, Amcache, ShimCache...)
+	ExecutionConfidence string   // Highest confidence of execution among the artefacts
+
 	// SRUM
 	BackgroundBytesRead    int
 	BackgroundBytesWritten int
 	ForegroundBytesRead    int
 	ForegroundBytesWritten int
-}
 
-func containsProcess(ps []Process, p Process) bool {
-	for _, v := range ps {
-		if v.PID == p.PID && v.PPID == p.PPID && v.FullPath == p.FullPath {
-			return true
-		}
-	}
-	return false
+	// SRUM Network Usage (bytes sent and received on an interface between the first and last sample)
+	BytesSent         int
+	BytesReceived     int
+	InterfaceLUID     int
+	NetworkUsageStart time.Time
+	NetworkUsageEnd   time.Time
+	NetworkUsages     []NetworkUsage // Bytes sent and received per hour, without their evidence
 }
 
+// AddProcess add a Process with a known Filename, duplicates are merged later by MergeProcesses
 func AddProcess(ps []Process, p Process) []Process {
-	if containsProcess(ps, p) {
+	if p.Filename != "" {
 		ps = append(ps, p)
 	}
 	return ps
@@ -67,14 +79,18 @@ func removeProcess(array []Process, index int) []Process {
 	return array[:len(array)-1]
 }
 
-// MergeProcesses Merge Last 2 * batch_size process
+// MergeProcesses Merge Last 2 * batch_size process.
+// The SRUM network usages are already summed per application and interface, they are not merged.
 func MergeProcesses(processes []Process, approx int) []Process {
 
 	for i := 0; i < len(processes); i++ {
+		if processes[i].InterfaceLUID != 0 {
+			continue
+		}
 		var markedToRemove []int
 		for j := 0; j < len(processes); j++ {
 			// We merge process if they have the same Filename and have a timestamp approximatly close
-			if i != j && processes[i].Filename == processes[j].Filename && processes[j].Timestamp-approx < processes[i].Timestamp && processes[i].Timestamp < processes[j].Timestamp+approx {
+			if i != j && processes[j].InterfaceLUID == 0 && processes[i].Filename == processes[j].Filename && processes[j].Timestamp-approx < processes[i].Timestamp && processes[i].Timestamp < processes[j].Timestamp+approx {
 				processes[i] = mergeProcess(processes[i], processes[j])
 				// We mark the process that we have merged to be removed. (we don't mess with indexes in J's for loop)
 				markedToRemove = append(markedToRemove, j)
@@ -95,6 +111,14 @@ func mergeProcess(dest Process, src Process) Process {
 		dest.Commandline = src.Commandline
 	}
 
+	if dest.DecodedCommandline == "" {
+		dest.DecodedCommandline = src.DecodedCommandline
+	}
+
+	if src.ObfuscationScore > dest.ObfuscationScore {
+		dest.ObfuscationScore = src.ObfuscationScore
+	}
+
 	if dest.FullPath == "" {
 		dest.Filename = src.FullPath
 	}
@@ -103,6 +127,11 @@ func mergeProcess(dest Process, src Process) Process {
 		dest.LogonID = src.LogonID
 	}
 
+	if dest.RecordID == 0 {
+		dest.RecordID = src.RecordID
+		dest.Channel = src.Channel
+	}
+
 	if dest.PID == 0 {
 		dest.PID = src.PID
 	}
@@ -127,11 +156,17 @@ func mergeProcess(dest Process, src Process) Process {
 		dest.ParentProcessName = src.ParentProcessName
 	}
 
+	dest = mergeExecutionEvidence(dest, src)
 	dest.Evidence = append(dest.Evidence, src.Evidence...)
 
 	return dest
 }
 
+func decodeCommandline(p Process) Process {
+	p.DecodedCommandline, p.ObfuscationScore = decodeScript(p.Commandline)
+	return p
+}
+
 func convertOct(s string) int {
 	i64, err := strconv.ParseInt(s, 0, 64)
 	handleErr(err)
@@ -141,6 +176,8 @@ func convertOct(s string) int {
 func NewProcessFrom4688(evtx EvtxLog) Process {
 	var process Process
 	process.Computer = evtx.System.Computer
+	process.RecordID, _ = strconv.Atoi(evtx.System.EventRecordID)
+	process.Channel = evtx.System.Channel
 	t, err := time.Parse(time.RFC3339Nano, evtx.System.TimeCreated.SystemTime)
 	handleErr(err)
 	process.CreatedTime = t
@@ -163,12 +200,16 @@ func NewProcessFrom4688(evtx EvtxLog) Process {
 	handleErr(err)
 	process.Evidence = append(process.Evidence, string(xml_string))
 
+	process = decodeCommandline(process)
+	process = setExecutionEvidence(process, "Security 4688")
 	return process
 }
 
 func NewProcessFromSysmon1(evtx EvtxLog) Process {
 	var process Process
 	process.Computer = evtx.System.Computer
+	process.RecordID, _ = strconv.Atoi(evtx.System.EventRecordID)
+	process.Channel = evtx.System.Channel
 	t, err := time.Parse(time.RFC3339Nano, evtx.System.TimeCreated.SystemTime)
 	handleErr(err)
 	process.CreatedTime = t
@@ -217,6 +258,8 @@ func NewProcessFromSysmon1(evtx EvtxLog) Process {
 	handleErr(err)
 	process.Evidence = append(process.Evidence, string(xml_string))
 
+	process = decodeCommandline(process)
+	process = setExecutionEvidence(process, "Sysmon 1")
 	return process
 }
 
@@ -232,6 +275,7 @@ func NewProcessFromPrefetchFile(pf PlasoLog) Process {
 	process.Timestamp = int(pf.Timestamp)
 	process.CreatedTime = time.UnixMicro(int64(pf.Timestamp)).In(utc)
 	// {"__container_type__": "event", "__type__": "AttributeContainer", "data_type": "windows:volume:creation", "date_time": {"__class_name__": "Filetime", "__type__": "DateTimeValues", "timestamp": 132902282486494590}, "device_path": "\\VOLUME{01d829ebf972357e-10f97ebe}", "display_name": "OS:/home/csoulet/Desktop/Workspace/projects/Plaso_test/C/Windows/prefetch/CHROME.EXE-AED7BA3D.pf", "filename": "/home/csoulet/Desktop/Workspace/projects/Plaso_test/C/Windows/prefetch/CHROME.EXE-AED7BA3D.pf", "inode": "-", "message": "\\VOLUME{01d829ebf972357e-10f97ebe} Serial number: 0x10F97EBE Origin: CHROME.EXE-AED7BA3D.pf", "origin": "CHROME.EXE-AED7BA3D.pf", "parser": "prefetch", "pathspec": {"__type__": "PathSpec", "location": "/home/csoulet/Desktop/Workspace/projects/Plaso_test/C/Windows/prefetch/CHROME.EXE-AED7BA3D.pf", "type_indicator": "OS"}, "serial_number": 284786366, "sha256_hash": "1ac73a0134a784d92b04eb054bf1d4e950c1270d223606a94857332d0d136645", "timestamp": 1645754648649459, "timestamp_desc": "Creation Time"}
+	process = setExecutionEvidence(process, "Prefetch")
 	return process
 }
 
@@ -245,6 +289,7 @@ func NewProcessFromPrefetchExecution(pf PlasoLog) Process {
 	process.Timestamp = int(pf.Timestamp)
 	process.CreatedTime = time.UnixMicro(int64(pf.Timestamp)).In(utc)
 
+	process = setExecutionEvidence(process, "Prefetch")
 	return process
 }
 
@@ -259,6 +304,7 @@ func NewProcessFromLink(pl PlasoLog) Process {
 	process.Timestamp = int(pl.Timestamp)
 	process.CreatedTime = time.UnixMicro(int64(pl.Timestamp)).In(utc)
 
+	process = setExecutionEvidence(process, "Lnk")
 	return process
 }
 
@@ -266,13 +312,14 @@ func NewProcessFromAmCache(pl PlasoLog) Process {
 	var process = *new(Process)
 
 	process.Evidence = append(process.Evidence, pl.Message)
-	process.FullPath = strings.ToLower(pl.Path)
+	process.FullPath = strings.ToLower(getAmCachePath(pl))
 	process.Filename = getFilename(process.FullPath)
 
 	var utc, _ = time.LoadLocation("UTC")
 	process.Timestamp = int(pl.Timestamp)
 	process.CreatedTime = time.UnixMicro(int64(pl.Timestamp)).In(utc)
 
+	process = setExecutionEvidence(process, "Amcache")
 	return process
 }
 
@@ -287,6 +334,7 @@ func NewProcessFromAppCompatCache(pl PlasoLog) Process {
 	process.Timestamp = int(pl.Timestamp)
 	process.CreatedTime = time.UnixMicro(int64(pl.Timestamp)).In(utc)
 
+	process = setExecutionEvidence(process, "ShimCache")
 	return process
 }
 
@@ -301,6 +349,7 @@ func NewProcessFromBAM(pl PlasoLog) Process {
 	process.Timestamp = int(pl.Timestamp)
 	process.CreatedTime = time.UnixMicro(int64(pl.Timestamp)).In(utc)
 
+	process = setExecutionEvidence(process, "BAM")
 	return process
 }
 
@@ -326,6 +375,7 @@ func NewProcessFromUserAssist(pl PlasoLog) Process {
 		process.Filename = strings.ToLower(pl.ValueName)
 	}
 
+	process = setExecutionEvidence(process, "UserAssist")
 	return process
 }
 
@@ -354,6 +404,7 @@ func NewProcessFromShellBag(pl PlasoLog) Process {
 	}
 	process.Filename = strings.ToLower(getFilename(process.FullPath))
 
+	process = setExecutionEvidence(process, "ShellBags")
 	return process
 }
 
@@ -380,5 +431,46 @@ func NewProcessFromSRUM(pl PlasoLog) Process {
 
 	process.Evidence = append(process.Evidence, pl.Message)
 
+	process = setExecutionEvidence(process, "SRUM")
 	return process
 }
+
+// NewProcessFromNetworkUsage create the Process of an application with the bytes it sent and received on an interface
+func NewProcessFromNetworkUsage(u NetworkUsage) Process {
+	var process Process
+
+	process.Timestamp = u.StartTimestamp
+	process.CreatedTime = u.Start
+	process.FullPath = u.FullPath
+	splitted_path := strings.Split(process.FullPath, "\\")
+	process.Filename = splitted_path[len(splitted_path)-1]
+	process.Computer = u.Computer
+	process.InterfaceLUID = u.InterfaceLUID
+	process.NetworkUsageStart = u.Start
+	process.NetworkUsageEnd = u.End
+
+	process = AddProcessNetworkUsage(process, u)
+
+	process = setExecutionEvidence(process, "SRUM")
+	return process
+}
+
+// AddProcessNetworkUsage add an interval of the same application and interface to the totals of the Process
+func AddProcessNetworkUsage(p Process, u NetworkUsage) Process {
+	p.BytesSent += u.BytesSent
+	p.BytesReceived += u.BytesReceived
+
+	if u.StartTimestamp < p.Timestamp {
+		p.Timestamp = u.StartTimestamp
+		p.CreatedTime = u.Start
+		p.NetworkUsageStart = u.Start
+	}
+	if u.End.After(p.NetworkUsageEnd) {
+		p.NetworkUsageEnd = u.End
+	}
+
+	p.Evidence = append(p.Evidence, u.Evidence...)
+	u.Evidence = nil
+	p.NetworkUsages = append(p.NetworkUsages, u)
+	return p
+}
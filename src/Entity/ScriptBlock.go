@@ -4,6 +4,7 @@ import (
 	"encoding/xml"
 	//	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,9 +20,42 @@ type ScriptBlock struct {
 	Path          string
 	Context       string
 	Evidence      string
+
+	// Fragments of a script block logged in several events, ordered by MessageNumber
+	Fragments []string
+
+	DecodedText      string
+	ObfuscationScore int
+
+	// 4103 ContextInfo and Windows PowerShell (400, 403, 600, 800) details
+	Type            string // "Script Block", "Module Logging", "Engine Started", "Engine Stopped", "Provider Started" or "Pipeline Execution"
+	HostApplication string
+	CommandName     string
+	CommandType     string
+	ScriptName      string
+	User            string
+	UserDomain      string
+	SessionID       string // Host ID of the PowerShell host session
+	RunspaceID      string
+	PipelineID      string
+	EngineState     string // New engine or provider state (400, 403, 600)
 }
 
+var (
+	// ClassicPowershellTypeMap map the events of the "Windows PowerShell" log to their type
+	ClassicPowershellTypeMap = map[int]string{
+		400: "Engine Started",
+		403: "Engine Stopped",
+		600: "Provider Started",
+		800: "Pipeline Execution",
+	}
+)
+
 func findScriptBlock(scriptBlocks []ScriptBlock, pwshScriptBlock ScriptBlock) int {
+	// 4103 events have no ScriptBlockID
+	if pwshScriptBlock.ScriptBlockID == "" {
+		return -1
+	}
 	for i, p := range scriptBlocks {
 		if p.ScriptBlockID == pwshScriptBlock.ScriptBlockID {
 			return i
@@ -30,11 +64,60 @@ func findScriptBlock(scriptBlocks []ScriptBlock, pwshScriptBlock ScriptBlock) in
 	return -1
 }
 
+// setFragment store the text of a fragment at its position in the script block
+func setFragment(s ScriptBlock, number int, text string) ScriptBlock {
+	if number < 1 {
+		number = 1
+	}
+	for len(s.Fragments) < number || len(s.Fragments) < s.MessageTotal {
+		s.Fragments = append(s.Fragments, "")
+	}
+	if s.Fragments[number-1] == "" {
+		s.Fragments[number-1] = text
+	}
+	return s
+}
+
+// mergeScriptBlock add the fragments of src to dest and reassemble the script
+func mergeScriptBlock(dest ScriptBlock, src ScriptBlock) ScriptBlock {
+	for j, text := range src.Fragments {
+		dest = setFragment(dest, j+1, text)
+	}
+
+	if src.Timestamp < dest.Timestamp {
+		dest.Timestamp = src.Timestamp
+		dest.Date = src.Date
+	}
+
+	if dest.Path == "" {
+		dest.Path = src.Path
+	}
+
+	dest.Text = strings.Join(dest.Fragments, "")
+	return decodeScriptBlock(dest)
+}
+
+// decodeScriptBlock decode the script, or the host command line of events without script (400, 403, 600)
+func decodeScriptBlock(s ScriptBlock) ScriptBlock {
+	script := s.Text
+	if script == "" {
+		script = s.HostApplication
+	}
+	s.DecodedText, s.ObfuscationScore = decodeScript(script)
+	return s
+}
+
 func AddScriptBlock(scriptBlocks []ScriptBlock, p ScriptBlock) []ScriptBlock {
+	if len(p.Fragments) == 0 {
+		p = setFragment(p, p.MessageNumber, p.Text)
+		p = decodeScriptBlock(p)
+	}
 
 	i := findScriptBlock(scriptBlocks, p)
 	if i == -1 {
 		scriptBlocks = append(scriptBlocks, p)
+	} else {
+		scriptBlocks[i] = mergeScriptBlock(scriptBlocks[i], p)
 	}
 	return scriptBlocks
 }
@@ -46,6 +129,51 @@ func UnionScriptBlocks(dest []ScriptBlock, src []ScriptBlock) []ScriptBlock {
 	return dest
 }
 
+// parseContextDetails parse the "Key = Value" lines of a 4103 ContextInfo or the "Key=Value" lines
+// of Windows PowerShell events. Keys are lower cased without spaces ("Host Application" -> "hostapplication").
+func parseContextDetails(context string) map[string]string {
+	details := map[string]string{}
+	for _, line := range strings.Split(context, "\n") {
+		index := strings.Index(line, "=")
+		if index == -1 {
+			continue
+		}
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(line[:index]), " ", ""))
+		if _, ok := details[key]; !ok {
+			details[key] = strings.TrimSpace(line[index+1:])
+		}
+	}
+	return details
+}
+
+// setContextDetails assign the host, command, user and runspace of a script block from its parsed details
+func setContextDetails(s ScriptBlock, details map[string]string) ScriptBlock {
+	s.HostApplication = details["hostapplication"]
+	s.CommandName = details["commandname"]
+	s.CommandType = details["commandtype"]
+	s.ScriptName = details["scriptname"]
+	s.SessionID = details["hostid"]
+	s.RunspaceID = details["runspaceid"]
+	s.PipelineID = details["pipelineid"]
+	if s.Path == "" {
+		s.Path = s.ScriptName
+	}
+
+	// "User = DOMAIN\user" in ContextInfo and "UserId=DOMAIN\user" in Windows PowerShell events
+	user := details["user"]
+	if user == "" {
+		user = details["userid"]
+	}
+	splitted := strings.Split(strings.ToLower(user), "\\")
+	if len(splitted) > 1 {
+		s.UserDomain = splitted[0]
+		s.User = splitted[1]
+	} else {
+		s.User = splitted[0]
+	}
+	return s
+}
+
 func NewScriptBlockFrom4104(evtx EvtxLog) ScriptBlock {
 	var s ScriptBlock
 
@@ -65,10 +193,13 @@ func NewScriptBlockFrom4104(evtx EvtxLog) ScriptBlock {
 	}
 	intStr := GetDataValue(evtx, "MessageNumber")
 	if intStr == "Not Found." {
+		s.Type = "Module Logging"
 		s.Text = GetDataValue(evtx, "Payload")
 		s.Context = GetDataValue(evtx, "ContextInfo")
+		s = setContextDetails(s, parseContextDetails(s.Context))
 		return s
 	}
+	s.Type = "Script Block"
 
 	i64, err = strconv.ParseInt(intStr, 0, 64)
 	handleErr(err)
@@ -104,8 +235,65 @@ func NewScriptBlockFrom4103(evtx EvtxLog) ScriptBlock {
 		return s
 	}
 
+	s.Type = "Module Logging"
 	s.Text = GetDataValue(evtx, "Payload")
 	s.Context = GetDataValue(evtx, "ContextInfo")
+	s = setContextDetails(s, parseContextDetails(s.Context))
 
 	return s
 }
+
+// NewScriptBlockFromWindowsPowershell handle the events 400, 403, 600 and 800 of the classic "Windows PowerShell" log.
+// The details ("HostApplication=...", "RunspaceId=...") are stored in an unnamed Data element.
+func NewScriptBlockFromWindowsPowershell(evtx EvtxLog) ScriptBlock {
+	var s ScriptBlock
+
+	s.Computer = evtx.System.Computer
+	xmlByte, _ := xml.Marshal(evtx)
+	s.Evidence = string(xmlByte)
+
+	t, err := time.Parse(time.RFC3339Nano, evtx.System.TimeCreated.SystemTime)
+	handleErr(err)
+	s.Date = t
+	s.Timestamp = int(t.UnixMicro())
+	i64, _ := strconv.ParseInt(evtx.System.Execution.ProcessID, 0, 64)
+	s.ProcessID = int(i64)
+	s.Type = ClassicPowershellTypeMap[evtx.System.EventID]
+
+	data := evtx.EventData.Data
+	for _, d := range data {
+		if strings.Contains(d.Text, "HostApplication=") {
+			s.Context = d.Text
+			break
+		}
+	}
+	details := parseContextDetails(s.Context)
+	s = setContextDetails(s, details)
+
+	switch evtx.System.EventID {
+	case 400, 403:
+		s.EngineState = details["newenginestate"]
+		break
+	case 600:
+		s.EngineState = details["newproviderstate"]
+		s.CommandName = details["providername"]
+		break
+	case 800:
+		// The first Data element is the pipeline
+		s.Text = details["commandline"]
+		if s.Text == "" && len(data) > 0 && data[0].Text != s.Context {
+			s.Text = data[0].Text
+		}
+		break
+	}
+
+	return s
+}
+
+// NewUserFromScriptBlock create the User running the PowerShell host (ContextInfo and Windows PowerShell events)
+func NewUserFromScriptBlock(s ScriptBlock) *User {
+	if s.User == "" {
+		return nil
+	}
+	return &User{FullName: s.User, Domain: s.UserDomain}
+}
@@ -0,0 +1,180 @@
+package Entity
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+type SecurityControlChange struct {
+	Date      time.Time
+	Timestamp int
+	Computer  string
+
+	Product  string // "Windows Defender" or "Windows Firewall"
+	Type     string
+	Title    string
+	Setting  string // Registry setting or firewall rule name
+	OldValue string
+	NewValue string
+
+	User        string
+	UserDomain  string
+	UserSID     string
+	ProcessName string // Application that performed the change (if any)
+	Evidence    []string
+}
+
+var (
+	FirewallDirectionMap = map[string]string{
+		"1": "Inbound",
+		"2": "Outbound",
+	}
+	FirewallActionMap = map[string]string{
+		"2": "Block",
+		"3": "Allow",
+	}
+)
+
+func AddSecurityControlChange(cs []SecurityControlChange, c SecurityControlChange) []SecurityControlChange {
+	if c.Title != "" {
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+func UnionSecurityControlChanges(dest []SecurityControlChange, src []SecurityControlChange) []SecurityControlChange {
+	for _, c := range src {
+		dest = AddSecurityControlChange(dest, c)
+	}
+	return dest
+}
+
+func constructSecurityControlChange(evtx EvtxLog, product string) SecurityControlChange {
+	var c SecurityControlChange
+
+	c.Product = product
+	c.Computer = evtx.System.Computer
+	t, err := time.Parse(time.RFC3339Nano, evtx.System.TimeCreated.SystemTime)
+	handleErr(err)
+	c.Date = t
+	c.Timestamp = int(t.UnixMicro())
+
+	xmlString, err := xml.Marshal(evtx)
+	handleErr(err)
+	c.Evidence = append(c.Evidence, string(xmlString))
+
+	return c
+}
+
+// splitDefenderValue split a 5007 value like "HKLM\...\DisableRealtimeMonitoring = 0x1" into setting and value
+func splitDefenderValue(value string) (string, string) {
+	if value == "Not Found." {
+		return "", ""
+	}
+	index := strings.LastIndex(value, " = ")
+	if index == -1 {
+		return value, ""
+	}
+	return value[:index], value[index+3:]
+}
+
+func NewSecurityControlChangeFromDefender(evtx EvtxLog) SecurityControlChange {
+	c := constructSecurityControlChange(evtx, "Windows Defender")
+
+	switch evtx.System.EventID {
+	case 5001:
+		c.Type = "Real-Time Protection Disabled"
+		c.Title = "Windows Defender real-time protection was disabled."
+		break
+	case 5007:
+		c.Type = "Configuration Changed"
+		oldSetting, oldValue := splitDefenderValue(GetDataValue(evtx, "Old Value"))
+		newSetting, newValue := splitDefenderValue(GetDataValue(evtx, "New Value"))
+		c.Setting = newSetting
+		if c.Setting == "" {
+			c.Setting = oldSetting
+		}
+		c.OldValue = oldValue
+		c.NewValue = newValue
+		c.Title = "Windows Defender setting " + c.Setting + " changed from \"" + c.OldValue + "\" to \"" + c.NewValue + "\"."
+		break
+	case 1013:
+		c.Type = "History Deleted"
+		tmp := GetDataValue(evtx, "User")
+		if tmp != "Not Found." {
+			splitted := strings.Split(tmp, "\\")
+			if len(splitted) > 1 {
+				c.UserDomain = strings.ToLower(splitted[0])
+				c.User = strings.ToLower(splitted[1])
+			} else {
+				c.User = strings.ToLower(tmp)
+			}
+		}
+		if tmp = GetDataValue(evtx, "SID"); tmp != "Not Found." {
+			c.UserSID = tmp
+		}
+		c.Title = "Windows Defender detection history was deleted by " + c.User + "."
+		break
+	}
+
+	return c
+}
+
+func NewSecurityControlChangeFromFirewall(evtx EvtxLog) SecurityControlChange {
+	c := constructSecurityControlChange(evtx, "Windows Firewall")
+
+	if tmp := GetDataValue(evtx, "ModifyingUser"); tmp != "Not Found." {
+		c.UserSID = tmp
+	}
+	if tmp := GetDataValue(evtx, "ModifyingApplication"); tmp != "Not Found." {
+		c.ProcessName = strings.ToLower(tmp)
+	}
+	if tmp := GetDataValue(evtx, "RuleName"); tmp != "Not Found." {
+		c.Setting = tmp
+	}
+
+	// Summarize the rule as "<Direction> <Action> ApplicationPath=... Protocol=... ..."
+	var rule []string
+	for _, tmp := range []string{FirewallDirectionMap[GetDataValue(evtx, "Direction")], FirewallActionMap[GetDataValue(evtx, "Action")]} {
+		if tmp != "" {
+			rule = append(rule, tmp)
+		}
+	}
+	for _, name := range []string{"ApplicationPath", "Protocol", "LocalPorts", "RemoteAddresses", "RemotePorts"} {
+		if tmp := GetDataValue(evtx, name); tmp != "Not Found." && tmp != "" {
+			rule = append(rule, name+"="+tmp)
+		}
+	}
+
+	switch evtx.System.EventID {
+	case 2004:
+		c.Type = "Firewall Rule Added"
+		c.NewValue = strings.Join(rule, " ")
+		c.Title = "Firewall rule " + c.Setting + " was added by " + c.ProcessName + "."
+		break
+	case 2005:
+		c.Type = "Firewall Rule Modified"
+		c.NewValue = strings.Join(rule, " ")
+		c.Title = "Firewall rule " + c.Setting + " was modified by " + c.ProcessName + "."
+		break
+	case 2006:
+		c.Type = "Firewall Rule Deleted"
+		c.Title = "Firewall rule " + c.Setting + " was deleted by " + c.ProcessName + "."
+		break
+	case 2033:
+		c.Type = "Firewall Rules Deleted"
+		c.Title = "All firewall rules were deleted by " + c.ProcessName + "."
+		break
+	}
+
+	return c
+}
+
+// NewUserFromSecurityControlChange create the User responsible for the change (if known)
+func NewUserFromSecurityControlChange(c SecurityControlChange) *User {
+	if c.User == "" {
+		return nil
+	}
+	return &User{FullName: c.User, Domain: c.UserDomain, SID: c.UserSID}
+}
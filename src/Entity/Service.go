@@ -37,7 +37,7 @@ var (
 
 func containsService(services []Service, service Service) bool {
 	for _, s := range services {
-		if s.Name == service.Name && s.Filename == service.Filename {
+		if s.Name == service.Name && s.Filename == service.Filename && s.Computer == service.Computer {
 			return true
 		}
 	}
@@ -45,7 +45,7 @@ func containsService(services []Service, service Service) bool {
 }
 
 func AddService(services []Service, service Service) []Service {
-	if containsService(services, service) {
+	if service.Name != "" && !containsService(services, service) {
 		services = append(services, service)
 	}
 	return services
@@ -83,20 +83,19 @@ func newUsersFromSecurity(evtx EvtxLog) (*User, *User) { //Best Effort
 	return u1, u2
 }
 
+// NewUserFromPath return the owner of a profile path ("C:\Users\bob\NTUSER.DAT" or "/mnt/C/Users/bob/NTUSER.DAT")
 func NewUserFromPath(path string) *User {
-	var u = new(User)
-	if strings.Contains(path, "Users") {
-		splitted := strings.Split(path, "\\")
-		if len(splitted) == 1 {
-			splitted = strings.Split(path, "/")
-		}
-		//fmt.Println(splitted)
-		u.FullName = strings.ToLower(splitted[2])
+	splitted := strings.Split(path, "\\")
+	if len(splitted) == 1 {
+		splitted = strings.Split(path, "/")
+	}
 
-	} else {
-		return nil // Not a user
+	for i, part := range splitted {
+		if part == "Users" && i+1 < len(splitted) && splitted[i+1] != "" {
+			return &User{FullName: strings.ToLower(splitted[i+1])}
+		}
 	}
-	return u
+	return nil // Not a user
 }
 
 func NewUserFromSAM(pl PlasoLog) *User {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -18,8 +19,28 @@ type WebHistory struct {
 	VisitCount      int
 	Computer        string
 	Evidence        []string
+
+	Browser string // Chrome, Edge, Firefox, Safari, Internet Explorer...
+	Type    string // "Visit" or "Download"
+
+	// Downloads
+	DownloadPath  string
+	Referrer      string
+	MimeType      string
+	ReceivedBytes int
+	TotalBytes    int
 }
 
+var (
+	urlRegex = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9+.-]*://(?P<domain>[^/]+)(?P<path>.*)")
+
+	// "Visited: bob@https://example.com/" in MSIE index.dat and WebCache history containers
+	msieVisitRegex = regexp.MustCompile(`^Visited: ([^@]*)@(.*)$`)
+
+	// Target path of a download in the response headers of the "iedownload:" entries, stored after the URL and the referrer
+	msieDownloadPathRegex = regexp.MustCompile(`[A-Za-z]:\\[^\x00-\x1f"*<>?|]+`)
+)
+
 func AddWebHistory(whs []WebHistory, wh WebHistory) []WebHistory {
 	if wh.Url != "" {
 		whs = append(whs, wh)
@@ -34,23 +55,74 @@ func UnionWebHistories(dest []WebHistory, src []WebHistory) []WebHistory {
 	return dest
 }
 
-func NewWebHistoryFromFirefox(pl PlasoLog) WebHistory {
+// splitUrl split an url into domain and path
+func splitUrl(url string) (string, string) {
+	matches := urlRegex.FindStringSubmatch(url)
+	if len(matches) != 3 {
+		return "", ""
+	}
+	return matches[1], matches[2]
+}
+
+// getBrowser return the browser that wrote a history database. Chromium based browsers share the Chrome parsers.
+func getBrowser(pl PlasoLog) string {
+	switch {
+	case strings.HasPrefix(pl.DataType, "firefox:"):
+		return "Firefox"
+	case strings.HasPrefix(pl.DataType, "safari:"):
+		return "Safari"
+	case strings.HasPrefix(pl.DataType, "msiecf:"):
+		return "Internet Explorer"
+	case strings.HasPrefix(pl.DataType, "msie:webcache:"):
+		return "Internet Explorer / Edge Legacy"
+	}
+
+	path := strings.ReplaceAll(pl.Filename, "/", "\\")
+	switch {
+	case strings.Contains(path, "Microsoft\\Edge"):
+		return "Edge"
+	case strings.Contains(path, "BraveSoftware"):
+		return "Brave"
+	case strings.Contains(path, "Opera Software"):
+		return "Opera"
+	case strings.Contains(path, "Vivaldi"):
+		return "Vivaldi"
+	}
+	return "Chrome"
+}
+
+// constructWebHistory create a WebHistory with the fields shared by every browser
+func constructWebHistory(pl PlasoLog) WebHistory {
 	var wh = *new(WebHistory)
+
 	wh.Url = pl.Url
 	wh.Title = pl.Title
-	wh.Domain = pl.Host
+	wh.Browser = getBrowser(pl)
+	wh.Type = "Visit"
+	wh.Domain, wh.Path = splitUrl(pl.Url)
 
 	var utc, _ = time.LoadLocation("UTC")
 	wh.Timestamp = int(pl.Timestamp)
-	wh.LastTimeVisited = time.UnixMicro(int64(wh.Timestamp)).In(utc)
-	wh.VisitCount = pl.VisitCount
+	wh.LastTimeVisited = time.UnixMicro(int64(pl.Timestamp)).In(utc)
 
 	wh.Evidence = append(wh.Evidence, pl.Message)
 
 	u := NewUserFromPath(pl.Filename)
-	if u != nil || u.FullName != "" {
+	if u != nil {
 		wh.User = u.FullName
-	} else {
+	}
+
+	return wh
+}
+
+func NewWebHistoryFromFirefox(pl PlasoLog) WebHistory {
+	wh := constructWebHistory(pl)
+	if pl.Host != "" {
+		wh.Domain = pl.Host
+	}
+	wh.VisitCount = pl.VisitCount
+
+	if wh.User == "" {
 		log.Println("Error parsing user from path: ", pl.Filename)
 	}
 
@@ -58,32 +130,84 @@ func NewWebHistoryFromFirefox(pl PlasoLog) WebHistory {
 }
 
 func NewWebHistoryFromChrome(pl PlasoLog) WebHistory {
-	var wh = *new(WebHistory)
+	wh := constructWebHistory(pl)
+	wh.VisitCount = pl.TypedCount
 
-	wh.Url = pl.Url
-	wh.Title = pl.Title
+	if wh.Domain == "" {
+		log.Println("Error parsing Domain and Path from Url: ", pl.Url, ": ", fmt.Sprint(urlRegex.FindStringSubmatch(pl.Url)))
+	}
 
-	var utc, _ = time.LoadLocation("UTC")
-	wh.Timestamp = int(pl.Timestamp)
-	wh.LastTimeVisited = time.UnixMicro(int64(pl.Timestamp / 1000000)).In(utc)
+	return wh
+}
 
-	wh.VisitCount = pl.TypedCount
+func NewWebHistoryFromSafari(pl PlasoLog) WebHistory {
+	wh := constructWebHistory(pl)
+	if wh.Title == "" {
+		wh.Title = pl.DisplayTitle
+	}
+	wh.VisitCount = pl.VisitCount
 
-	wh.Evidence = append(wh.Evidence, pl.Message)
+	return wh
+}
 
-	u := NewUserFromPath(pl.Filename)
-	if u != nil {
-		wh.User = u.FullName
+// NewWebHistoryFromMsie handle the history entries of MSIE index.dat and WebCache (IE 10+ and Edge Legacy).
+// Only "Visited:" urls are visits, the other entries are cached content, cookies or downloads.
+func NewWebHistoryFromMsie(pl PlasoLog) WebHistory {
+	var wh WebHistory
+
+	matches := msieVisitRegex.FindStringSubmatch(pl.Url)
+	if len(matches) != 3 {
+		return wh
 	}
+	pl.Url = matches[2]
+
+	wh = constructWebHistory(pl)
+	if matches[1] != "" {
+		wh.User = strings.ToLower(matches[1])
+	}
+	wh.VisitCount = pl.AccessCount
+
+	return wh
+}
+
+// getMsieDownloadPath return the target path of an "iedownload:" entry, "" when its response headers do not hold one.
+// The cached filename is the WebCache container file, not the downloaded file.
+func getMsieDownloadPath(pl PlasoLog) string {
+	paths := msieDownloadPathRegex.FindAllString(strings.ReplaceAll(pl.ResponseHeaders, "\x00", ""), -1)
+	if len(paths) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(paths[len(paths)-1])
+}
+
+// NewWebHistoryFromMsieDownload handle the "iedownload:" entries of the WebCache
+func NewWebHistoryFromMsieDownload(pl PlasoLog) WebHistory {
+	var wh WebHistory
+
+	if !strings.HasPrefix(pl.Url, "iedownload:") {
+		return wh
+	}
+	pl.Url = strings.TrimPrefix(pl.Url, "iedownload:")
+
+	wh = constructWebHistory(pl)
+	wh.Type = "Download"
+	wh.DownloadPath = getMsieDownloadPath(pl)
+
+	return wh
+}
 
-	r, err := regexp.Compile("http(?:s|)://(?P<domain>[^/]+)(?P<path>.*)")
-	handleErr(err)
-	matches := r.FindStringSubmatch(pl.Url)
-	if len(matches) == 3 {
-		wh.Domain = matches[1]
-		wh.Path = matches[2]
-	} else {
-		log.Println("Error parsing Domain and Path from Url: ", pl.Url, ": ", fmt.Sprint(matches))
+// NewWebHistoryFromDownload handle Chrome (and Chromium based browsers) and Firefox downloads
+func NewWebHistoryFromDownload(pl PlasoLog) WebHistory {
+	wh := constructWebHistory(pl)
+
+	wh.Type = "Download"
+	wh.DownloadPath = pl.FullPath
+	wh.Referrer = pl.Referrer
+	wh.MimeType = pl.MimeType
+	wh.ReceivedBytes = pl.ReceivedBytes
+	wh.TotalBytes = pl.TotalBytes
+	if wh.Title == "" {
+		wh.Title = getFilename(strings.ReplaceAll(wh.DownloadPath, "/", "\\"))
 	}
 
 	return wh
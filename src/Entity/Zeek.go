@@ -0,0 +1,331 @@
+package Entity
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	zeekUnsetField = "-"
+	zeekEmptyField = "(empty)"
+)
+
+// getZeekLogKind return the kind of a Zeek log from its name (conn.log, dns.09:00:00-10:00:00.log, http.json...)
+func getZeekLogKind(path string) string {
+	filename := strings.ToLower(filepath.Base(path))
+	extension := filepath.Ext(filename)
+	if extension != ".log" && extension != ".json" && extension != ".jsonl" {
+		return ""
+	}
+
+	for _, kind := range []string{"conn", "dns", "http"} {
+		if filename == kind+extension || strings.HasPrefix(filename, kind+".") {
+			return kind
+		}
+	}
+	return ""
+}
+
+// getZeekRecordKind return the kind of a Zeek log from the fields of a record, for the logs renamed when they were collected.
+// JSON logs may carry their kind in the "_path" field.
+func getZeekRecordKind(record map[string]string) string {
+	if path, ok := record["_path"]; ok {
+		return path
+	}
+
+	for _, kind := range []struct {
+		name   string
+		fields []string
+	}{
+		{"conn", []string{"conn_state", "history"}},
+		{"dns", []string{"query", "qtype_name", "rcode"}},
+		{"http", []string{"method", "uri", "status_code"}},
+	} {
+		for _, field := range kind.fields {
+			if _, ok := record[field]; ok {
+				return kind.name
+			}
+		}
+	}
+	return ""
+}
+
+// FindZeekLogs list the conn, dns and http logs of a path, a single file or a directory and its sub directories
+func FindZeekLogs(path string) []string {
+	var paths []string
+
+	info, err := os.Stat(path)
+	handleErr(err)
+	if !info.IsDir() {
+		return []string{path}
+	}
+
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && getZeekLogKind(p) != "" {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	handleErr(err)
+	return paths
+}
+
+// unescapeZeekSeparator decode the separator of the TSV header ("#separator \x09")
+func unescapeZeekSeparator(value string) string {
+	if strings.HasPrefix(value, "\\x") && len(value) == 4 {
+		if b, err := strconv.ParseUint(value[2:], 16, 8); err == nil {
+			return string(rune(b))
+		}
+	}
+	return value
+}
+
+// getZeekJsonValue convert a value of a Zeek JSON log to its TSV representation
+func getZeekJsonValue(v interface{}) string {
+	switch value := v.(type) {
+	case bool:
+		if value {
+			return "T"
+		}
+		return "F"
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			values = append(values, getJsonString(item))
+		}
+		return strings.Join(values, ",")
+	}
+	return getJsonString(v)
+}
+
+// ReadZeekLog read the records of a Zeek log written in TSV (default) or JSON (LogAscii::use_json)
+func ReadZeekLog(path string) []map[string]string {
+	var records []map[string]string
+
+	file, err := os.Open(path)
+	handleErr(err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	buf := make([]byte, 0, 128*1024)
+	scanner.Buffer(buf, 2048*1024)
+
+	separator := "\t"
+	setSeparator := ","
+	var fields []string
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "{") {
+			var value map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &value); err != nil {
+				continue
+			}
+			record := map[string]string{}
+			for key, v := range value {
+				record[key] = getZeekJsonValue(v)
+			}
+			records = append(records, record)
+			continue
+		}
+
+		if strings.HasPrefix(line, "#separator ") {
+			separator = unescapeZeekSeparator(strings.TrimPrefix(line, "#separator "))
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			header := strings.Split(line, separator)
+			switch header[0] {
+			case "#fields":
+				fields = header[1:]
+				break
+			case "#set_separator":
+				if len(header) > 1 {
+					setSeparator = header[1]
+				}
+				break
+			}
+			continue
+		}
+
+		// Lines before the #fields header are not records
+		if fields == nil {
+			continue
+		}
+
+		values := strings.Split(line, separator)
+		record := map[string]string{}
+		for i, field := range fields {
+			if i >= len(values) || values[i] == zeekUnsetField || values[i] == zeekEmptyField {
+				record[field] = ""
+				continue
+			}
+			record[field] = strings.ReplaceAll(values[i], setSeparator, ",")
+		}
+		records = append(records, record)
+	}
+	handleErr(scanner.Err())
+
+	return records
+}
+
+// parseZeekTime parse the "ts" field, epoch seconds in TSV and ISO 8601 in JSON logs written with JSON::TS_ISO8601
+func parseZeekTime(value string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.UTC(), true
+	}
+
+	splitted := strings.SplitN(value, ".", 2)
+	seconds, err := strconv.ParseInt(splitted[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var nanoseconds int64
+	if len(splitted) == 2 {
+		fraction := (splitted[1] + "000000000")[:9]
+		nanoseconds, err = strconv.ParseInt(fraction, 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+	}
+	return time.Unix(seconds, nanoseconds).UTC(), true
+}
+
+// formatZeekRecord format a record for the evidence of the entities
+func formatZeekRecord(record map[string]string) string {
+	b, err := json.Marshal(record)
+	handleErr(err)
+	return string(b)
+}
+
+// constructConnectionFromZeek create a Connection with the fields shared by conn.log and http.log
+func constructConnectionFromZeek(record map[string]string) Connection {
+	var c Connection
+
+	if t, ok := parseZeekTime(record["ts"]); ok {
+		c.Date = t
+		c.Timestamp = int(t.UnixNano())
+	}
+
+	c.Uid = record["uid"]
+	c.SourceIP = record["id.orig_h"]
+	c.SourcePort, _ = strconv.Atoi(record["id.orig_p"])
+	c.DestinationIP = record["id.resp_h"]
+	c.DestinationPort, _ = strconv.Atoi(record["id.resp_p"])
+	c.Protocol = normalizeProtocol(record["proto"])
+	c.Initiated = true
+	c.Source = []string{"Zeek"}
+	c.Evidence = append(c.Evidence, formatZeekRecord(record))
+
+	// AddConnection ignore connections without addresses
+	if c.SourceIP == "" || c.DestinationIP == "" {
+		c.SourceIP = "Not Found."
+	}
+	return c
+}
+
+// NewConnectionFromZeekConn handle a record of conn.log
+func NewConnectionFromZeekConn(record map[string]string) Connection {
+	c := constructConnectionFromZeek(record)
+
+	c.Service = record["service"]
+	c.State = record["conn_state"]
+	c.Duration, _ = strconv.ParseFloat(record["duration"], 64)
+	c.BytesSent, _ = strconv.Atoi(record["orig_bytes"])
+	c.BytesReceived, _ = strconv.Atoi(record["resp_bytes"])
+	return c
+}
+
+// NewConnectionFromZeekHttp handle a record of http.log, it is merged with its conn.log record by uid
+func NewConnectionFromZeekHttp(record map[string]string) Connection {
+	c := constructConnectionFromZeek(record)
+
+	c.Service = "http"
+	c.HttpMethod = record["method"]
+	c.HttpHost = record["host"]
+	if record["uri"] != "" {
+		c.HttpUris = append(c.HttpUris, record["uri"])
+	}
+	c.HttpUserAgent = record["user_agent"]
+	c.HttpStatus, _ = strconv.Atoi(record["status_code"])
+	return c
+}
+
+// NewDnsQueryFromZeek handle a record of dns.log
+func NewDnsQueryFromZeek(record map[string]string) DnsQuery {
+	var q DnsQuery
+
+	if t, ok := parseZeekTime(record["ts"]); ok {
+		q.Date = t
+		q.Timestamp = int(t.UnixNano())
+	}
+
+	q.Uid = record["uid"]
+	q.SourceIP = record["id.orig_h"]
+	q.SourcePort, _ = strconv.Atoi(record["id.orig_p"])
+	q.DestinationIP = record["id.resp_h"]
+	q.DestinationPort, _ = strconv.Atoi(record["id.resp_p"])
+	q.Protocol = normalizeProtocol(record["proto"])
+
+	q.Query = strings.ToLower(strings.TrimSuffix(record["query"], "."))
+	q.QueryType = record["qtype_name"]
+	q.ResponseCode = record["rcode_name"]
+	if record["answers"] != "" {
+		q.Answers = strings.Split(record["answers"], ",")
+	}
+
+	q.Evidence = append(q.Evidence, formatZeekRecord(record))
+	return q
+}
+
+// ParseZeekLogs parse Zeek conn.log, dns.log and http.log into Connection and DnsQuery entities.
+// Network telemetry is not tied to a Computer, the default computer is not assigned.
+func ParseZeekLogs(data []interface{}, paths []string, args map[string]interface{}) []interface{} {
+	for _, path := range paths {
+		var connections []Connection
+		var queries []DnsQuery
+		index := map[dnsQueryKey]int{}
+
+		records := ReadZeekLog(path)
+		kind := getZeekLogKind(path)
+		if kind == "" && len(records) > 0 {
+			kind = getZeekRecordKind(records[0])
+		}
+		if kind != "conn" && kind != "dns" && kind != "http" {
+			log.Println("Unsupported Zeek log, only conn, dns and http logs are parsed - ", path)
+			continue
+		}
+
+		for _, record := range records {
+			switch kind {
+			case "conn":
+				connections = AddConnection(connections, NewConnectionFromZeekConn(record))
+				break
+			case "http":
+				connections = AddConnection(connections, NewConnectionFromZeekHttp(record))
+				break
+			case "dns":
+				queries = addDnsQuery(queries, index, NewDnsQueryFromZeek(record))
+				break
+			}
+		}
+
+		for _, entities := range []interface{}{connections, queries} {
+			data = unionEntities(data, entities, "")
+		}
+	}
+	return data
+}
@@ -0,0 +1,82 @@
+package Entity
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadZeekLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		records []map[string]string
+	}{
+		{
+			"tsv",
+			"#separator \\x09\n#set_separator\t,\n#empty_field\t(empty)\n#unset_field\t-\n#path\tdns\n" +
+				"#fields\tts\tuid\tid.orig_h\tquery\tanswers\trcode_name\n" +
+				"#types\ttime\tstring\taddr\tstring\tvector[string]\tstring\n" +
+				"1672531200.123456\tC1\t10.0.0.1\texample.com\t93.184.216.34,2606:2800:220:1::\tNOERROR\n" +
+				"1672531201.000000\tC2\t10.0.0.1\tunknown.local\t(empty)\t-\n" +
+				"#close\t2023-01-01-00-00-02\n",
+			[]map[string]string{
+				{"ts": "1672531200.123456", "uid": "C1", "id.orig_h": "10.0.0.1", "query": "example.com", "answers": "93.184.216.34,2606:2800:220:1::", "rcode_name": "NOERROR"},
+				{"ts": "1672531201.000000", "uid": "C2", "id.orig_h": "10.0.0.1", "query": "unknown.local", "answers": "", "rcode_name": ""},
+			},
+		},
+		{
+			"tsv with another set separator",
+			"#separator \\x09\n#set_separator\t|\n#fields\tuid\tanswers\nC1\t1.1.1.1|8.8.8.8\n",
+			[]map[string]string{
+				{"uid": "C1", "answers": "1.1.1.1,8.8.8.8"},
+			},
+		},
+		{
+			"json",
+			"{\"ts\":1672531200.5,\"uid\":\"C1\",\"id.orig_h\":\"10.0.0.1\",\"id.orig_p\":49152,\"local_orig\":true,\"answers\":[\"1.1.1.1\",\"8.8.8.8\"]}\n" +
+				"not a record\n" +
+				"{\"ts\":\"2023-01-01T00:00:01.000000Z\",\"uid\":\"C2\",\"local_orig\":false}\n",
+			[]map[string]string{
+				{"ts": "1672531200.5", "uid": "C1", "id.orig_h": "10.0.0.1", "id.orig_p": "49152", "local_orig": "T", "answers": "1.1.1.1,8.8.8.8"},
+				{"ts": "2023-01-01T00:00:01.000000Z", "uid": "C2", "local_orig": "F"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dns.log")
+			if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if records := ReadZeekLog(path); !reflect.DeepEqual(records, test.records) {
+				t.Errorf("ReadZeekLog() = %v, want %v", records, test.records)
+			}
+		})
+	}
+}
+
+func TestGetZeekRecordKind(t *testing.T) {
+	tests := []struct {
+		name   string
+		record map[string]string
+		kind   string
+	}{
+		{"_path field", map[string]string{"_path": "dns", "uid": "C1"}, "dns"},
+		{"conn", map[string]string{"uid": "C1", "conn_state": "SF", "history": "ShADadFf"}, "conn"},
+		{"dns", map[string]string{"uid": "C1", "query": "example.com", "qtype_name": "A"}, "dns"},
+		{"http", map[string]string{"uid": "C1", "method": "GET", "host": "example.com", "uri": "/"}, "http"},
+		{"unknown", map[string]string{"uid": "C1", "server_name": "example.com"}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if kind := getZeekRecordKind(test.record); kind != test.kind {
+				t.Errorf("getZeekRecordKind(%v) = %q, want %q", test.record, kind, test.kind)
+			}
+		})
+	}
+}
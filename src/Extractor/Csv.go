@@ -1,293 +1,160 @@
 package Extractor
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
-	"log"
 	"os"
-	. "plaso2graph/master/src/Entity"
+	"path/filepath"
+	. "plaso2graph/master/src/Linker"
+	"sort"
+	"strings"
+	"time"
 )
 
-func InitializeCsvExtractor(args map[string]interface{}) map[string]interface{} {
-	if args["output"] == nil {
-		log.Fatal("Output directory is required")
-	}
-
-	if args["verbose"] == nil {
-		args["verbose"] = false
-	}
+// The CSV extractor writes one file per node label (process.csv, user.csv...) and one file per relationship type
+// (relationship_execute.csv...), nodes are identified by the "id" column referenced by the start_id and end_id columns
 
-	output := args["output"].(string)
-	args["output_files"] = map[string]*os.File{}
-	args["output_files"].(map[string]*os.File)["user"] = InitializeUserCsv(output)
-	args["output_files"].(map[string]*os.File)["process"] = InitializeProcessCsv(output)
-	args["output_files"].(map[string]*os.File)["file"] = InitializeFileCsv(output)
-	args["output_files"].(map[string]*os.File)["task"] = InitializeTaskCsv(output)
-	args["output_files"].(map[string]*os.File)["computer"] = InitializeComputerCsv(output)
-	args["output_files"].(map[string]*os.File)["domain"] = InitializeDomainCsv(output)
-	args["output_files"].(map[string]*os.File)["webhistory"] = InitializeWebHistoryCsv(output)
-	return args
+func InitializeCsvExtractor(args map[string]interface{}) map[string]interface{} {
+	return initializeGraphExtractor(args)
 }
 
-func InitializeUserCsv(output string) *os.File {
-	file, err := os.OpenFile(output+"/user.csv", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	handleError(err)
-
-	_, err = file.WriteString("Name,Username,Domain,SID,Comments")
-	return file
+// CsvExtract keep the entities of a batch, the files are written once every relationship is known
+func CsvExtract(data []interface{}, args map[string]interface{}) {
+	addGraphEntities(data, args)
 }
 
-func InitializeProcessCsv(output string) *os.File {
-	file, err := os.OpenFile(output+"/process.csv", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	handleError(err)
-
-	_, err = file.WriteString("Timestamp,CreatedTime,Filename,FullPath,Commandline,PID,ParentProcessName,ParentProcessCommandline,PPID,User,UserDomain,Computer,LogonID,Evidence")
-	handleError(err)
+func CsvPostProcessing(args map[string]interface{}) {
+	graph, _ := linkGraph(args)
+	output := args["output"].(string)
 
-	return file
-}
+	fmt.Println("Writing nodes...")
+	WriteNodesCsv(graph.Nodes, output)
 
-func InitializeFileCsv(output string) *os.File {
-	file, err := os.OpenFile(output+"/file.csv", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	handleError(err)
-	_, err = file.WriteString("Timestamp,Date,TimestampDesc,Filename,FullPath,Extension,IsAllocated,Evidence")
-	return file
+	fmt.Println("Writing relationships...")
+	WriteRelationshipsCsv(graph.Relationships, output)
 }
 
-func InitializeTaskCsv(output string) *os.File {
-	file, err := os.OpenFile(output+"/task.csv", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	handleError(err)
-	_, err = file.WriteString("Application,Comment,Trigger,User")
-	return file
+// getCsvFilename keep the names of the files written by the previous versions (task.csv for the ScheduledTasks)
+func getCsvFilename(label string) string {
+	if label == "ScheduledTask" {
+		return "task.csv"
+	}
+	return strings.ToLower(label) + ".csv"
 }
 
-func InitializeComputerCsv(output string) *os.File {
-	file, err := os.OpenFile(output+"/computer.csv", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	handleError(err)
-
-	_, err = file.WriteString("Name, Domain")
-	handleError(err)
-
-	return file
+// formatValue format a property for the text based extractors, lists are written as JSON arrays
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339Nano)
+	case []string:
+		if len(v) == 0 {
+			return ""
+		}
+		b, err := json.Marshal(v)
+		handleError(err)
+		return string(b)
+	}
+	return fmt.Sprint(value)
 }
 
-func InitializeDomainCsv(output string) *os.File {
-	file, err := os.OpenFile(output+"/domain.csv", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+func createCsv(path string, header []string) (*os.File, *csv.Writer) {
+	file, err := os.Create(path)
 	handleError(err)
 
-	_, err = file.WriteString("Name")
-
-	return file
+	writer := csv.NewWriter(file)
+	handleError(writer.Write(header))
+	return file, writer
 }
 
-func InitializeWebHistoryCsv(output string) *os.File {
-	file, err := os.OpenFile(output+"/webhistory.csv", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	handleError(err)
-
-	_, err = file.WriteString("Timestamp,LastTimeVisited,URL,Title,User,Domain,Path,VisitCount,Evidence")
-	handleError(err)
-	return file
+func closeCsv(file *os.File, writer *csv.Writer) {
+	writer.Flush()
+	handleError(writer.Error())
+	handleError(file.Close())
 }
 
-func CsvExtract(data []interface{}, args map[string]interface{}) {
-	if args["output"] == nil {
-		log.Fatal("Output directory is required")
-	}
+func WriteNodesCsv(nodes []Node, output string) {
+	files := map[string]*os.File{}
+	writers := map[string]*csv.Writer{}
 
-	if args["verbose"] == nil {
-		args["verbose"] = false
-	}
+	for _, n := range nodes {
+		properties := getProperties(n.Entity)
 
-	for _, d := range data {
-		switch d.(type) {
-		case []Process:
-			InsertProcessesCsv(d.([]Process), args)
-			break
-		case []User:
-			InsertUsersCsv(d.([]User), args)
-			break
-		case []File:
-			InsertFilesCsv(d.([]File), args)
-			break
-		case []ScheduledTask:
-			InsertTasksCsv(d.([]ScheduledTask), args)
-			break
-		case []Computer:
-			InsertComputersCsv(d.([]Computer), args)
-			break
-		case []Domain:
-			InsertDomainsCsv(d.([]Domain), args)
-			break
-		case []WebHistory:
-			InsertWebHistoriesCsv(d.([]WebHistory), args)
-			break
+		writer, ok := writers[n.Label]
+		if !ok {
+			header := []string{"id"}
+			for _, p := range properties {
+				header = append(header, p.Name)
+			}
+			files[n.Label], writer = createCsv(filepath.Join(output, getCsvFilename(n.Label)), header)
+			writers[n.Label] = writer
 		}
-	}
-
-}
-
-func InsertProcessesCsv(processes []Process, args map[string]interface{}) {
-
-	file := args["output_files"].(map[string]*os.File)["process"]
-
-	for _, p := range processes {
-		InsertProcessCsv(p, file)
-	}
-}
-
-func InsertProcessCsv(process Process, file *os.File) {
-	var str string
-
-	str += fmt.Sprint(process.Timestamp) + ","
-	str += fmt.Sprint(process.CreatedTime) + ","
-	str += process.Filename + ","
-	str += process.FullPath + ","
-	str += process.Commandline + ","
-	str += fmt.Sprint(process.PID) + ","
-
-	str += process.ParentProcessName + ","
-	str += process.ParentProcessCommandline + ","
-	str += fmt.Sprint(process.PPID) + ","
-
-	str += process.User + ","
-	str += process.UserDomain + ","
-	str += process.Computer + ","
-	str += fmt.Sprint(process.LogonID) + ","
-	str += fmt.Sprint(process.Evidence)
-
-	_, err := file.WriteString(str)
-	handleError(err)
-}
 
-func InsertUsersCsv(users []User, args map[string]interface{}) {
-
-	file := args["output_files"].(map[string]*os.File)["user"]
-
-	for _, u := range users {
-		InsertUserCsv(u, file)
+		row := []string{n.ID}
+		for _, p := range properties {
+			row = append(row, formatValue(p.Value))
+		}
+		handleError(writer.Write(row))
 	}
-}
-
-func InsertUserCsv(user User, file *os.File) {
-	var str string
-
-	str += user.FullName + ","
-	str += user.Username + ","
-	str += user.Domain + ","
-	str += user.SID + ","
-	str += user.Comments
-	str += "\n"
 
-	_, err := file.WriteString(str)
-	handleError(err)
-}
-
-func InsertFilesCsv(files []File, args map[string]interface{}) {
-
-	file := args["output_files"].(map[string]*os.File)["file"]
-
-	for _, f := range files {
-		InsertFileCsv(f, file)
+	for label, writer := range writers {
+		closeCsv(files[label], writer)
 	}
 }
 
-func InsertFileCsv(file File, f *os.File) {
-	var str string
-
-	str += fmt.Sprint(file.Timestamp) + ","
-	str += fmt.Sprint(file.Date) + ","
-	str += file.TimestampDesc + ","
-	str += file.Filename + ","
-	str += file.FullPath + ","
-	str += file.Extension + ","
-	str += fmt.Sprint(file.IsAllocated) + ","
-	str += fmt.Sprint(file.Evidence)
-	str += "\n"
-
-	_, err := f.WriteString(str)
-	handleError(err)
-}
-
-func InsertTasksCsv(tasks []ScheduledTask, args map[string]interface{}) {
-	file := args["output_files"].(map[string]*os.File)["task"]
-
-	for _, t := range tasks {
-		InsertTaskCsv(t, file)
+// getRelationshipKeys list the properties of every relationship type, sorted by name
+func getRelationshipKeys(relationships []Relationship) map[string][]string {
+	keys := map[string][]string{}
+	seen := map[string]bool{}
+	for _, r := range relationships {
+		if _, ok := keys[r.Type]; !ok {
+			keys[r.Type] = []string{}
+		}
+		for key := range r.Properties {
+			if !seen[r.Type+"."+key] {
+				seen[r.Type+"."+key] = true
+				keys[r.Type] = append(keys[r.Type], key)
+			}
+		}
 	}
-}
-
-func InsertTaskCsv(task ScheduledTask, file *os.File) {
-	var str string
-
-	str += task.Application + ","
-	str += task.Comment + ","
-	str += task.Trigger + ","
-	str += task.User
-	str += "\n"
-
-	_, err := file.WriteString(str)
-	handleError(err)
-}
-
-func InsertComputersCsv(computers []Computer, args map[string]interface{}) {
-
-	file := args["output_files"].(map[string]*os.File)["computer"]
-
-	for _, c := range computers {
-		InsertComputerCsv(c, file)
+	for _, k := range keys {
+		sort.Strings(k)
 	}
+	return keys
 }
 
-func InsertComputerCsv(computer Computer, file *os.File) {
-	var str string
-
-	str += computer.Name + ","
-	str += computer.Domain
-	str += "\n"
-
-	_, err := file.WriteString(str)
-	handleError(err)
-}
-
-func InsertWebHistoriesCsv(webhistory []WebHistory, args map[string]interface{}) {
+func WriteRelationshipsCsv(relationships []Relationship, output string) {
+	keys := getRelationshipKeys(relationships)
+	files := map[string]*os.File{}
+	writers := map[string]*csv.Writer{}
 
-	file := args["output_files"].(map[string]*os.File)["webhistory"]
+	for _, r := range relationships {
+		writer, ok := writers[r.Type]
+		if !ok {
+			header := append([]string{"start_id", "end_id", "type"}, keys[r.Type]...)
+			files[r.Type], writer = createCsv(filepath.Join(output, "relationship_"+strings.ToLower(r.Type)+".csv"), header)
+			writers[r.Type] = writer
+		}
 
-	for _, w := range webhistory {
-		InsertWebHistoryCsv(w, file)
+		row := []string{r.StartID, r.EndID, r.Type}
+		for _, key := range keys[r.Type] {
+			value, ok := r.Properties[key]
+			if !ok {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, formatValue(value))
+		}
+		handleError(writer.Write(row))
 	}
-}
-
-func InsertWebHistoryCsv(webhistory WebHistory, file *os.File) {
-	var str string
-
-	str += fmt.Sprint(webhistory.Timestamp) + ","
-	str += fmt.Sprint(webhistory.LastTimeVisited) + ","
-	str += webhistory.Url + ","
-	str += webhistory.Title + ","
-	str += webhistory.User + ","
-	str += webhistory.Domain + ","
-	str += webhistory.Path + ","
-	str += fmt.Sprint(webhistory.VisitCount)
-	str += fmt.Sprint(webhistory.Evidence)
-	str += "\n"
-
-	_, err := file.WriteString(str)
-	handleError(err)
-}
 
-func InsertDomainsCsv(domains []Domain, args map[string]interface{}) {
-	file := args["output_files"].(map[string]*os.File)["domain"]
-	for _, d := range domains {
-		InsertDomainCsv(d, file)
+	for relType, writer := range writers {
+		closeCsv(files[relType], writer)
 	}
 }
-
-func InsertDomainCsv(domain Domain, file *os.File) {
-	var str string
-
-	str += domain.Name
-	str += "\n"
-
-	_, err := file.WriteString(str)
-	handleError(err)
-}
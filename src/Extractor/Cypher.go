@@ -0,0 +1,240 @@
+package Extractor
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	. "plaso2graph/master/src/Linker"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// The Cypher extractor writes case.cypher, a script replaying the neo4j extractor without a connection to Neo4j:
+// the uniqueness constraints on the id of every label, the nodes and the relationships by batch of 1000 (UNWIND of literal lists)
+// and the post-processing queries. The nodes are created with the queries of the neo4j extractor, so the graph is the same.
+// Replay it with: cypher-shell -u neo4j -p <password> -f case.cypher
+
+const cypherBatchSize = 1000
+
+var (
+	cypherParameterRegexp  = regexp.MustCompile(`\$(\w+)`)
+	cypherWhitespaceRegexp = regexp.MustCompile(`\s+`)
+	cypherIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// cypherRecorder is a neo4j.Transaction keeping the query and the parameters of the neo4j extractor instead of running them
+type cypherRecorder struct {
+	query      string
+	parameters map[string]interface{}
+}
+
+func (r *cypherRecorder) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	r.query = cypher
+	r.parameters = params
+	return nil, nil
+}
+
+func (r *cypherRecorder) Commit() error   { return nil }
+func (r *cypherRecorder) Rollback() error { return nil }
+func (r *cypherRecorder) Close() error    { return nil }
+
+func InitializeCypherExtractor(args map[string]interface{}) map[string]interface{} {
+	return initializeGraphExtractor(args)
+}
+
+func CypherExtract(data []interface{}, args map[string]interface{}) {
+	addGraphEntities(data, args)
+}
+
+func CypherPostProcessing(args map[string]interface{}) {
+	graph, _ := linkGraph(args)
+
+	fmt.Println("Writing case.cypher...")
+	WriteCypher(graph, filepath.Join(args["output"].(string), "case.cypher"))
+}
+
+// getCypherString quote a string for Cypher
+func getCypherString(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range strings.ToValidUTF8(s, "�") {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+			break
+		case '\'':
+			b.WriteString(`\'`)
+			break
+		case '\n':
+			b.WriteString(`\n`)
+			break
+		case '\r':
+			b.WriteString(`\r`)
+			break
+		case '\t':
+			b.WriteString(`\t`)
+			break
+		default:
+			if r < 0x20 || r == 0x7f {
+				b.WriteString(fmt.Sprintf(`\u%04x`, r))
+			} else {
+				b.WriteRune(r)
+			}
+			break
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// getCypherKey quote a map key when it is not an identifier
+func getCypherKey(key string) string {
+	if cypherIdentifierRegexp.MatchString(key) {
+		return key
+	}
+	return "`" + strings.ReplaceAll(key, "`", "``") + "`"
+}
+
+// getCypherDateTime write a date like the driver sends it: the same instant in the zone of the date
+func getCypherDateTime(t time.Time) string {
+	timezone := t.Location().String()
+	if timezone != "UTC" && !strings.Contains(timezone, "/") {
+		_, offset := t.Zone()
+		sign := "+"
+		if offset < 0 {
+			sign = "-"
+			offset = -offset
+		}
+		timezone = fmt.Sprintf("%s%02d:%02d", sign, offset/3600, offset%3600/60)
+	}
+	return "datetime({epochSeconds: " + strconv.FormatInt(t.Unix(), 10) + ", nanosecond: " + strconv.Itoa(t.Nanosecond()) + ", timezone: " + getCypherString(timezone) + "})"
+}
+
+func getCypherFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "0.0/0.0"
+	case math.IsInf(f, 1):
+		return "1.0/0.0"
+	case math.IsInf(f, -1):
+		return "-1.0/0.0"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+// getCypherValue write a parameter of the neo4j extractor as a Cypher literal
+func getCypherValue(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	switch v := value.(type) {
+	case string:
+		return getCypherString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return getCypherDateTime(v)
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return getCypherFloat(v.Float())
+	case reflect.Slice, reflect.Array:
+		values := make([]string, v.Len())
+		for i := range values {
+			values[i] = getCypherValue(v.Index(i).Interface())
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case reflect.Map:
+		var keys []string
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = getCypherKey(key) + ": " + getCypherValue(v.MapIndex(reflect.ValueOf(key)).Interface())
+		}
+		return "{" + strings.Join(values, ", ") + "}"
+	}
+	log.Fatalf("Unsupported Cypher value %T", value)
+	return ""
+}
+
+// writeCypherRelationships write a batch of relationships of the neo4j extractor with its parameter replaced by the literal list
+func writeCypherRelationships(w *bufio.Writer, batch relationshipBatch) {
+	w.WriteString(strings.Replace(batch.Query, "$relationships", getCypherValue(batch.Relationships), 1) + ";\n")
+}
+
+// writeCypherNodes write the nodes by batch of nodes created by the same query, the parameters of a node become a row
+func writeCypherNodes(w *bufio.Writer, nodes []Node) {
+	query := ""
+	var rows []interface{}
+	flush := func() {
+		if len(rows) > 0 {
+			w.WriteString("UNWIND " + getCypherValue(rows) + " AS row " + query + ";\n")
+		}
+		rows = nil
+	}
+
+	for _, n := range nodes {
+		recorder := &cypherRecorder{}
+		_, err := persistNode(recorder, n)
+		handleError(err)
+		if recorder.query == "" {
+			continue
+		}
+
+		nodeQuery := cypherWhitespaceRegexp.ReplaceAllString(cypherParameterRegexp.ReplaceAllString(recorder.query, "row.$1"), " ")
+		if nodeQuery != query || len(rows) == cypherBatchSize {
+			flush()
+			query = nodeQuery
+		}
+		rows = append(rows, recorder.parameters)
+	}
+	flush()
+}
+
+func WriteCypher(graph *Graph, path string) {
+	file, err := os.Create(path)
+	handleError(err)
+	w := bufio.NewWriter(file)
+
+	w.WriteString("// plaso2graph case, replay with: cypher-shell -u neo4j -p <password> -f " + filepath.Base(path) + "\n\n")
+
+	w.WriteString("// Schema\n")
+	for _, label := range Labels {
+		w.WriteString("CREATE CONSTRAINT IF NOT EXISTS FOR (n:" + label + ") REQUIRE n.id IS UNIQUE;\n")
+	}
+
+	w.WriteString("\n// Nodes (" + strconv.Itoa(len(graph.Nodes)) + ")\n")
+	writeCypherNodes(w, graph.Nodes)
+
+	w.WriteString("\n// Relationships (" + strconv.Itoa(len(graph.Relationships)) + ")\n")
+	for _, batch := range getRelationshipBatches(graph.Relationships) {
+		writeCypherRelationships(w, batch)
+	}
+
+	w.WriteString("\n" + updateIdsQuery + ";\n")
+
+	handleError(w.Flush())
+	handleError(file.Close())
+}
@@ -1,12 +1,18 @@
 package Extractor
 
 import (
+	"fmt"
 	"log"
+	"os"
+	. "plaso2graph/master/src/Linker"
 )
 
 // Contains generic functions for extractors and the Extract function which calls the correct extractor
 // depending on the extractor specified in the args map
 
+// Extractors is the list of the available extractors, the first one is the default
+var Extractors = []string{"neo4j", "neo4j-import", "cypher", "csv", "sqlite", "graphml", "gexf", "maltego", "stix", "timesketch", "json", "xml"}
+
 func Extract(data []interface{}, args map[string]interface{}) {
 	if args["extractor"] == nil {
 		log.Fatal("No extractor specified")
@@ -26,6 +32,30 @@ func Extract(data []interface{}, args map[string]interface{}) {
 	case "csv":
 		CsvExtract(data, args)
 		break
+	case "neo4j-import":
+		Neo4jImportExtract(data, args)
+		break
+	case "cypher":
+		CypherExtract(data, args)
+		break
+	case "graphml":
+		GraphmlExtract(data, args)
+		break
+	case "gexf":
+		GexfExtract(data, args)
+		break
+	case "sqlite":
+		SqliteExtract(data, args)
+		break
+	case "maltego":
+		MaltegoExtract(data, args)
+		break
+	case "stix":
+		StixExtract(data, args)
+		break
+	case "timesketch":
+		TimesketchExtract(data, args)
+		break
 	}
 }
 
@@ -45,6 +75,22 @@ func InitializeExtractor(args map[string]interface{}) map[string]interface{} {
 		return InitializeXmlExtractor(args)
 	case "csv":
 		return InitializeCsvExtractor(args)
+	case "neo4j-import":
+		return InitializeNeo4jImportExtractor(args)
+	case "cypher":
+		return InitializeCypherExtractor(args)
+	case "graphml":
+		return InitializeGraphmlExtractor(args)
+	case "gexf":
+		return InitializeGexfExtractor(args)
+	case "sqlite":
+		return InitializeSqliteExtractor(args)
+	case "maltego":
+		return InitializeMaltegoExtractor(args)
+	case "stix":
+		return InitializeStixExtractor(args)
+	case "timesketch":
+		return InitializeTimesketchExtractor(args)
 	}
 	return args
 }
@@ -60,7 +106,104 @@ func PostProcessing(args map[string]interface{}) {
 	case "neo4j":
 		Neo4jPostProcessing(args)
 		break
+	case "json":
+		JsonPostProcessing(args)
+		break
+	case "xml":
+		XmlPostProcessing(args)
+		break
+	case "csv":
+		CsvPostProcessing(args)
+		break
+	case "neo4j-import":
+		Neo4jImportPostProcessing(args)
+		break
+	case "cypher":
+		CypherPostProcessing(args)
+		break
+	case "graphml":
+		GraphmlPostProcessing(args)
+		break
+	case "gexf":
+		GexfPostProcessing(args)
+		break
+	case "sqlite":
+		SqlitePostProcessing(args)
+		break
+	case "maltego":
+		MaltegoPostProcessing(args)
+		break
+	case "stix":
+		StixPostProcessing(args)
+		break
+	case "timesketch":
+		TimesketchPostProcessing(args)
+		break
+	}
+}
+
+// initializeGraphExtractor create the output directory and the Graph which keeps the entities of every batch,
+// the file extractors write the case once the entities are linked in post-processing
+func initializeGraphExtractor(args map[string]interface{}) map[string]interface{} {
+	if args["output"] == nil {
+		log.Fatal("Output directory is required")
+	}
+
+	if args["verbose"] == nil {
+		args["verbose"] = false
+	}
+
+	err := os.MkdirAll(args["output"].(string), 0755)
+	handleError(err)
+
+	args["graph"] = NewGraph()
+	return args
+}
+
+// initializeStreamingGraphExtractor initialize an extractor writing the nodes of every batch, its Graph is compact
+func initializeStreamingGraphExtractor(args map[string]interface{}) map[string]interface{} {
+	args = initializeGraphExtractor(args)
+	args["graph"].(*Graph).Compact = true
+	return args
+}
+
+// addGraphEntities add the entities of a batch to the Graph and return their nodes
+func addGraphEntities(data []interface{}, args map[string]interface{}) []Node {
+	return args["graph"].(*Graph).AddEntities(data)
+}
+
+// streamGraphEntities add the entities of a batch to a compact Graph and return the nodes to write now,
+// the nodes updated while linking are returned by linkStreamedGraph
+func streamGraphEntities(data []interface{}, args map[string]interface{}) []Node {
+	var nodes []Node
+	for _, n := range addGraphEntities(data, args) {
+		if !DeferredLabels[n.Label] {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// linkGraph compute the relationships of the Graph, it returns the Graph and the number of nodes created while linking
+func linkGraph(args map[string]interface{}) (*Graph, int) {
+	graph := args["graph"].(*Graph)
+
+	fmt.Println("Linking entities...")
+	created := graph.Link()
+	return graph, created
+}
+
+// linkStreamedGraph link a compact Graph and return the nodes left to write: the ones updated or created while linking
+func linkStreamedGraph(args map[string]interface{}) (*Graph, []Node) {
+	graph, created := linkGraph(args)
+
+	var nodes []Node
+	for _, n := range graph.Nodes[:len(graph.Nodes)-created] {
+		if DeferredLabels[n.Label] {
+			nodes = append(nodes, n)
+		}
 	}
+	return graph, append(nodes, graph.Nodes[len(graph.Nodes)-created:]...)
 }
 
 func handleError(err error) {
@@ -0,0 +1,128 @@
+package Extractor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	. "plaso2graph/master/src/Linker"
+	"strconv"
+	"time"
+)
+
+// The GEXF extractor writes the case in a single case.gexf file for Gephi. The graph is dynamic:
+// nodes and relationships appear at the date of their entity, so the incident timeline can be animated
+
+func InitializeGexfExtractor(args map[string]interface{}) map[string]interface{} {
+	return initializeGraphExtractor(args)
+}
+
+func GexfExtract(data []interface{}, args map[string]interface{}) {
+	addGraphEntities(data, args)
+}
+
+func GexfPostProcessing(args map[string]interface{}) {
+	graph, _ := linkGraph(args)
+
+	fmt.Println("Writing case.gexf...")
+	WriteGexf(graph, filepath.Join(args["output"].(string), "case.gexf"))
+}
+
+// getNodeStart return the first date of an entity (creation, first connection, visit...), nodes without date are always visible
+func getNodeStart(properties []property) string {
+	for _, p := range properties {
+		if t, ok := p.Value.(time.Time); ok && !t.IsZero() {
+			return formatValue(t)
+		}
+	}
+	return ""
+}
+
+// getRelationshipStart return the date of a relationship, relationships without date are always visible
+func getRelationshipStart(r Relationship) string {
+	for _, key := range []string{"date", "first_connected", "start"} {
+		if t, ok := r.Properties[key].(time.Time); ok && !t.IsZero() {
+			return formatValue(t)
+		}
+	}
+	return ""
+}
+
+// getNodeTitle return the name shown by Gephi
+func getNodeTitle(n Node, properties []property) string {
+	for _, name := range []string{"name", "fullname", "filename", "ip", "query", "title", "url", "commandline"} {
+		for _, p := range properties {
+			if p.Name == name && formatValue(p.Value) != "" {
+				return formatValue(p.Value)
+			}
+		}
+	}
+	return n.ID
+}
+
+func writeGexfAttributes(w *bufio.Writer, class string, attributes []graphAttribute) {
+	w.WriteString("    <attributes class=\"" + class + "\" mode=\"static\">\n")
+	w.WriteString("      <attribute id=\"" + class + "_type\" title=\"type\" type=\"string\"/>\n")
+	for i, a := range attributes {
+		w.WriteString("      <attribute id=\"" + strconv.Itoa(i) + "\" title=\"" + a.Name + "\" type=\"" + a.Type + "\"/>\n")
+	}
+	w.WriteString("    </attributes>\n")
+}
+
+func WriteGexf(graph *Graph, path string) {
+	file, err := os.Create(path)
+	handleError(err)
+	w := bufio.NewWriter(file)
+
+	nodeAttributes, nodeIndex := getNodeAttributes(graph.Nodes)
+	edgeAttributes, edgeIndex := getRelationshipAttributes(graph.Relationships)
+
+	w.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	w.WriteString("<gexf xmlns=\"http://gexf.net/1.3\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\" ")
+	w.WriteString("xsi:schemaLocation=\"http://gexf.net/1.3 http://gexf.net/1.3/gexf.xsd\" version=\"1.3\">\n")
+	w.WriteString("  <meta><creator>plaso2graph</creator></meta>\n")
+	w.WriteString("  <graph mode=\"dynamic\" defaultedgetype=\"directed\" timeformat=\"datetime\">\n")
+	writeGexfAttributes(w, "node", nodeAttributes)
+	writeGexfAttributes(w, "edge", edgeAttributes)
+
+	w.WriteString("    <nodes>\n")
+	for _, n := range graph.Nodes {
+		properties := getProperties(n.Entity)
+		w.WriteString("      <node id=\"" + escapeXml(n.ID) + "\" label=\"" + escapeXml(getNodeTitle(n, properties)) + "\"")
+		if start := getNodeStart(properties); start != "" {
+			w.WriteString(" start=\"" + start + "\"")
+		}
+		w.WriteString("><attvalues><attvalue for=\"node_type\" value=\"" + n.Label + "\"/>")
+		for _, p := range properties {
+			if value := formatValue(p.Value); value != "" {
+				w.WriteString("<attvalue for=\"" + strconv.Itoa(nodeIndex[p.Name]) + "\" value=\"" + escapeXml(value) + "\"/>")
+			}
+		}
+		w.WriteString("</attvalues></node>\n")
+	}
+	w.WriteString("    </nodes>\n")
+
+	w.WriteString("    <edges>\n")
+	for i, r := range graph.Relationships {
+		w.WriteString("      <edge id=\"" + strconv.Itoa(i) + "\" source=\"" + escapeXml(r.StartID) + "\" target=\"" + escapeXml(r.EndID) + "\" label=\"" + r.Type + "\"")
+		if start := getRelationshipStart(r); start != "" {
+			w.WriteString(" start=\"" + start + "\"")
+		}
+		w.WriteString("><attvalues><attvalue for=\"edge_type\" value=\"" + r.Type + "\"/>")
+		for _, a := range edgeAttributes {
+			value, ok := r.Properties[a.Name]
+			if !ok {
+				continue
+			}
+			if value := formatValue(value); value != "" {
+				w.WriteString("<attvalue for=\"" + strconv.Itoa(edgeIndex[a.Name]) + "\" value=\"" + escapeXml(value) + "\"/>")
+			}
+		}
+		w.WriteString("</attvalues></edge>\n")
+	}
+	w.WriteString("    </edges>\n")
+	w.WriteString("  </graph>\n</gexf>\n")
+
+	handleError(w.Flush())
+	handleError(file.Close())
+}
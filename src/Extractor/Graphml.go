@@ -0,0 +1,151 @@
+package Extractor
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	. "plaso2graph/master/src/Linker"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The GraphML extractor writes the case in a single case.graphml file (yEd, Cytoscape, Gephi),
+// the keys are the properties of the nodes and the relationships with their type
+
+// graphAttribute is a property declared in the header of the GraphML and GEXF files
+type graphAttribute struct {
+	Name string
+	Type string
+}
+
+func InitializeGraphmlExtractor(args map[string]interface{}) map[string]interface{} {
+	return initializeGraphExtractor(args)
+}
+
+func GraphmlExtract(data []interface{}, args map[string]interface{}) {
+	addGraphEntities(data, args)
+}
+
+func GraphmlPostProcessing(args map[string]interface{}) {
+	graph, _ := linkGraph(args)
+
+	fmt.Println("Writing case.graphml...")
+	WriteGraphml(graph, filepath.Join(args["output"].(string), "case.graphml"))
+}
+
+// getGraphAttributeType return the type of a property, dates and lists are written as text
+func getGraphAttributeType(value interface{}) string {
+	switch value.(type) {
+	case int, int64:
+		return "long"
+	case float64:
+		return "double"
+	case bool:
+		return "boolean"
+	}
+	return "string"
+}
+
+// addGraphAttribute declare a property in the order it is first seen, a property with several types becomes a string
+func addGraphAttribute(attributes []graphAttribute, index map[string]int, name string, value interface{}) []graphAttribute {
+	attributeType := getGraphAttributeType(value)
+	i, ok := index[name]
+	if !ok {
+		index[name] = len(attributes)
+		return append(attributes, graphAttribute{name, attributeType})
+	}
+	if attributes[i].Type != attributeType {
+		attributes[i].Type = "string"
+	}
+	return attributes
+}
+
+func getNodeAttributes(nodes []Node) ([]graphAttribute, map[string]int) {
+	var attributes []graphAttribute
+	index := map[string]int{}
+	for _, n := range nodes {
+		for _, p := range getProperties(n.Entity) {
+			attributes = addGraphAttribute(attributes, index, p.Name, p.Value)
+		}
+	}
+	return attributes, index
+}
+
+func getRelationshipAttributes(relationships []Relationship) ([]graphAttribute, map[string]int) {
+	var attributes []graphAttribute
+	index := map[string]int{}
+	for _, r := range relationships {
+		var keys []string
+		for key := range r.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			attributes = addGraphAttribute(attributes, index, key, r.Properties[key])
+		}
+	}
+	return attributes, index
+}
+
+// escapeXml escape a value written in an element or an attribute
+func escapeXml(value string) string {
+	var b strings.Builder
+	handleError(xml.EscapeText(&b, []byte(value)))
+	return b.String()
+}
+
+func WriteGraphml(graph *Graph, path string) {
+	file, err := os.Create(path)
+	handleError(err)
+	w := bufio.NewWriter(file)
+
+	nodeAttributes, nodeIndex := getNodeAttributes(graph.Nodes)
+	edgeAttributes, edgeIndex := getRelationshipAttributes(graph.Relationships)
+
+	w.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	w.WriteString("<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\" ")
+	w.WriteString("xsi:schemaLocation=\"http://graphml.graphdrawing.org/xmlns http://graphml.graphdrawing.org/xmlns/1.0/graphml.xsd\">\n")
+
+	// Same keys as the Neo4j APOC export: "labels" for the nodes and "label" for the relationships
+	w.WriteString("  <key id=\"labels\" for=\"node\" attr.name=\"labels\" attr.type=\"string\"/>\n")
+	for i, a := range nodeAttributes {
+		w.WriteString("  <key id=\"n" + strconv.Itoa(i) + "\" for=\"node\" attr.name=\"" + a.Name + "\" attr.type=\"" + a.Type + "\"/>\n")
+	}
+	w.WriteString("  <key id=\"label\" for=\"edge\" attr.name=\"label\" attr.type=\"string\"/>\n")
+	for i, a := range edgeAttributes {
+		w.WriteString("  <key id=\"e" + strconv.Itoa(i) + "\" for=\"edge\" attr.name=\"" + a.Name + "\" attr.type=\"" + a.Type + "\"/>\n")
+	}
+
+	w.WriteString("  <graph id=\"case\" edgedefault=\"directed\">\n")
+	for _, n := range graph.Nodes {
+		w.WriteString("    <node id=\"" + escapeXml(n.ID) + "\"><data key=\"labels\">" + n.Label + "</data>")
+		for _, p := range getProperties(n.Entity) {
+			if value := formatValue(p.Value); value != "" {
+				w.WriteString("<data key=\"n" + strconv.Itoa(nodeIndex[p.Name]) + "\">" + escapeXml(value) + "</data>")
+			}
+		}
+		w.WriteString("</node>\n")
+	}
+
+	for i, r := range graph.Relationships {
+		w.WriteString("    <edge id=\"e" + strconv.Itoa(i) + "\" source=\"" + escapeXml(r.StartID) + "\" target=\"" + escapeXml(r.EndID) + "\">")
+		w.WriteString("<data key=\"label\">" + r.Type + "</data>")
+		for _, a := range edgeAttributes {
+			value, ok := r.Properties[a.Name]
+			if !ok {
+				continue
+			}
+			if value := formatValue(value); value != "" {
+				w.WriteString("<data key=\"e" + strconv.Itoa(edgeIndex[a.Name]) + "\">" + escapeXml(value) + "</data>")
+			}
+		}
+		w.WriteString("</edge>\n")
+	}
+	w.WriteString("  </graph>\n</graphml>\n")
+
+	handleError(w.Flush())
+	handleError(file.Close())
+}
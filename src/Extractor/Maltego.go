@@ -0,0 +1,176 @@
+package Extractor
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	. "plaso2graph/master/src/Linker"
+	"strconv"
+	"strings"
+)
+
+// The Maltego extractor writes the case in a case.mtgx graph (File > Import > Import Graph from MTGX).
+// Users, Hosts, Domains, URLs and Devices use the standard Maltego entities, the other labels are "plaso2graph.<Label>"
+// entities defined in the archive. Relationships are manual links labelled with their type.
+
+// maltegoEntity is the Maltego type of a label, the property holding its value
+// and the plaso2graph property it comes from (the title of the node when empty)
+type maltegoEntity struct {
+	Type     string
+	Property string
+	Source   string
+}
+
+var maltegoEntities = map[string]maltegoEntity{
+	"User":       {"maltego.Person", "person.fullname", ""},
+	"Host":       {"maltego.IPv4Address", "ipv4-address", "ip"},
+	"Domain":     {"maltego.Domain", "fqdn", "name"},
+	"WebHistory": {"maltego.URL", "url", "url"},
+	"Device":     {"maltego.Device", "device", ""},
+}
+
+// maltegoIPv6Entity is the entity of the Hosts with an IPv6 address
+var maltegoIPv6Entity = maltegoEntity{"maltego.IPv6Address", "ipv6-address", "ip"}
+
+func InitializeMaltegoExtractor(args map[string]interface{}) map[string]interface{} {
+	return initializeGraphExtractor(args)
+}
+
+func MaltegoExtract(data []interface{}, args map[string]interface{}) {
+	addGraphEntities(data, args)
+}
+
+func MaltegoPostProcessing(args map[string]interface{}) {
+	graph, _ := linkGraph(args)
+
+	fmt.Println("Writing case.mtgx...")
+	WriteMaltego(graph, filepath.Join(args["output"].(string), "case.mtgx"))
+}
+
+// getMaltegoEntity return the Maltego entity of a label
+func getMaltegoEntity(label string) maltegoEntity {
+	if entity, ok := maltegoEntities[label]; ok {
+		return entity
+	}
+	return maltegoEntity{"plaso2graph." + label, "properties." + strings.ToLower(label), ""}
+}
+
+// getMaltegoValue return the main value of a node, the one Maltego shows under the icon
+func getMaltegoValue(n Node, entity maltegoEntity, properties []property) string {
+	for _, p := range properties {
+		if entity.Source != "" && p.Name == entity.Source {
+			return formatValue(p.Value)
+		}
+		if n.Label == "User" && (p.Name == "fullname" || p.Name == "username") && formatValue(p.Value) != "" && formatValue(p.Value) != "-" {
+			return formatValue(p.Value)
+		}
+	}
+	return getNodeTitle(n, properties)
+}
+
+func writeMaltegoProperty(w *bufio.Writer, name string, displayName string, value string) {
+	w.WriteString("<mtg:Property displayName=\"" + escapeXml(displayName) + "\" hidden=\"false\" name=\"" + escapeXml(name) + "\" nullable=\"true\" readonly=\"false\" type=\"string\">")
+	w.WriteString("<mtg:Value>" + escapeXml(value) + "</mtg:Value></mtg:Property>")
+}
+
+// writeMaltegoGraph write the GraphML of the graph with the Maltego entities and links
+func writeMaltegoGraph(w *bufio.Writer, graph *Graph) {
+	w.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	w.WriteString("<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\" xmlns:mtg=\"http://maltego.paterva.com/xml/mtgx\">\n")
+	w.WriteString("  <key attr.name=\"MaltegoEntity\" for=\"node\" id=\"d0\"/>\n")
+	w.WriteString("  <key attr.name=\"MaltegoLink\" for=\"edge\" id=\"d1\"/>\n")
+	w.WriteString("  <graph edgedefault=\"directed\" id=\"G\">\n")
+
+	for _, n := range graph.Nodes {
+		properties := getProperties(n.Entity)
+		entity := getMaltegoEntity(n.Label)
+		value := getMaltegoValue(n, entity, properties)
+		if n.Label == "Host" && strings.Contains(value, ":") {
+			entity = maltegoIPv6Entity
+		}
+
+		w.WriteString("    <node id=\"" + escapeXml(n.ID) + "\"><data key=\"d0\"><mtg:MaltegoEntity type=\"" + entity.Type + "\"><mtg:Properties>")
+		writeMaltegoProperty(w, entity.Property, n.Label, value)
+		writeMaltegoProperty(w, "plaso2graph.id", "id", n.ID)
+		for _, p := range properties {
+			if p.Name == "evidence" || p.Name == entity.Property {
+				continue
+			}
+			if value := formatValue(p.Value); value != "" {
+				writeMaltegoProperty(w, p.Name, p.Name, value)
+			}
+		}
+		w.WriteString("</mtg:Properties></mtg:MaltegoEntity></data></node>\n")
+	}
+
+	keys := getRelationshipKeys(graph.Relationships)
+	for i, r := range graph.Relationships {
+		w.WriteString("    <edge id=\"e" + strconv.Itoa(i) + "\" source=\"" + escapeXml(r.StartID) + "\" target=\"" + escapeXml(r.EndID) + "\">")
+		w.WriteString("<data key=\"d1\"><mtg:MaltegoLink type=\"maltego.link.manual-link\"><mtg:Properties>")
+		writeMaltegoProperty(w, "maltego.link.manual.type", "Label", r.Type)
+		for _, key := range keys[r.Type] {
+			value, ok := r.Properties[key]
+			if !ok {
+				continue
+			}
+			if value := formatValue(value); value != "" {
+				writeMaltegoProperty(w, key, key, value)
+			}
+		}
+		w.WriteString("</mtg:Properties></mtg:MaltegoLink></data></edge>\n")
+	}
+
+	w.WriteString("  </graph>\n</graphml>\n")
+}
+
+// writeMaltegoEntityDefinition define a plaso2graph entity, so Maltego knows its main property
+func writeMaltegoEntityDefinition(w *bufio.Writer, label string) {
+	entity := getMaltegoEntity(label)
+	w.WriteString("<MaltegoEntity id=\"" + entity.Type + "\" displayName=\"" + label + "\" displayNamePlural=\"" + label + "\" ")
+	w.WriteString("description=\"" + label + " extracted by plaso2graph\" category=\"plaso2graph\" smallIconResource=\"Unknown\" largeIconResource=\"Unknown\" ")
+	w.WriteString("allowedRoot=\"true\" conversionOrder=\"2147483647\" visible=\"true\">\n")
+	w.WriteString("  <Properties value=\"" + entity.Property + "\" displayValue=\"" + entity.Property + "\">\n")
+	w.WriteString("    <Groups/>\n")
+	w.WriteString("    <Fields>\n")
+	w.WriteString("      <Field name=\"" + entity.Property + "\" type=\"string\" nullable=\"true\" hidden=\"false\" readonly=\"false\" description=\"\" displayName=\"" + label + "\"/>\n")
+	w.WriteString("    </Fields>\n")
+	w.WriteString("  </Properties>\n")
+	w.WriteString("</MaltegoEntity>\n")
+}
+
+func addMaltegoFile(archive *zip.Writer, name string, write func(w *bufio.Writer)) {
+	f, err := archive.Create(name)
+	handleError(err)
+	w := bufio.NewWriter(f)
+	write(w)
+	handleError(w.Flush())
+}
+
+func WriteMaltego(graph *Graph, path string) {
+	file, err := os.Create(path)
+	handleError(err)
+	archive := zip.NewWriter(file)
+
+	addMaltegoFile(archive, "Version.properties", func(w *bufio.Writer) {
+		w.WriteString("#Maltego Graph (MTGX) written by plaso2graph\n")
+		w.WriteString("maltego.graph.version=1.2\n")
+	})
+
+	addMaltegoFile(archive, "Graphs/Graph1.graphml", func(w *bufio.Writer) {
+		writeMaltegoGraph(w, graph)
+	})
+
+	for _, label := range Labels {
+		if _, ok := maltegoEntities[label]; ok {
+			continue
+		}
+		addMaltegoFile(archive, "Entities/"+getMaltegoEntity(label).Type+".entity", func(w *bufio.Writer) {
+			writeMaltegoEntityDefinition(w, label)
+		})
+	}
+
+	handleError(archive.Close())
+	handleError(file.Close())
+}
@@ -6,8 +6,7 @@ import (
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 	"log"
 	. "plaso2graph/master/src/Entity"
-	"sync"
-	"time"
+	. "plaso2graph/master/src/Linker"
 )
 
 func handleErr(err error) {
@@ -53,7 +52,23 @@ func InitializeNeo4jExtractor(args map[string]interface{}) map[string]interface{
 		log.Fatal("Url is required")
 	}
 
-	args["connector"] = Neo4jConnect(args["username"].(string), args["password"].(string), args["url"].(string))
+	con := Neo4jConnect(args["username"].(string), args["password"].(string), args["url"].(string))
+	args["connector"] = con
+	graph := NewGraph()
+	graph.Compact = true
+	args["graph"] = graph
+
+	// The relationships are created by matching the id of their nodes, unique across the cases of the database
+	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	for _, label := range Labels {
+		_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+			query := "CREATE CONSTRAINT IF NOT EXISTS FOR (n:" + label + ") REQUIRE n.id IS UNIQUE"
+			parameters := map[string]interface{}{}
+			_, err := tx.Run(query, parameters)
+			return nil, err
+		})
+		handleErr(err)
+	}
 
 	return args
 }
@@ -66,147 +81,171 @@ func Neo4jExtract(data []interface{}, args map[string]interface{}) {
 
 	con := args["connector"].(Neo4JConnector)
 
-	var wg sync.WaitGroup
-	for _, d := range data {
-		switch d.(type) {
-		case []Process:
-			InsertProcessesNeo4j(con, d.([]Process))
-			break
-
-		case []ScriptBlock:
-			InsertScriptBlocksNeo4j(con, d.([]ScriptBlock))
-			break
-
-		case []User:
-			InsertUsersNeo4j(con, d.([]User))
-			break
-
-		case []Group:
-			InsertGroupsNeo4j(con, d.([]Group))
-			break
-
-		case []File:
-			InsertFilesNeo4j(con, d.([]File))
-			break
-
-		case []ScheduledTask:
-			InsertTasksNeo4j(con, d.([]ScheduledTask))
-			break
-
-		case []Service:
-			InsertServicesNeo4j(con, d.([]Service))
-			break
-
-		case []Computer:
-			InsertComputersNeo4j(con, d.([]Computer))
-			break
-
-		case []Domain:
-			InsertDomainsNeo4j(con, d.([]Domain))
-			break
-
-		case []WebHistory:
-			InsertWebHistoriesNeo4j(con, d.([]WebHistory))
-			break
-
-		case []Connection:
-			InsertConnectionsNeo4j(con, d.([]Connection))
-			break
-
-		case []Event:
-			InsertEventsNeo4j(con, d.([]Event))
-			break
-		case []Registry:
-			InsertRegistriesNeo4j(con, d.([]Registry))
-			break
-		}
-
+	// The graph gives the id of the nodes, it keeps them without evidence to compute the relationships in post-processing
+	for _, n := range streamGraphEntities(data, args) {
+		InsertNodeNeo4j(con, n)
 	}
-	wg.Wait()
 	/*if args["verbose"].(bool) {
 		log.Println("Neo4j Extractor finished")
 	}*/
 }
 
 func Neo4jPostProcessing(args map[string]interface{}) {
-
-	wg := sync.WaitGroup{}
-
 	con := args["connector"].(Neo4JConnector)
-	fmt.Println("Linking processes...")
-
-	go func() {
-		wg.Add(1)
-		linkProcess(con)
-		wg.Done()
-	}()
-
-	fmt.Println("Linking user to process...")
-
-	go func() {
-		wg.Add(1)
-		wg.Done()
-		linkUsers(con)
-	}()
+	graph, linked := linkStreamedGraph(args)
 
-	fmt.Println("Linking ScriptBlocks...")
-	go func() {
-		wg.Add(1)
-		linkScriptBlock(con)
-		wg.Done()
-	}()
-
-	fmt.Println("Linking computers...")
-	go func() {
-		wg.Add(1)
-		linkComputers(con)
-		wg.Done()
-
-	}()
-
-	fmt.Println("Linking Connections...")
-
-	go func() {
-		wg.Add(1)
-		handleConnections(con)
-		wg.Done()
-	}()
-
-	fmt.Println("Processing Events...")
-	go func() {
-		wg.Add(1)
-		handleEvents(con)
-		wg.Done()
-	}()
+	// Computers and AntiForensics with their log coverage gaps, Hosts, Domains, Files and Folders created while linking
+	fmt.Println("Inserting " + fmt.Sprint(len(linked)) + " linked nodes...")
+	for _, n := range linked {
+		InsertNodeNeo4j(con, n)
+	}
 
-	time.Sleep(2 * time.Second)
-	wg.Wait()
+	fmt.Println("Inserting " + fmt.Sprint(len(graph.Relationships)) + " relationships...")
+	InsertRelationshipsNeo4j(con, graph.Relationships)
 
 	updateIds(con)
 }
 
-func InsertProcessesNeo4j(con Neo4JConnector, ps []Process) {
-	for _, p := range ps {
-		InsertProcessNeo4j(con, p)
+func InsertNodeNeo4j(con Neo4JConnector, n Node) {
+	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		return persistNode(tx, n)
+	})
+	handleErr(err)
+}
+
+func persistNode(tx neo4j.Transaction, n Node) (interface{}, error) {
+	switch e := n.Entity.(type) {
+	case Process:
+		return persistProcess(tx, e, n.ID)
+	case ScriptBlock:
+		return persistScriptBlock(tx, e, n.ID)
+	case User:
+		return persistUser(tx, e, n.ID)
+	case Group:
+		return persistGroup(tx, e, n.ID)
+	case File:
+		return persistFile(tx, e, n.ID)
+	case Folder:
+		return persistFolder(tx, e, n.ID)
+	case ScheduledTask:
+		return persistTask(tx, e, n.ID)
+	case Service:
+		return persistService(tx, e, n.ID)
+	case Computer:
+		return persistComputer(tx, e, n.ID)
+	case Domain:
+		return persistDomain(tx, e, n.ID)
+	case Host:
+		return persistHost(tx, e, n.ID)
+	case WebHistory:
+		return persistWebHistory(tx, e, n.ID)
+	case Connection:
+		return persistConnection(tx, e, n.ID)
+	case Event:
+		return persistEvent(tx, e, n.ID)
+	case Registry:
+		return persistRegistry(tx, e, n.ID)
+	case SecurityControlChange:
+		return persistSecurityControlChange(tx, e, n.ID)
+	case Detection:
+		return persistDetection(tx, e, n.ID)
+	case AntiForensics:
+		return persistAntiForensics(tx, e, n.ID)
+	case Device:
+		return persistDevice(tx, e, n.ID)
+	case FileAccess:
+		return persistFileAccess(tx, e, n.ID)
+	case Network:
+		return persistNetwork(tx, e, n.ID)
+	case Command:
+		return persistCommand(tx, e, n.ID)
+	case Cookie:
+		return persistCookie(tx, e, n.ID)
+	case Alert:
+		return persistAlert(tx, e, n.ID)
+	case DnsQuery:
+		return persistDnsQuery(tx, e, n.ID)
+	}
+	return nil, nil
+}
+
+// relationshipBatch is a query creating up to 1000 relationships of the same type and labels, with its parameter
+type relationshipBatch struct {
+	Query         string
+	Relationships []interface{}
+}
+
+// getRelationshipBatches group the relationships computed by the Linker by type and labels,
+// so the nodes are matched on the index of their label
+func getRelationshipBatches(relationships []Relationship) []relationshipBatch {
+	const batchSize = 1000
+
+	type batchKey struct {
+		relType    string
+		startLabel string
+		endLabel   string
+	}
+	var keys []batchKey
+	batches := map[batchKey][]interface{}{}
+
+	for _, r := range relationships {
+		key := batchKey{r.Type, GetLabelFromID(r.StartID), GetLabelFromID(r.EndID)}
+		if _, ok := batches[key]; !ok {
+			keys = append(keys, key)
+		}
+		batches[key] = append(batches[key], map[string]interface{}{
+			"start_id":   r.StartID,
+			"end_id":     r.EndID,
+			"properties": r.Properties,
+		})
+	}
+
+	var result []relationshipBatch
+	for _, key := range keys {
+		query := "UNWIND $relationships AS r MATCH (a:" + key.startLabel + " {id: r.start_id}) MATCH (b:" + key.endLabel + " {id: r.end_id}) "
+		query += "CREATE (a)-[x:" + key.relType + "]->(b) SET x = r.properties"
+
+		batch := batches[key]
+		for start := 0; start < len(batch); start += batchSize {
+			end := start + batchSize
+			if end > len(batch) {
+				end = len(batch)
+			}
+			result = append(result, relationshipBatch{query, batch[start:end]})
+		}
 	}
+	return result
 }
 
-func InsertProcessNeo4j(con Neo4JConnector, p Process) {
+// InsertRelationshipsNeo4j create the relationships computed by the Linker, by batch of relationships
+// with the same type and labels so the nodes are matched on the index of their label
+func InsertRelationshipsNeo4j(con Neo4JConnector, relationships []Relationship) {
 	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistProcess(tx, p)
-	})
-	handleErr(err)
+	for _, batch := range getRelationshipBatches(relationships) {
+		_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+			parameters := map[string]interface{}{
+				"relationships": batch.Relationships,
+			}
+			_, err := tx.Run(batch.Query, parameters)
+			return nil, err
+		})
+		handleErr(err)
+	}
 }
 
-func persistProcess(tx neo4j.Transaction, p Process) (interface{}, error) {
-	query := "CREATE (:Process {created_time: $created_time, timestamp: $timestamp, filename: $filename, fullpath: $fullpath,pid: $pid,commandline: $commandline, "
+func persistProcess(tx neo4j.Transaction, p Process, id string) (interface{}, error) {
+	query := "CREATE (:Process {id: $id, created_time: $created_time, timestamp: $timestamp, filename: $filename, fullpath: $fullpath,pid: $pid,commandline: $commandline, "
 	query += "ppid: $ppid, pprocess_name: $pprocess_name, pprocess_commandline: $pprocess_commandline, "
-	query += "user: $user, user_domain: $user_domain, computer: $computer, logonid: $logonid, evidence: $evidence})"
+	query += "user: $user, user_domain: $user_domain, computer: $computer, logonid: $logonid, execution_artefacts: $execution_artefacts, "
+	query += "execution_confidence: $execution_confidence, bytes_sent: $bytes_sent, bytes_received: $bytes_received, interface_luid: $interface_luid, "
+	query += "network_usage_start: $network_usage_start, network_usage_end: $network_usage_end, decoded_commandline: $decoded_commandline, "
+	query += "obfuscation_score: $obfuscation_score, record_id: $record_id, channel: $channel, evidence: $evidence})"
 	//fmt.Println("Created time:" + fmt.Sprint(p.CreatedTime))
 	//fmt.Println(fmt.Sprint(p.Evidence))
 
 	parameters := map[string]interface{}{
+		"id":                   id,
 		"created_time":         p.CreatedTime,
 		"timestamp":            p.Timestamp,
 		"fullpath":             p.FullPath,
@@ -220,63 +259,64 @@ func persistProcess(tx neo4j.Transaction, p Process) (interface{}, error) {
 		"user_domain":          p.UserDomain,
 		"logonid":              p.LogonID,
 		"computer":             p.Computer,
+		"execution_artefacts":  p.ExecutionArtefacts,
+		"execution_confidence": p.ExecutionConfidence,
+		"bytes_sent":           p.BytesSent,
+		"bytes_received":       p.BytesReceived,
+		"interface_luid":       p.InterfaceLUID,
+		"network_usage_start":  p.NetworkUsageStart,
+		"network_usage_end":    p.NetworkUsageEnd,
+		"decoded_commandline":  p.DecodedCommandline,
+		"obfuscation_score":    p.ObfuscationScore,
+		"record_id":            p.RecordID,
+		"channel":              p.Channel,
 		"evidence":             p.Evidence,
 	}
 	_, err := tx.Run(query, parameters)
 	return nil, err
 }
 
-func InsertScriptBlocksNeo4j(con Neo4JConnector, sb []ScriptBlock) {
-	for _, s := range sb {
-		InsertScriptBlockNeo4j(con, s)
-	}
-}
-
-func InsertScriptBlockNeo4j(con Neo4JConnector, s ScriptBlock) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistScriptBlock(tx, s)
-	})
-	handleErr(err)
-}
-
-func persistScriptBlock(tx neo4j.Transaction, s ScriptBlock) (interface{}, error) {
-	query := `CREATE (:ScriptBlock {date: $date, timestamp: $timestamp, scriptblockid: $scriptblockid, scriptblocktext: $scriptblocktext, context: $context, 
-		process_id: $processid, message_number: $message_number, message_total: $message_total, path: $path, computer: $computer, evidence: $evidence})`
+func persistScriptBlock(tx neo4j.Transaction, s ScriptBlock, id string) (interface{}, error) {
+	query := `CREATE (:ScriptBlock {id: $id, date: $date, timestamp: $timestamp, scriptblockid: $scriptblockid, scriptblocktext: $scriptblocktext, context: $context, 
+		process_id: $processid, message_number: $message_number, message_total: $message_total, path: $path, computer: $computer, evidence: $evidence,
+		decoded_text: $decoded_text, obfuscation_score: $obfuscation_score, type: $type, host_application: $host_application, command_name: $command_name,
+		command_type: $command_type, script_name: $script_name, user: $user, user_domain: $user_domain, session_id: $session_id, runspace_id: $runspace_id,
+		pipeline_id: $pipeline_id, engine_state: $engine_state})`
 	parameters := map[string]interface{}{
-		"date":            s.Date,
-		"timestamp":       s.Timestamp,
-		"scriptblockid":   s.ScriptBlockID,
-		"scriptblocktext": s.Text,
-		"processid":       s.ProcessID,
-		"message_number":  s.MessageNumber,
-		"message_total":   s.MessageTotal,
-		"path":            s.Path,
-		"computer":        s.Computer,
-		"context":         s.Context,
-		"evidence":        s.Evidence,
+		"id":                id,
+		"date":              s.Date,
+		"timestamp":         s.Timestamp,
+		"scriptblockid":     s.ScriptBlockID,
+		"scriptblocktext":   s.Text,
+		"processid":         s.ProcessID,
+		"message_number":    s.MessageNumber,
+		"message_total":     s.MessageTotal,
+		"path":              s.Path,
+		"computer":          s.Computer,
+		"context":           s.Context,
+		"evidence":          s.Evidence,
+		"decoded_text":      s.DecodedText,
+		"obfuscation_score": s.ObfuscationScore,
+		"type":              s.Type,
+		"host_application":  s.HostApplication,
+		"command_name":      s.CommandName,
+		"command_type":      s.CommandType,
+		"script_name":       s.ScriptName,
+		"user":              s.User,
+		"user_domain":       s.UserDomain,
+		"session_id":        s.SessionID,
+		"runspace_id":       s.RunspaceID,
+		"pipeline_id":       s.PipelineID,
+		"engine_state":      s.EngineState,
 	}
 	_, err := tx.Run(query, parameters)
 	return nil, err
 }
 
-func InsertUsersNeo4j(con Neo4JConnector, users []User) {
-	for _, u := range users {
-		InsertUserNeo4j(con, u)
-	}
-}
-
-func InsertUserNeo4j(con Neo4JConnector, u User) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistUser(tx, u)
-	})
-	handleErr(err)
-}
-
-func persistUser(tx neo4j.Transaction, u User) (interface{}, error) {
-	query := "CREATE (:User {fullname: $fullname, username: $username, comments: $comments, sid: $sid, domain: $domain})"
+func persistUser(tx neo4j.Transaction, u User, id string) (interface{}, error) {
+	query := "CREATE (:User {id: $id, fullname: $fullname, username: $username, comments: $comments, sid: $sid, domain: $domain})"
 	parameters := map[string]interface{}{
+		"id":       id,
 		"fullname": u.FullName,
 		"username": u.Username,
 		"comments": u.Comments,
@@ -287,23 +327,10 @@ func persistUser(tx neo4j.Transaction, u User) (interface{}, error) {
 	return nil, err
 }
 
-func InsertGroupsNeo4j(con Neo4JConnector, groups []Group) {
-	for _, g := range groups {
-		InsertGroupNeo4j(con, g)
-	}
-}
-
-func InsertGroupNeo4j(con Neo4JConnector, g Group) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistGroup(tx, g)
-	})
-	handleErr(err)
-}
-
-func persistGroup(tx neo4j.Transaction, g Group) (interface{}, error) {
-	query := "CREATE (:Group {name: $name, domain: $domain, computer:$computer, evidence: $evidence})"
+func persistGroup(tx neo4j.Transaction, g Group, id string) (interface{}, error) {
+	query := "CREATE (:Group {id: $id, name: $name, domain: $domain, computer:$computer, evidence: $evidence})"
 	parameters := map[string]interface{}{
+		"id":       id,
 		"name":     g.Name,
 		"domain":   g.Domain,
 		"computer": g.Computer,
@@ -313,47 +340,22 @@ func persistGroup(tx neo4j.Transaction, g Group) (interface{}, error) {
 	return nil, err
 }
 
-func InsertComputersNeo4j(con Neo4JConnector, computers []Computer) {
-	for _, c := range computers {
-		InsertComputerNeo4j(con, c)
-	}
-}
-
-func InsertComputerNeo4j(con Neo4JConnector, c Computer) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistComputer(tx, c)
-	})
-	handleErr(err)
-}
-
-func persistComputer(tx neo4j.Transaction, c Computer) (interface{}, error) {
-	query := "CREATE (:Computer {name: $name, domain: $domain})"
+func persistComputer(tx neo4j.Transaction, c Computer, id string) (interface{}, error) {
+	query := "CREATE (:Computer {id: $id, name: $name, domain: $domain, log_gaps: $log_gaps})"
 	parameters := map[string]interface{}{
-		"name":   c.Name,
-		"domain": c.Domain,
+		"id":       id,
+		"name":     c.Name,
+		"domain":   c.Domain,
+		"log_gaps": c.LogGaps,
 	}
 	_, err := tx.Run(query, parameters)
 	return nil, err
 }
 
-func InsertTasksNeo4j(con Neo4JConnector, tasks []ScheduledTask) {
-	for _, t := range tasks {
-		InsertTaskNeo4j(con, t)
-	}
-}
-
-func InsertTaskNeo4j(con Neo4JConnector, t ScheduledTask) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistTask(tx, t)
-	})
-	handleErr(err)
-}
-
-func persistTask(tx neo4j.Transaction, t ScheduledTask) (interface{}, error) {
-	query := "CREATE (:ScheduledTask {application: $application, user: $user, comment: $comment, trigger: $trigger, computer: $computer, evidence: $evidence})"
+func persistTask(tx neo4j.Transaction, t ScheduledTask, id string) (interface{}, error) {
+	query := "CREATE (:ScheduledTask {id: $id, application: $application, user: $user, comment: $comment, trigger: $trigger, computer: $computer, evidence: $evidence})"
 	parameters := map[string]interface{}{
+		"id":          id,
 		"application": t.Application,
 		"user":        t.User,
 		"comment":     t.Comment,
@@ -365,23 +367,10 @@ func persistTask(tx neo4j.Transaction, t ScheduledTask) (interface{}, error) {
 	return nil, err
 }
 
-func InsertServicesNeo4j(con Neo4JConnector, services []Service) {
-	for _, service := range services {
-		InsertServiceNeo4j(con, service)
-	}
-}
-
-func InsertServiceNeo4j(con Neo4JConnector, service Service) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistService(tx, service)
-	})
-	handleErr(err)
-}
-
-func persistService(tx neo4j.Transaction, s Service) (interface{}, error) {
-	query := "CREATE (:Service {name: $name, filename: $filename, service_type: $service_type, start_type: $start_type, error_control: $error_control, user: $user, computer: $computer, dll: $dll, evidence: $evidence})"
+func persistService(tx neo4j.Transaction, s Service, id string) (interface{}, error) {
+	query := "CREATE (:Service {id: $id, name: $name, filename: $filename, service_type: $service_type, start_type: $start_type, error_control: $error_control, user: $user, computer: $computer, dll: $dll, evidence: $evidence})"
 	parameters := map[string]interface{}{
+		"id":            id,
 		"name":          s.Name,
 		"filename":      s.Filename,
 		"service_type":  s.ServiceType,
@@ -396,46 +385,33 @@ func persistService(tx neo4j.Transaction, s Service) (interface{}, error) {
 	return nil, err
 }
 
-func InsertDomainsNeo4j(con Neo4JConnector, domains []Domain) {
-	for _, d := range domains {
-		InsertDomainNeo4j(con, d)
-	}
-}
-
-func InsertDomainNeo4j(con Neo4JConnector, d Domain) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistDomain(tx, d)
-	})
-	handleErr(err)
-}
-
-func persistDomain(tx neo4j.Transaction, d Domain) (interface{}, error) {
-	query := "CREATE (:Domain {name: $name})"
+func persistDomain(tx neo4j.Transaction, d Domain, id string) (interface{}, error) {
+	query := "CREATE (:Domain {id: $id, name: $name})"
 	parameters := map[string]interface{}{
+		"id":   id,
 		"name": d.Name,
 	}
 	_, err := tx.Run(query, parameters)
 	return nil, err
 }
 
-func InsertWebHistoriesNeo4j(con Neo4JConnector, history []WebHistory) {
-	for _, h := range history {
-		InsertWebHistoryNeo4j(con, h)
+func persistHost(tx neo4j.Transaction, h Host, id string) (interface{}, error) {
+	query := "CREATE (:Host {id: $id, domain: $domain, ip: $ip})"
+	parameters := map[string]interface{}{
+		"id":     id,
+		"domain": h.Domain,
+		"ip":     h.IP,
 	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func InsertWebHistoryNeo4j(con Neo4JConnector, h WebHistory) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistWebHistory(tx, h)
-	})
-	handleErr(err)
-}
-
-func persistWebHistory(tx neo4j.Transaction, h WebHistory) (interface{}, error) {
-	query := "CREATE (:WebHistory {url: $url, title: $title, visit_count: $visit_count, last_visit_time: $last_visit_time, timestamp: $timestamp, path: $path, evidence: $evidence, user: $user, computer: $computer})"
+func persistWebHistory(tx neo4j.Transaction, h WebHistory, id string) (interface{}, error) {
+	query := "CREATE (:WebHistory {id: $id, url: $url, title: $title, visit_count: $visit_count, last_visit_time: $last_visit_time, timestamp: $timestamp, path: $path, evidence: $evidence, user: $user, computer: $computer, "
+	query += "domain: $domain, browser: $browser, type: $type, download_path: $download_path, referrer: $referrer, mime_type: $mime_type, "
+	query += "received_bytes: $received_bytes, total_bytes: $total_bytes})"
 	parameters := map[string]interface{}{
+		"id":              id,
 		"url":             h.Url,
 		"title":           h.Title,
 		"visit_count":     h.VisitCount,
@@ -445,60 +421,107 @@ func persistWebHistory(tx neo4j.Transaction, h WebHistory) (interface{}, error)
 		"user":            h.User,
 		"computer":        h.Computer,
 		"timestamp":       h.Timestamp,
+		"domain":          h.Domain,
+		"browser":         h.Browser,
+		"type":            h.Type,
+		"download_path":   h.DownloadPath,
+		"referrer":        h.Referrer,
+		"mime_type":       h.MimeType,
+		"received_bytes":  h.ReceivedBytes,
+		"total_bytes":     h.TotalBytes,
 	}
 	_, err := tx.Run(query, parameters)
 	return nil, err
 }
 
-func InsertFilesNeo4j(con Neo4JConnector, files []File) {
-	for _, f := range files {
-		InsertFileNeo4j(con, f)
+func persistCookie(tx neo4j.Transaction, c Cookie, id string) (interface{}, error) {
+	query := `CREATE (:Cookie {id: $id, date: $date, timestamp: $timestamp, timestamp_desc: $timestamp_desc, last_access: $last_access, browser: $browser,
+		host: $host, name: $name, path: $path, url: $url, secure: $secure, httponly: $httponly, persistent: $persistent, user: $user,
+		computer: $computer, evidence: $evidence})`
+	parameters := map[string]interface{}{
+		"id":             id,
+		"date":           c.Date,
+		"timestamp":      c.Timestamp,
+		"timestamp_desc": c.TimestampDesc,
+		"last_access":    c.LastAccess,
+		"browser":        c.Browser,
+		"host":           c.Host,
+		"name":           c.Name,
+		"path":           c.Path,
+		"url":            c.Url,
+		"secure":         c.Secure,
+		"httponly":       c.HttpOnly,
+		"persistent":     c.Persistent,
+		"user":           c.User,
+		"computer":       c.Computer,
+		"evidence":       c.Evidence,
 	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func InsertFileNeo4j(con Neo4JConnector, f File) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistFile(tx, f)
-	})
-	handleErr(err)
-}
-
-func persistFile(tx neo4j.Transaction, f File) (interface{}, error) {
-	query := "CREATE (:File {fullpath: $fullpath, filename: $filename, extension: $extension, is_allocated: $is_allocated, date: $date, timestamp: $timestamp, timestamp_desc: $timestamp_desc, evidence: $evidence, date: $date, computer: $computer})"
+func persistFile(tx neo4j.Transaction, f File, id string) (interface{}, error) {
+	query := `CREATE (:File {id: $id, fullpath: $fullpath, filename: $filename, extension: $extension, is_allocated: $is_allocated, date: $date, timestamp: $timestamp,
+		timestamp_desc: $timestamp_desc, evidence: $evidence, computer: $computer, link_path: $link_path, user: $user, creation_time: $creation_time,
+		modification_time: $modification_time, access_time: $access_time, size: $size, volume_serial: $volume_serial, volume_label: $volume_label,
+		drive_type: $drive_type, machine_id: $machine_id, droid_volume_id: $droid_volume_id, droid_file_id: $droid_file_id,
+		birth_droid_volume_id: $birth_droid_volume_id, birth_droid_file_id: $birth_droid_file_id, sha1: $sha1, publisher: $publisher, product: $product,
+		description: $description, version: $version, fsevent_flags: $fsevent_flags, fsevent_id: $fsevent_id})`
 	parameters := map[string]interface{}{
-		"fullpath":       f.FullPath,
-		"filename":       f.Filename,
-		"extension":      f.Extension,
-		"is_allocated":   f.IsAllocated,
-		"date":           f.Date,
-		"timestamp":      f.Timestamp,
-		"timestamp_desc": f.TimestampDesc,
-		"computer":       f.Computer,
-		"evidence":       f.Evidence,
+		"id":                    id,
+		"fullpath":              f.FullPath,
+		"filename":              f.Filename,
+		"extension":             f.Extension,
+		"is_allocated":          f.IsAllocated,
+		"date":                  f.Date,
+		"timestamp":             f.Timestamp,
+		"timestamp_desc":        f.TimestampDesc,
+		"computer":              f.Computer,
+		"evidence":              f.Evidence,
+		"link_path":             f.LinkPath,
+		"user":                  f.User,
+		"creation_time":         f.CreationTime,
+		"modification_time":     f.ModificationTime,
+		"access_time":           f.AccessTime,
+		"size":                  f.Size,
+		"volume_serial":         f.VolumeSerial,
+		"volume_label":          f.VolumeLabel,
+		"drive_type":            f.DriveType,
+		"machine_id":            f.MachineID,
+		"droid_volume_id":       f.DroidVolumeID,
+		"droid_file_id":         f.DroidFileID,
+		"birth_droid_volume_id": f.BirthDroidVolumeID,
+		"birth_droid_file_id":   f.BirthDroidFileID,
+		"sha1":                  f.Sha1,
+		"publisher":             f.Publisher,
+		"product":               f.Product,
+		"description":           f.Description,
+		"version":               f.Version,
+		"fsevent_flags":         f.FSEventFlags,
+		"fsevent_id":            f.FSEventID,
 	}
 	_, err := tx.Run(query, parameters)
 	return nil, err
 }
 
-func InsertConnectionsNeo4j(con Neo4JConnector, connections []Connection) {
-	for _, c := range connections {
-		InsertConnectionNeo4j(con, c)
+func persistFolder(tx neo4j.Transaction, f Folder, id string) (interface{}, error) {
+	query := "CREATE (:Folder {id: $id, fullpath: $fullpath, filename: $filename, computer: $computer})"
+	parameters := map[string]interface{}{
+		"id":       id,
+		"fullpath": f.FullPath,
+		"filename": f.Filename,
+		"computer": f.Computer,
 	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func InsertConnectionNeo4j(con Neo4JConnector, c Connection) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistConnection(tx, c)
-	})
-	handleErr(err)
-}
-
-func persistConnection(tx neo4j.Transaction, c Connection) (interface{}, error) {
-	query := "CREATE (:Connection {timestamp: $timestamp, date:$date, protocol: $protocol, ip_source: $ip_source, ip_destination: $ip_destination, port_source: $port_source, port_destination: $port_destination, user: $user, user_domain: $user_domain, computer: $computer, process: $process, process_id: $process_id})"
+func persistConnection(tx neo4j.Transaction, c Connection, id string) (interface{}, error) {
+	query := "CREATE (:Connection {id: $id, timestamp: $timestamp, date:$date, protocol: $protocol, ip_source: $ip_source, ip_destination: $ip_destination, port_source: $port_source, port_destination: $port_destination, initiated: $initiated, user: $user, user_domain: $user_domain, computer: $computer, process: $process, process_id: $process_id, "
+	query += "source: $source, uid: $uid, service: $service, state: $state, action: $action, duration: $duration, bytes_sent: $bytes_sent, bytes_received: $bytes_received, "
+	query += "http_method: $http_method, http_host: $http_host, http_uris: $http_uris, http_user_agent: $http_user_agent, http_status: $http_status, evidence: $evidence})"
 	parameters := map[string]interface{}{
+		"id":               id,
 		"timestamp":        c.Timestamp,
 		"date":             c.Date,
 		"protocol":         c.Protocol,
@@ -506,39 +529,42 @@ func persistConnection(tx neo4j.Transaction, c Connection) (interface{}, error)
 		"ip_destination":   c.DestinationIP,
 		"port_source":      c.SourcePort,
 		"port_destination": c.DestinationPort,
+		"initiated":        c.Initiated,
 		"user":             c.User,
 		"user_domain":      c.UserDomain,
 		"computer":         c.Computer,
 		"process":          c.ProcessName,
 		"process_id":       c.ProcessId,
+		"source":           c.Source,
+		"uid":              c.Uid,
+		"service":          c.Service,
+		"state":            c.State,
+		"action":           c.Action,
+		"duration":         c.Duration,
+		"bytes_sent":       c.BytesSent,
+		"bytes_received":   c.BytesReceived,
+		"http_method":      c.HttpMethod,
+		"http_host":        c.HttpHost,
+		"http_uris":        c.HttpUris,
+		"http_user_agent":  c.HttpUserAgent,
+		"http_status":      c.HttpStatus,
+		"evidence":         c.Evidence,
 	}
 	_, err := tx.Run(query, parameters)
 	return nil, err
 }
 
-func InsertEventsNeo4j(con Neo4JConnector, events []Event) {
-	for _, e := range events {
-		InsertEventNeo4j(con, e)
-	}
-}
-
-func InsertEventNeo4j(con Neo4JConnector, e Event) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistEvent(tx, e)
-	})
-	handleErr(err)
-}
-
-func persistEvent(tx neo4j.Transaction, e Event) (interface{}, error) {
-	query := `CREATE (:Event {timestamp: $timestamp, date: $date, event_type: $event_type, title: $title,
+func persistEvent(tx neo4j.Transaction, e Event, id string) (interface{}, error) {
+	query := `CREATE (:Event {id: $id, timestamp: $timestamp, date: $date, event_type: $event_type, title: $title,
 		user_source: $user_source, user_destination: $user_destination, domain_source: $domain_source,
 		domain_destination: $domain_destination, group: $group, group_domain: $group_domain, process_source: $process_source, process_source_id: $process_source_id,
 		process_target: $process_target, process_target_id: $process_target_id, fullpath: $fullpath, filename: $filename,
-		extension: $extension, computer: $computer,evidence: $evidence})`
+		extension: $extension, computer: $computer, record_id: $record_id, channel: $channel, evidence: $evidence})`
 	parameters := map[string]interface{}{
+		"id":                 id,
 		"timestamp":          e.Timestamp,
+		"record_id":          e.RecordID,
+		"channel":            e.Channel,
 		"date":               e.Date,
 		"title":              e.Title,
 		"event_type":         e.Type,
@@ -562,24 +588,10 @@ func persistEvent(tx neo4j.Transaction, e Event) (interface{}, error) {
 	return nil, err
 }
 
-func InsertRegistriesNeo4j(con Neo4JConnector, registry []Registry) {
-	for _, r := range registry {
-		InsertRegistryNeo4j(con, r)
-	}
-}
-
-func InsertRegistryNeo4j(con Neo4JConnector, r Registry) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		return persistRegistry(tx, r)
-	})
-	handleErr(err)
-}
-
-func persistRegistry(tx neo4j.Transaction, r Registry) (interface{}, error) {
-	query := "CREATE (:Registry {timestamp: $timestamp, date: $date, key: $key, value: $value, computer: $computer, evidence: $evidence})"
+func persistRegistry(tx neo4j.Transaction, r Registry, id string) (interface{}, error) {
+	query := "CREATE (:Registry {id: $id, timestamp: $timestamp, date: $date, key: $key, value: $value, computer: $computer, evidence: $evidence})"
 	parameters := map[string]interface{}{
+		"id":        id,
 		"timestamp": r.LastModifictationTimestamp,
 		"date":      r.LastModificationTime,
 		"key":       r.Path,
@@ -591,558 +603,239 @@ func persistRegistry(tx neo4j.Transaction, r Registry) (interface{}, error) {
 	return nil, err
 }
 
-func linkProcess(con Neo4JConnector) {
-
-	//create link based on pid, ppid and name. Quick Filter to avoid some duplicates
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		var param map[string]interface{}
-		return tx.Run("match (n) match (m) where n.pid <> 0 and m.pid <> 0 and n.ppid = m.pid and n.pprocess_name = m.fullpath and n.timestamp > m.timestamp merge (m)-[:EXECUTE]->(n)", param)
-	})
-	handleErr(err)
-
-	//Remove duplicates
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		var param map[string]interface{}
-		return tx.Run("match (m)-[r:EXECUTE]->(n)<-[s:EXECUTE]-(o) where n.timestamp - m.timestamp < n.timestamp - o.timestamp delete s", param)
-	})
-	handleErr(err)
-
-}
-
-func linkGroup(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	// Link group to event to prepare for future query
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.group <> ""
-		match (g:Group) where e.group = g.name and e.group_domain = g.domain
-		merge (e)-[:ABOUT]->(g)`
-		var param map[string]interface{}
-		return tx.Run(query, param)
-	})
-
-	handleErr(err)
-
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (u:User)-->(e:Event)-->(g:Group) where e.event_type =~ "(?i).*enable.*"
-		merge (u)-[:ENABLE{timestamp:e.timestamp, date: e.date}]->(g)`
-		var param map[string]interface{}
-		return tx.Run(query, param)
-	})
-	handleErr(err)
-
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (u:User)-->(e:Event)-->(g:Group) where e.event_type =~ "(?i).*disable.*"
-		merge (u)-[:DISABLE{timestamp:e.timestamp, date: e.date}]->(g)`
-		var param map[string]interface{}
-		return tx.Run(query, param)
-	})
-	handleErr(err)
-
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (u:User)<--(e:Event)-->(g:Group) match (e)<--(source:User)
-		where e.event_type =~ "(?i).*added.*"
-		merge (u)-[:AddedTo{timestamp: e.timestamp, date:e.date}]->(g)`
-		var param map[string]interface{}
-		return tx.Run(query, param)
-	})
-	handleErr(err)
-
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (u:User)<--(e:Event)-->(g:Group) match (e)<--(source:User)
-		where e.event_type =~ "(?i).*removed.*"
-		merge (u)-[:RemovedFrom{timestamp: e.timestamp, date:e.date}]->(g)`
-		var param map[string]interface{}
-		return tx.Run(query, param)
-	})
-	handleErr(err)
-
-}
-
-func linkUsers(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		var param map[string]interface{}
-		return tx.Run(`match (u:User) match (p) where u.fullname = p.user or u.username = p.user and p.user <> "" and u.fullname <> "-" merge (u)-[:BY]->(p)`, param)
-	})
-
-	handleErr(err)
-}
-
-func linkComputers(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		var param map[string]interface{}
-		return tx.Run("match (c:Computer) match (p) where c.name = p.computer merge (c)-[:ON]->(p)", param)
-	})
-	handleErr(err)
-}
-
-func linkScriptBlock(con Neo4JConnector) {
-	//create link based on pid, ppid and name. Quick Filter to avoid some duplicates
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (s:ScriptBlock) match(p:Process) where p.pid = s.process_id and p.timestamp > s.timestamp
-		merge (p)-[:EXECUTE]->(s)`
-		var param map[string]interface{}
-		return tx.Run(query, param)
-	})
-	handleErr(err)
-
-	//Remove duplicates
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		var param map[string]interface{}
-		return tx.Run("match (m)-[r:EXECUTE]->(n:ScriptBlock)<-[s:EXECUTE]-(o) where n.timestamp - m.timestamp < n.timestamp - o.timestamp delete s", param)
-	})
-	handleErr(err)
-}
-
-func handleConnections(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	//Create Hosts Nodes based on Connection's IP destination
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := "match (n:Connection) with collect(distinct n.ip_destination) as ip_dests FOREACH (ip IN ip_dests | Create (:Host {domain: \"\", ip:ip}))"
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
-	// Convert Connection nodes to relationships between Hosts and Processes
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (n:Connection) with collect(n) as connections
-		UNWIND connections as c
-		match (p:Process) where p.fullpath = c.process and p.pid = c.process_id and p.timestamp < c.timestamp and c.computer = p.computer
-		match (h:Host) where h.ip = c.ip_destination 
-		merge (p)-[r:CONNECT{port_source:c.port_source,port_destination:c.port_destination, ip_source:c.ip_source, timestamp:c.timestamp, date:c.date}]->(h)`
-
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
-}
-
-func handleFileCreate(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	// Create File Based On Events "CreateFile" and "DeleteFile"
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "File Created" with collect(e) as events
-		UNWIND events as event
-		create (:File {fullpath: event.fullpath, filename: event.filename, extension:event.extension, 
-		timestamp:event.timestamp, date:event.date, timestamp_desc:"Creation Time", computer:event.computer})`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
-	fmt.Println("Link Processes to Files based on 'File Create' and 'File Delete' Events")
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "File Created"
-		match (p:Process) where e.process_source = p.fullpath and e.process_source_id = p.pid and e.computer = p.computer
-		match (f:File) where f.fullpath = e.fullpath and f.computer = e.computer
-		merge (p)-[:CREATE]->(f)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
-}
-
-func handleFileDelete(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "File Deleted" with collect(e) as events
-		UNWIND events as event
-		create (:File {fullpath: event.fullpath, filename: event.filename, extension:event.extension, 
-		timestamp:event.timestamp, date:event.date, timestamp_desc:"Deletion Time", computer:event.computer})`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "File Deleted"
-		match (p:Process) where e.process_source = p.fullpath and e.process_source_id = p.pid and e.computer = p.computer
-		match (f:File) where f.fullpath = e.fullpath and f.computer = e.computer
-		merge (p)-[:DELETE]->(f)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
-}
-
-func handleEventUsers(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (u:User) match (e:Event) where e.user_destination = u.fullname or e.user_destination = u.username and u.username <> ""
-		merge (e)<-[:ACTS]-(u)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (u:User) match (e:Event) where e.user_source = u.fullname or e.user_source = u.username and u.username <> ""
-		merge (e)-[:ON]->(u)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-}
-
-func handleRawAccessRead(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "Raw Access Read"
-		with collect(e) as events
-		unwind events as event
-		create (:File{fullpath: event.fullpath, filename: event.filename, computer: event.computer})`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
-	// Link Process and File based on "Raw Access Read" Events
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "Raw Access Read"
-		with collect(e) as events
-		unwind events as event
-		match (p:Process) where p.fullpath = event.process_source and p.pid = event.process_source_id and p.timestamp <= event.timestamp and p.computer = event.computer
-		match (f:File) where f.fullpath = event.fullpath and f.computer = event.computer
-		merge (p)-[:LOAD{timestamp:event.timestamp, date: event.date}]->(f)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-
-}
-
-func handleMemoryAccess(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (event:Event) where event.event_type = "Process's Memory Access"
-		with collect(event) as events
-		unwind events as e
-		match (source:Process) where source.fullpath = e.process_source and source.pid = e.process_source_id and source.timestamp <= e.timestamp and e.computer = source.computer
-		match (target: Process) where target.fullpath = e.process_target and target.pid = e.process_target_id and target.timestamp <= e.timestamp and e.computer = target.computer
-		merge (source)-[:MEMORY_ACCESS{timestamp: e.timestamp, date:e.date}]->(target)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
-	// Delete Duplicate (due to Pid collision on reboots)
-
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (m)-[r:MEMORY_ACCESS]->(n)<-[s:MEMORY_ACCESS]-(o) 
-		where n.timestamp - m.timestamp < n.timestamp - o.timestamp and m.pid = o.pid and m.fullpath=o.fullpath delete s`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
+func persistSecurityControlChange(tx neo4j.Transaction, c SecurityControlChange, id string) (interface{}, error) {
+	query := `CREATE (:SecurityControlChange {id: $id, timestamp: $timestamp, date: $date, product: $product, change_type: $change_type, title: $title,
+		setting: $setting, old_value: $old_value, new_value: $new_value, user: $user, user_domain: $user_domain, user_sid: $user_sid,
+		process: $process, computer: $computer, evidence: $evidence})`
+	parameters := map[string]interface{}{
+		"id":          id,
+		"timestamp":   c.Timestamp,
+		"date":        c.Date,
+		"product":     c.Product,
+		"change_type": c.Type,
+		"title":       c.Title,
+		"setting":     c.Setting,
+		"old_value":   c.OldValue,
+		"new_value":   c.NewValue,
+		"user":        c.User,
+		"user_domain": c.UserDomain,
+		"user_sid":    c.UserSID,
+		"process":     c.ProcessName,
+		"computer":    c.Computer,
+		"evidence":    c.Evidence,
+	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func handleImageLoaded(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "Image Loaded"
-		with collect(e) as events
-		unwind events as event
-		create (:File{fullpath: event.fullpath, filename: event.filename, computer: event.computer})`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
-	// Link File and Process from Events "Image Loaded"
-
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "Image Loaded"
-		with collect(e) as events
-		unwind events as event
-		match (p:Process) where p.fullpath = event.process_source and p.pid = event.process_source_id and p.computer = event.computer and p.timestamp <= event.timestamp
-		match (f:File) where f.fullpath = event.fullpath and f.computer = event.computer
-		merge (p)-[:LOAD{timestamp:event.timestamp, date: event.date}]->(f)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
+func persistDetection(tx neo4j.Transaction, d Detection, id string) (interface{}, error) {
+	query := `CREATE (:Detection {id: $id, timestamp: $timestamp, date: $date, detection_type: $detection_type, title: $title, detection_id: $detection_id,
+		threat_name: $threat_name, severity: $severity, category: $category, action: $action, fullpath: $fullpath, filename: $filename,
+		process: $process, user: $user, user_domain: $user_domain, computer: $computer, evidence: $evidence})`
+	parameters := map[string]interface{}{
+		"id":             id,
+		"timestamp":      d.Timestamp,
+		"date":           d.Date,
+		"detection_type": d.Type,
+		"title":          d.Title,
+		"detection_id":   d.DetectionID,
+		"threat_name":    d.ThreatName,
+		"severity":       d.Severity,
+		"category":       d.Category,
+		"action":         d.Action,
+		"fullpath":       d.FullPath,
+		"filename":       d.Filename,
+		"process":        d.ProcessName,
+		"user":           d.User,
+		"user_domain":    d.UserDomain,
+		"computer":       d.Computer,
+		"evidence":       d.Evidence,
+	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func handleDisableUserEvents(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "User Account Disabled"
-		with collect(e) as events
-		unwind events as event
-		match (actor:User)-[ACTS]->(event)-[:ON]->(target:User)
-		merge (actor)-[:DISABLE{timestamp:event.timestamp, date: event.date}]->(target)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
-
+func persistAntiForensics(tx neo4j.Transaction, af AntiForensics, id string) (interface{}, error) {
+	query := `CREATE (:AntiForensics {id: $id, timestamp: $timestamp, date: $date, af_type: $af_type, title: $title, channel: $channel,
+		setting: $setting, changes: $changes, auditing_removed: $auditing_removed, auditing_added: $auditing_added,
+		creates_gap: $creates_gap, gap_source: $gap_source, gap_start: $gap_start, gap_end: $gap_end,
+		user: $user, user_domain: $user_domain, user_sid: $user_sid, logonid: $logonid, process: $process, computer: $computer, evidence: $evidence})`
+	parameters := map[string]interface{}{
+		"id":               id,
+		"timestamp":        af.Timestamp,
+		"date":             af.Date,
+		"af_type":          af.Type,
+		"title":            af.Title,
+		"channel":          af.Channel,
+		"setting":          af.Setting,
+		"changes":          af.Changes,
+		"auditing_removed": af.AuditingRemoved,
+		"auditing_added":   af.AuditingAdded,
+		"creates_gap":      af.CreatesGap,
+		"gap_source":       af.GapSource,
+		"gap_start":        af.GapStart,
+		"gap_end":          af.GapEnd,
+		"user":             af.User,
+		"user_domain":      af.UserDomain,
+		"user_sid":         af.UserSID,
+		"logonid":          af.LogonID,
+		"process":          af.ProcessName,
+		"computer":         af.Computer,
+		"evidence":         af.Evidence,
+	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func handleEnableUserEvents(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "User Account Enabled"
-		with collect(e) as events
-		unwind events as event
-		match (actor:User)-[ACTS]->(event)-[:ON]->(target:User)
-		merge (actor)-[:ENABLE{timestamp:event.timestamp, date: event.date}]->(target)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
+func persistDevice(tx neo4j.Transaction, d Device, id string) (interface{}, error) {
+	query := `CREATE (:Device {id: $id, vendor: $vendor, product: $product, revision: $revision, serial: $serial, volume_guid: $volume_guid,
+		drive_letter: $drive_letter, volume_label: $volume_label, first_connected: $first_connected, first_connected_timestamp: $first_connected_timestamp,
+		last_connected: $last_connected, last_connected_timestamp: $last_connected_timestamp, users: $users, computer: $computer, evidence: $evidence})`
+	parameters := map[string]interface{}{
+		"id":                        id,
+		"vendor":                    d.Vendor,
+		"product":                   d.Product,
+		"revision":                  d.Revision,
+		"serial":                    d.Serial,
+		"volume_guid":               d.VolumeGUID,
+		"drive_letter":              d.DriveLetter,
+		"volume_label":              d.VolumeLabel,
+		"first_connected":           d.FirstConnected,
+		"first_connected_timestamp": d.FirstConnectedTimestamp,
+		"last_connected":            d.LastConnected,
+		"last_connected_timestamp":  d.LastConnectedTimestamp,
+		"users":                     d.Users,
+		"computer":                  d.Computer,
+		"evidence":                  d.Evidence,
+	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func handleDeleteUserEvents(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "User Account Deleted"
-		with collect(e) as events
-		unwind events as event
-		match (actor:User)-[ACTS]->(event)-[:ON]->(target:User)
-		merge (actor)-[:DELETE{timestamp:event.timestamp, date: event.date}]->(target)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
+func persistNetwork(tx neo4j.Transaction, n Network, id string) (interface{}, error) {
+	query := `CREATE (:Network {id: $id, interface_luid: $interface_luid, interface_type: $interface_type, profile_id: $profile_id, profile_flags: $profile_flags,
+		first_connected: $first_connected, first_connected_timestamp: $first_connected_timestamp, last_seen: $last_seen, last_seen_timestamp: $last_seen_timestamp,
+		user_sid: $user_sid, computer: $computer, evidence: $evidence})`
+	parameters := map[string]interface{}{
+		"id":                        id,
+		"interface_luid":            n.InterfaceLUID,
+		"interface_type":            n.InterfaceType,
+		"profile_id":                n.ProfileID,
+		"profile_flags":             n.ProfileFlags,
+		"first_connected":           n.FirstConnected,
+		"first_connected_timestamp": n.FirstConnectedTimestamp,
+		"last_seen":                 n.LastSeen,
+		"last_seen_timestamp":       n.LastSeenTimestamp,
+		"user_sid":                  n.UserSID,
+		"computer":                  n.Computer,
+		"evidence":                  n.Evidence,
+	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func handleCreateUserEvents(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "User Account Created"
-		with collect(e) as events
-		unwind events as event
-		match (actor:User)-[ACTS]->(event)-[:ON]->(target:User)
-		merge (actor)-[:CREATE{timestamp:event.timestamp, date: event.date}]->(target)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
+func persistCommand(tx neo4j.Transaction, c Command, id string) (interface{}, error) {
+	query := `CREATE (:Command {id: $id, date: $date, timestamp: $timestamp, local_time: $local_time, commandline: $commandline, output: $output, working_directory: $working_directory,
+		user: $user, user_domain: $user_domain, runas_user: $runas_user, runas_user_domain: $runas_user_domain, host_application: $host_application,
+		process_id: $process_id, transcript_path: $transcript_path, computer: $computer, evidence: $evidence})`
+	parameters := map[string]interface{}{
+		"id":                id,
+		"date":              c.Date,
+		"timestamp":         c.Timestamp,
+		"local_time":        c.LocalTime,
+		"commandline":       c.Commandline,
+		"output":            c.Output,
+		"working_directory": c.WorkingDirectory,
+		"user":              c.User,
+		"user_domain":       c.UserDomain,
+		"runas_user":        c.RunAsUser,
+		"runas_user_domain": c.RunAsUserDomain,
+		"host_application":  c.HostApplication,
+		"process_id":        c.ProcessID,
+		"transcript_path":   c.TranscriptPath,
+		"computer":          c.Computer,
+		"evidence":          c.Evidence,
+	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func handleChangeUserEvents(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "User Account Changed"
-		with collect(e) as events
-		unwind events as event
-		match (actor:User)-[ACTS]->(event)-[:ON]->(target:User)
-		merge (actor)-[:CHANGE{timestamp:event.timestamp, date: event.date}]->(target)`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-	handleErr(err)
+func persistDnsQuery(tx neo4j.Transaction, q DnsQuery, id string) (interface{}, error) {
+	query := `CREATE (:DnsQuery {id: $id, date: $date, timestamp: $timestamp, query: $query, query_type: $query_type, response_code: $response_code,
+		answers: $answers, ip_source: $ip_source, port_source: $port_source, ip_destination: $ip_destination, port_destination: $port_destination,
+		protocol: $protocol, uid: $uid, computer: $computer, evidence: $evidence})`
+	parameters := map[string]interface{}{
+		"id":               id,
+		"date":             q.Date,
+		"timestamp":        q.Timestamp,
+		"query":            q.Query,
+		"query_type":       q.QueryType,
+		"response_code":    q.ResponseCode,
+		"answers":          q.Answers,
+		"ip_source":        q.SourceIP,
+		"port_source":      q.SourcePort,
+		"ip_destination":   q.DestinationIP,
+		"port_destination": q.DestinationPort,
+		"protocol":         q.Protocol,
+		"uid":              q.Uid,
+		"computer":         q.Computer,
+		"evidence":         q.Evidence,
+	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func handleLogonEvents(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "Logon"
-		with collect(e) as events
-		unwind events as event
-		match (actor:User)-[ACTS]->(event)-[:ON]->(target:User)
-		where actor.fullname <> "-"
-		merge (actor)-[:LOGON{timestamp:event.timestamp, date: event.date}]->(target)
-		`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-
-	handleErr(err)
-
-	_, err = sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "Logon"
-		with collect(e) as events
-		unwind events as event
-		match (event)-[:ON]->(target:User)
-		match (computer:Computer) where computer.name = event.computer
-		merge (target)-[:LOGON{timestamp:event.timestamp, date: event.date}]->(computer)
-		`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-
-	handleErr(err)
+func persistAlert(tx neo4j.Transaction, a Alert, id string) (interface{}, error) {
+	query := `CREATE (:Alert {id: $id, date: $date, timestamp: $timestamp, tool: $tool, title: $title, rule_id: $rule_id, level: $level, tags: $tags,
+		details: $details, record_id: $record_id, event_id: $event_id, channel: $channel, user: $user, computer: $computer, evidence: $evidence})`
+	parameters := map[string]interface{}{
+		"id":        id,
+		"date":      a.Date,
+		"timestamp": a.Timestamp,
+		"tool":      a.Tool,
+		"title":     a.Title,
+		"rule_id":   a.RuleID,
+		"level":     a.Level,
+		"tags":      a.Tags,
+		"details":   a.Details,
+		"record_id": a.RecordID,
+		"event_id":  a.EventID,
+		"channel":   a.Channel,
+		"user":      a.User,
+		"computer":  a.Computer,
+		"evidence":  a.Evidence,
+	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func handleLogoffEvents(con Neo4JConnector) {
-	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-
-	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (e:Event) where e.event_type = "Logoff"
-		with collect(e) as events
-		unwind events as event
-		match (event)-[:ON]->(target:User)
-		match (computer:Computer) where computer.name = event.computer
-		merge (target)-[:LOGOFF{timestamp:event.timestamp, date: event.date}]->(computer)
-		`
-		parameters := map[string]interface{}{}
-		_, err := tx.Run(query, parameters)
-		return nil, err
-	})
-
-	handleErr(err)
+func persistFileAccess(tx neo4j.Transaction, a FileAccess, id string) (interface{}, error) {
+	query := `CREATE (:FileAccess {id: $id, timestamp: $timestamp, date: $date, fullpath: $fullpath, filename: $filename, is_folder: $is_folder,
+		source: $source, mru_order: $mru_order, user: $user, computer: $computer, evidence: $evidence})`
+	parameters := map[string]interface{}{
+		"id":        id,
+		"timestamp": a.Timestamp,
+		"date":      a.Date,
+		"fullpath":  a.FullPath,
+		"filename":  a.Filename,
+		"is_folder": a.IsFolder,
+		"source":    a.Source,
+		"mru_order": a.MruOrder,
+		"user":      a.User,
+		"computer":  a.Computer,
+		"evidence":  a.Evidence,
+	}
+	_, err := tx.Run(query, parameters)
+	return nil, err
 }
 
-func handleEvents(con Neo4JConnector) {
-
-	wg := sync.WaitGroup{}
-
-	// Create and Link File with Process based on Events "File Create" and "File Delete"
-	go func() {
-		wg.Add(1)
-		handleFileCreate(con)
-		wg.Done()
-	}()
-
-	go func() {
-		wg.Add(1)
-		handleFileDelete(con)
-		wg.Done()
-	}()
-
-	// Link Events to Users
-	go func() {
-		wg.Add(1)
-		handleEventUsers(con)
-		wg.Done()
-	}()
-
-	time.Sleep(3 * time.Second)
-	wg.Wait()
-
-	// Create File based on "RawAccessRead" Events
-	go func() {
-		wg.Add(1)
-		handleRawAccessRead(con)
-		wg.Done()
-
-	}()
-	// Link Process with Process based on "MemoryAccess" Events
-
-	go func() {
-		wg.Add(1)
-		handleMemoryAccess(con)
-		wg.Done()
-	}()
-	// Create File from Events "Image Loaded"
-	go func() {
-		wg.Add(1)
-		handleImageLoaded(con)
-		wg.Done()
-	}()
-
-	// Handle User -> User Events
-	go func() {
-		wg.Add(1)
-		handleCreateUserEvents(con)
-		wg.Done()
-	}()
-
-	time.Sleep(3 * time.Second)
-	wg.Wait()
-
-	go func() {
-		wg.Add(1)
-		handleDeleteUserEvents(con)
-		wg.Done()
-	}()
-
-	go func() {
-		wg.Add(1)
-		handleEnableUserEvents(con)
-		wg.Done()
-	}()
-
-	go func() {
-		wg.Add(1)
-		handleDisableUserEvents(con)
-		wg.Done()
-	}()
-
-	time.Sleep(3 * time.Second)
-	wg.Wait()
-
-	// handle Logon Events
-
-	go func() {
-		wg.Add(1)
-		handleLogonEvents(con)
-		wg.Done()
-	}()
-
-	// handle Logoff Events
-
-	go func() {
-		wg.Add(1)
-		handleLogoffEvents(con)
-		wg.Done()
-	}()
-
-	// handle Change User Events
-	go func() {
-		wg.Add(1)
-		handleChangeUserEvents(con)
-		wg.Done()
-	}()
-
-	// Link Events to Groups
-	go func() {
-		wg.Add(1)
-		linkGroup(con)
-		wg.Done()
-	}()
-
-	time.Sleep(2 * time.Second)
-	wg.Wait()
-}
+const updateIdsQuery = `match (n) set n.objectid = id(n)`
 
 func updateIds(con Neo4JConnector) {
 	sess := con.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 
 	_, err := sess.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `match (n) set n.objectid = id(n)`
+		query := updateIdsQuery
 		parameters := map[string]interface{}{}
 		_, err := tx.Run(query, parameters)
 		return nil, err
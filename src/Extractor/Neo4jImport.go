@@ -0,0 +1,180 @@
+package Extractor
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	. "plaso2graph/master/src/Linker"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The neo4j-import extractor writes the nodes and relationships in the CSV format of "neo4j-admin database import",
+// with an import.sh script loading them offline into an empty database
+
+// Array values are separated by the unit separator, the evidence may contain the default ";" delimiter
+const neo4jImportArrayDelimiter = "\x1f"
+
+func InitializeNeo4jImportExtractor(args map[string]interface{}) map[string]interface{} {
+	return initializeGraphExtractor(args)
+}
+
+func Neo4jImportExtract(data []interface{}, args map[string]interface{}) {
+	addGraphEntities(data, args)
+}
+
+func Neo4jImportPostProcessing(args map[string]interface{}) {
+	graph, _ := linkGraph(args)
+	output := args["output"].(string)
+
+	fmt.Println("Writing nodes...")
+	nodeFiles := WriteNodesNeo4jImport(graph.Nodes, output)
+
+	fmt.Println("Writing relationships...")
+	relationshipFiles := WriteRelationshipsNeo4jImport(graph.Relationships, output)
+
+	WriteNeo4jImportScript(nodeFiles, relationshipFiles, output)
+	fmt.Println("Run " + filepath.Join(output, "import.sh") + " on the Neo4j server to load the case")
+}
+
+// getNeo4jImportType return the type of a property in the header of the import files
+func getNeo4jImportType(value interface{}) string {
+	switch value.(type) {
+	case int, int64:
+		return "long"
+	case float64:
+		return "double"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "datetime"
+	case []string:
+		return "string[]"
+	}
+	return "string"
+}
+
+func formatNeo4jImportValue(value interface{}) string {
+	if v, ok := value.([]string); ok {
+		return strings.Join(v, neo4jImportArrayDelimiter)
+	}
+	return formatValue(value)
+}
+
+// WriteNodesNeo4jImport write one file per label and return their names
+func WriteNodesNeo4jImport(nodes []Node, output string) []string {
+	var filenames []string
+	files := map[string]*os.File{}
+	writers := map[string]*csv.Writer{}
+
+	for _, n := range nodes {
+		properties := getProperties(n.Entity)
+
+		writer, ok := writers[n.Label]
+		if !ok {
+			header := []string{"id:ID"}
+			for _, p := range properties {
+				header = append(header, p.Name+":"+getNeo4jImportType(p.Value))
+			}
+			header = append(header, ":LABEL")
+
+			filename := "nodes_" + getCsvFilename(n.Label)
+			files[n.Label], writer = createCsv(filepath.Join(output, filename), header)
+			writers[n.Label] = writer
+			filenames = append(filenames, filename)
+		}
+
+		row := []string{n.ID}
+		for _, p := range properties {
+			row = append(row, formatNeo4jImportValue(p.Value))
+		}
+		row = append(row, n.Label)
+		handleError(writer.Write(row))
+	}
+
+	for label, writer := range writers {
+		closeCsv(files[label], writer)
+	}
+	return filenames
+}
+
+// getRelationshipTypes return the type of every property of every relationship type
+func getRelationshipTypes(relationships []Relationship) map[string]string {
+	types := map[string]string{}
+	for _, r := range relationships {
+		for key, value := range r.Properties {
+			if _, ok := types[r.Type+"."+key]; !ok {
+				types[r.Type+"."+key] = getNeo4jImportType(value)
+			}
+		}
+	}
+	return types
+}
+
+// WriteRelationshipsNeo4jImport write one file per relationship type and return their names
+func WriteRelationshipsNeo4jImport(relationships []Relationship, output string) []string {
+	var filenames []string
+	keys := getRelationshipKeys(relationships)
+	types := getRelationshipTypes(relationships)
+	files := map[string]*os.File{}
+	writers := map[string]*csv.Writer{}
+
+	for _, r := range relationships {
+		writer, ok := writers[r.Type]
+		if !ok {
+			header := []string{":START_ID", ":END_ID", ":TYPE"}
+			for _, key := range keys[r.Type] {
+				header = append(header, key+":"+types[r.Type+"."+key])
+			}
+
+			filename := "relationships_" + strings.ToLower(r.Type) + ".csv"
+			files[r.Type], writer = createCsv(filepath.Join(output, filename), header)
+			writers[r.Type] = writer
+			filenames = append(filenames, filename)
+		}
+
+		row := []string{r.StartID, r.EndID, r.Type}
+		for _, key := range keys[r.Type] {
+			value, ok := r.Properties[key]
+			if !ok {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, formatNeo4jImportValue(value))
+		}
+		handleError(writer.Write(row))
+	}
+
+	for relType, writer := range writers {
+		closeCsv(files[relType], writer)
+	}
+	return filenames
+}
+
+// WriteNeo4jImportScript write the neo4j-admin command loading the files (the database must not exist or be stopped)
+func WriteNeo4jImportScript(nodeFiles []string, relationshipFiles []string, output string) {
+	sort.Strings(nodeFiles)
+	sort.Strings(relationshipFiles)
+
+	script := "#!/bin/sh\n"
+	script += "# Generated by plaso2graph, load the case into an empty database: ./import.sh [database]\n"
+	script += "# Neo4j 4.x uses \"neo4j-admin import --database=$DATABASE\" instead of \"neo4j-admin database import full $DATABASE\"\n"
+	script += "DATABASE=\"${1:-neo4j}\"\n"
+	script += "cd \"$(dirname \"$0\")\" || exit 1\n\n"
+	script += "neo4j-admin database import full \\\n"
+	script += "\t--array-delimiter=\"U+001F\" \\\n"
+	script += "\t--multiline-fields=true \\\n"
+	script += "\t--skip-bad-relationships=true \\\n"
+	for _, filename := range nodeFiles {
+		script += "\t--nodes=\"" + filename + "\" \\\n"
+	}
+	for _, filename := range relationshipFiles {
+		script += "\t--relationships=\"" + filename + "\" \\\n"
+	}
+	script += "\t\"$DATABASE\"\n"
+
+	err := os.WriteFile(filepath.Join(output, "import.sh"), []byte(script), 0755)
+	handleError(err)
+}
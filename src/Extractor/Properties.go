@@ -0,0 +1,421 @@
+package Extractor
+
+import (
+	. "plaso2graph/master/src/Entity"
+	. "plaso2graph/master/src/Linker"
+)
+
+// Contains the properties of every node, with the names used by the Neo4j extractor,
+// in the order of the columns of the file based extractors
+
+type property struct {
+	Name  string
+	Value interface{}
+}
+
+func getProperties(entity interface{}) []property {
+	switch e := entity.(type) {
+	case Process:
+		return []property{
+			{"created_time", e.CreatedTime},
+			{"timestamp", e.Timestamp},
+			{"filename", e.Filename},
+			{"fullpath", e.FullPath},
+			{"pid", e.PID},
+			{"commandline", e.Commandline},
+			{"ppid", e.PPID},
+			{"pprocess_name", e.ParentProcessName},
+			{"pprocess_commandline", e.ParentProcessCommandline},
+			{"user", e.User},
+			{"user_domain", e.UserDomain},
+			{"computer", e.Computer},
+			{"logonid", e.LogonID},
+			{"execution_artefacts", e.ExecutionArtefacts},
+			{"execution_confidence", e.ExecutionConfidence},
+			{"bytes_sent", e.BytesSent},
+			{"bytes_received", e.BytesReceived},
+			{"interface_luid", e.InterfaceLUID},
+			{"network_usage_start", e.NetworkUsageStart},
+			{"network_usage_end", e.NetworkUsageEnd},
+			{"decoded_commandline", e.DecodedCommandline},
+			{"obfuscation_score", e.ObfuscationScore},
+			{"record_id", e.RecordID},
+			{"channel", e.Channel},
+			{"evidence", e.Evidence},
+		}
+	case ScriptBlock:
+		return []property{
+			{"date", e.Date},
+			{"timestamp", e.Timestamp},
+			{"scriptblockid", e.ScriptBlockID},
+			{"scriptblocktext", e.Text},
+			{"context", e.Context},
+			{"process_id", e.ProcessID},
+			{"message_number", e.MessageNumber},
+			{"message_total", e.MessageTotal},
+			{"path", e.Path},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+			{"decoded_text", e.DecodedText},
+			{"obfuscation_score", e.ObfuscationScore},
+			{"type", e.Type},
+			{"host_application", e.HostApplication},
+			{"command_name", e.CommandName},
+			{"command_type", e.CommandType},
+			{"script_name", e.ScriptName},
+			{"user", e.User},
+			{"user_domain", e.UserDomain},
+			{"session_id", e.SessionID},
+			{"runspace_id", e.RunspaceID},
+			{"pipeline_id", e.PipelineID},
+			{"engine_state", e.EngineState},
+		}
+	case User:
+		return []property{
+			{"fullname", e.FullName},
+			{"username", e.Username},
+			{"comments", e.Comments},
+			{"sid", e.SID},
+			{"domain", e.Domain},
+		}
+	case Group:
+		return []property{
+			{"name", e.Name},
+			{"domain", e.Domain},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case Computer:
+		return []property{
+			{"name", e.Name},
+			{"domain", e.Domain},
+			{"log_gaps", e.LogGaps},
+		}
+	case ScheduledTask:
+		return []property{
+			{"application", e.Application},
+			{"user", e.User},
+			{"comment", e.Comment},
+			{"trigger", e.Trigger},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case Service:
+		return []property{
+			{"name", e.Name},
+			{"filename", e.Filename},
+			{"service_type", e.ServiceType},
+			{"start_type", e.StartType},
+			{"error_control", e.ErrorControl},
+			{"user", e.User},
+			{"computer", e.Computer},
+			{"dll", e.Dll},
+			{"evidence", e.Evidence},
+		}
+	case Domain:
+		return []property{
+			{"name", e.Name},
+		}
+	case Folder:
+		return []property{
+			{"fullpath", e.FullPath},
+			{"filename", e.Filename},
+			{"computer", e.Computer},
+		}
+	case Host:
+		return []property{
+			{"domain", e.Domain},
+			{"ip", e.IP},
+		}
+	case WebHistory:
+		return []property{
+			{"url", e.Url},
+			{"title", e.Title},
+			{"visit_count", e.VisitCount},
+			{"last_visit_time", e.LastTimeVisited},
+			{"timestamp", e.Timestamp},
+			{"path", e.Path},
+			{"evidence", e.Evidence},
+			{"user", e.User},
+			{"computer", e.Computer},
+			{"domain", e.Domain},
+			{"browser", e.Browser},
+			{"type", e.Type},
+			{"download_path", e.DownloadPath},
+			{"referrer", e.Referrer},
+			{"mime_type", e.MimeType},
+			{"received_bytes", e.ReceivedBytes},
+			{"total_bytes", e.TotalBytes},
+		}
+	case Cookie:
+		return []property{
+			{"date", e.Date},
+			{"timestamp", e.Timestamp},
+			{"timestamp_desc", e.TimestampDesc},
+			{"last_access", e.LastAccess},
+			{"browser", e.Browser},
+			{"host", e.Host},
+			{"name", e.Name},
+			{"path", e.Path},
+			{"url", e.Url},
+			{"secure", e.Secure},
+			{"httponly", e.HttpOnly},
+			{"persistent", e.Persistent},
+			{"user", e.User},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case File:
+		return []property{
+			{"fullpath", e.FullPath},
+			{"filename", e.Filename},
+			{"extension", e.Extension},
+			{"is_allocated", e.IsAllocated},
+			{"date", e.Date},
+			{"timestamp", e.Timestamp},
+			{"timestamp_desc", e.TimestampDesc},
+			{"evidence", e.Evidence},
+			{"computer", e.Computer},
+			{"link_path", e.LinkPath},
+			{"user", e.User},
+			{"creation_time", e.CreationTime},
+			{"modification_time", e.ModificationTime},
+			{"access_time", e.AccessTime},
+			{"size", e.Size},
+			{"volume_serial", e.VolumeSerial},
+			{"volume_label", e.VolumeLabel},
+			{"drive_type", e.DriveType},
+			{"machine_id", e.MachineID},
+			{"droid_volume_id", e.DroidVolumeID},
+			{"droid_file_id", e.DroidFileID},
+			{"birth_droid_volume_id", e.BirthDroidVolumeID},
+			{"birth_droid_file_id", e.BirthDroidFileID},
+			{"sha1", e.Sha1},
+			{"publisher", e.Publisher},
+			{"product", e.Product},
+			{"description", e.Description},
+			{"version", e.Version},
+			{"fsevent_flags", e.FSEventFlags},
+			{"fsevent_id", e.FSEventID},
+		}
+	case Connection:
+		return []property{
+			{"timestamp", e.Timestamp},
+			{"date", e.Date},
+			{"protocol", e.Protocol},
+			{"ip_source", e.SourceIP},
+			{"ip_destination", e.DestinationIP},
+			{"port_source", e.SourcePort},
+			{"port_destination", e.DestinationPort},
+			{"initiated", e.Initiated},
+			{"user", e.User},
+			{"user_domain", e.UserDomain},
+			{"computer", e.Computer},
+			{"process", e.ProcessName},
+			{"process_id", e.ProcessId},
+			{"source", e.Source},
+			{"uid", e.Uid},
+			{"service", e.Service},
+			{"state", e.State},
+			{"action", e.Action},
+			{"duration", e.Duration},
+			{"bytes_sent", e.BytesSent},
+			{"bytes_received", e.BytesReceived},
+			{"http_method", e.HttpMethod},
+			{"http_host", e.HttpHost},
+			{"http_uris", e.HttpUris},
+			{"http_user_agent", e.HttpUserAgent},
+			{"http_status", e.HttpStatus},
+			{"evidence", e.Evidence},
+		}
+	case Event:
+		return []property{
+			{"timestamp", e.Timestamp},
+			{"date", e.Date},
+			{"event_type", e.Type},
+			{"title", e.Title},
+			{"user_source", e.UserSource},
+			{"user_destination", e.UserDestination},
+			{"domain_source", e.UserSourceDomain},
+			{"domain_destination", e.UserDestinationDomain},
+			{"group", e.GroupName},
+			{"group_domain", e.GroupDomain},
+			{"process_source", e.ProcessSource},
+			{"process_source_id", e.ProcessSourceId},
+			{"process_target", e.ProcessTarget},
+			{"process_target_id", e.ProcessTargetId},
+			{"fullpath", e.FullPath},
+			{"filename", e.Filename},
+			{"extension", e.Extension},
+			{"computer", e.Computer},
+			{"record_id", e.RecordID},
+			{"channel", e.Channel},
+			{"evidence", e.Evidence},
+		}
+	case Registry:
+		return []property{
+			{"timestamp", e.LastModifictationTimestamp},
+			{"date", e.LastModificationTime},
+			{"key", e.Path},
+			{"value", e.Entries},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case SecurityControlChange:
+		return []property{
+			{"timestamp", e.Timestamp},
+			{"date", e.Date},
+			{"product", e.Product},
+			{"change_type", e.Type},
+			{"title", e.Title},
+			{"setting", e.Setting},
+			{"old_value", e.OldValue},
+			{"new_value", e.NewValue},
+			{"user", e.User},
+			{"user_domain", e.UserDomain},
+			{"user_sid", e.UserSID},
+			{"process", e.ProcessName},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case Detection:
+		return []property{
+			{"timestamp", e.Timestamp},
+			{"date", e.Date},
+			{"detection_type", e.Type},
+			{"title", e.Title},
+			{"detection_id", e.DetectionID},
+			{"threat_name", e.ThreatName},
+			{"severity", e.Severity},
+			{"category", e.Category},
+			{"action", e.Action},
+			{"fullpath", e.FullPath},
+			{"filename", e.Filename},
+			{"process", e.ProcessName},
+			{"user", e.User},
+			{"user_domain", e.UserDomain},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case AntiForensics:
+		return []property{
+			{"timestamp", e.Timestamp},
+			{"date", e.Date},
+			{"af_type", e.Type},
+			{"title", e.Title},
+			{"channel", e.Channel},
+			{"setting", e.Setting},
+			{"changes", e.Changes},
+			{"auditing_removed", e.AuditingRemoved},
+			{"auditing_added", e.AuditingAdded},
+			{"creates_gap", e.CreatesGap},
+			{"gap_source", e.GapSource},
+			{"gap_start", e.GapStart},
+			{"gap_end", e.GapEnd},
+			{"user", e.User},
+			{"user_domain", e.UserDomain},
+			{"user_sid", e.UserSID},
+			{"logonid", e.LogonID},
+			{"process", e.ProcessName},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case Device:
+		return []property{
+			{"vendor", e.Vendor},
+			{"product", e.Product},
+			{"revision", e.Revision},
+			{"serial", e.Serial},
+			{"volume_guid", e.VolumeGUID},
+			{"drive_letter", e.DriveLetter},
+			{"volume_label", e.VolumeLabel},
+			{"first_connected", e.FirstConnected},
+			{"first_connected_timestamp", e.FirstConnectedTimestamp},
+			{"last_connected", e.LastConnected},
+			{"last_connected_timestamp", e.LastConnectedTimestamp},
+			{"users", e.Users},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case FileAccess:
+		return []property{
+			{"timestamp", e.Timestamp},
+			{"date", e.Date},
+			{"fullpath", e.FullPath},
+			{"filename", e.Filename},
+			{"is_folder", e.IsFolder},
+			{"source", e.Source},
+			{"mru_order", e.MruOrder},
+			{"user", e.User},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case Network:
+		return []property{
+			{"interface_luid", e.InterfaceLUID},
+			{"interface_type", e.InterfaceType},
+			{"profile_id", e.ProfileID},
+			{"profile_flags", e.ProfileFlags},
+			{"first_connected", e.FirstConnected},
+			{"first_connected_timestamp", e.FirstConnectedTimestamp},
+			{"last_seen", e.LastSeen},
+			{"last_seen_timestamp", e.LastSeenTimestamp},
+			{"user_sid", e.UserSID},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case Command:
+		return []property{
+			{"date", e.Date},
+			{"timestamp", e.Timestamp},
+			{"local_time", e.LocalTime},
+			{"commandline", e.Commandline},
+			{"output", e.Output},
+			{"working_directory", e.WorkingDirectory},
+			{"user", e.User},
+			{"user_domain", e.UserDomain},
+			{"runas_user", e.RunAsUser},
+			{"runas_user_domain", e.RunAsUserDomain},
+			{"host_application", e.HostApplication},
+			{"process_id", e.ProcessID},
+			{"transcript_path", e.TranscriptPath},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case DnsQuery:
+		return []property{
+			{"date", e.Date},
+			{"timestamp", e.Timestamp},
+			{"query", e.Query},
+			{"query_type", e.QueryType},
+			{"response_code", e.ResponseCode},
+			{"answers", e.Answers},
+			{"ip_source", e.SourceIP},
+			{"port_source", e.SourcePort},
+			{"ip_destination", e.DestinationIP},
+			{"port_destination", e.DestinationPort},
+			{"protocol", e.Protocol},
+			{"uid", e.Uid},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	case Alert:
+		return []property{
+			{"date", e.Date},
+			{"timestamp", e.Timestamp},
+			{"tool", e.Tool},
+			{"title", e.Title},
+			{"rule_id", e.RuleID},
+			{"level", e.Level},
+			{"tags", e.Tags},
+			{"details", e.Details},
+			{"record_id", e.RecordID},
+			{"event_id", e.EventID},
+			{"channel", e.Channel},
+			{"user", e.User},
+			{"computer", e.Computer},
+			{"evidence", e.Evidence},
+		}
+	}
+	return nil
+}
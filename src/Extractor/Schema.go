@@ -0,0 +1,269 @@
+package Extractor
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"os"
+	. "plaso2graph/master/src/Entity"
+	. "plaso2graph/master/src/Linker"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The json and xml extractors write the case with the properties of the other extractors (getProperties).
+// Their format is described by a JSON Schema and an XSD, generated from the properties of every label
+// and written next to the case, a new property or label changes the schema version.
+
+const caseSchemaVersion = "1.0"
+
+// nodeIDPattern match the node IDs: the UUID of the case, the label and the index of the node
+const nodeIDPattern = "[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}:[A-Za-z]+:[0-9]+"
+
+// caseNamespace is the namespace of the XML case and the identifier of the JSON Schema
+const caseNamespace = "urn:plaso2graph:case:" + caseSchemaVersion
+
+// schemaEntities is an empty entity of every label
+var schemaEntities = []interface{}{
+	Process{}, ScriptBlock{}, File{}, Folder{}, User{}, Group{}, Computer{}, ScheduledTask{}, Service{}, Domain{}, Host{},
+	WebHistory{}, Connection{}, Event{}, Registry{}, SecurityControlChange{}, Detection{}, AntiForensics{}, Device{},
+	FileAccess{}, Network{}, Command{}, Cookie{}, Alert{}, DnsQuery{},
+}
+
+// getSchemaFilename return the name of the JSON Schema (".schema.json") or XSD (".xsd") of the current version
+func getSchemaFilename(extension string) string {
+	return "plaso2graph-case-" + caseSchemaVersion + extension
+}
+
+// getJsonValue convert a property to its JSON value: dates are RFC 3339 strings, unknown dates and numbers are null
+func getJsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return v.Format(time.RFC3339Nano)
+	case []string:
+		if v == nil {
+			return []string{}
+		}
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return v
+	}
+	return value
+}
+
+// getJsonProperties return the properties of an entity with their JSON value
+func getJsonProperties(entity interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for _, p := range getProperties(entity) {
+		properties[p.Name] = getJsonValue(p.Value)
+	}
+	return properties
+}
+
+// getJsonRelationshipProperties return the properties of a relationship with their JSON value
+func getJsonRelationshipProperties(r Relationship) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for key, value := range r.Properties {
+		properties[key] = getJsonValue(value)
+	}
+	return properties
+}
+
+func getJsonSchemaType(value interface{}) map[string]interface{} {
+	switch value.(type) {
+	case time.Time:
+		return map[string]interface{}{"type": []string{"string", "null"}, "format": "date-time"}
+	case []string:
+		return map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+	case int, int64:
+		return map[string]interface{}{"type": "integer"}
+	case float64:
+		return map[string]interface{}{"type": []string{"number", "null"}}
+	case bool:
+		return map[string]interface{}{"type": "boolean"}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+// getJsonSchema return the JSON Schema of a line of case.jsonl
+func getJsonSchema() map[string]interface{} {
+	definitions := map[string]interface{}{
+		"case": map[string]interface{}{
+			"type":                 "object",
+			"required":             []string{"kind", "version"},
+			"additionalProperties": false,
+			"properties": map[string]interface{}{
+				"kind":    map[string]interface{}{"const": "case"},
+				"version": map[string]interface{}{"const": caseSchemaVersion},
+				"schema":  map[string]interface{}{"type": "string"},
+			},
+		},
+		"relationship": map[string]interface{}{
+			"type":                 "object",
+			"required":             []string{"kind", "type", "start_id", "end_id", "properties"},
+			"additionalProperties": false,
+			"properties": map[string]interface{}{
+				"kind":       map[string]interface{}{"const": "relationship"},
+				"type":       map[string]interface{}{"type": "string"},
+				"start_id":   map[string]interface{}{"type": "string", "pattern": "^" + nodeIDPattern + "$"},
+				"end_id":     map[string]interface{}{"type": "string", "pattern": "^" + nodeIDPattern + "$"},
+				"properties": map[string]interface{}{"type": "object"},
+			},
+		},
+	}
+	references := []interface{}{
+		map[string]interface{}{"$ref": "#/$defs/case"},
+		map[string]interface{}{"$ref": "#/$defs/relationship"},
+	}
+
+	for _, entity := range schemaEntities {
+		label := GetLabel(entity)
+		var required []string
+		properties := map[string]interface{}{}
+		for _, p := range getProperties(entity) {
+			required = append(required, p.Name)
+			properties[p.Name] = getJsonSchemaType(p.Value)
+		}
+
+		definitions[label] = map[string]interface{}{
+			"type":                 "object",
+			"required":             []string{"kind", "id", "label", "properties"},
+			"additionalProperties": false,
+			"properties": map[string]interface{}{
+				"kind":  map[string]interface{}{"const": "node"},
+				"id":    map[string]interface{}{"type": "string", "pattern": "^" + strings.Replace(nodeIDPattern, "[A-Za-z]+", label, 1) + "$"},
+				"label": map[string]interface{}{"const": label},
+				"properties": map[string]interface{}{
+					"type":                 "object",
+					"required":             required,
+					"additionalProperties": false,
+					"properties":           properties,
+				},
+			},
+		}
+		references = append(references, map[string]interface{}{"$ref": "#/$defs/" + label})
+	}
+
+	return map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         caseNamespace,
+		"title":       "plaso2graph case " + caseSchemaVersion,
+		"description": "A line of case.jsonl: the case header, a node (its kind is \"node\" and its label the type of entity) or a relationship between two node ids",
+		"oneOf":       references,
+		"$defs":       definitions,
+	}
+}
+
+func WriteJsonSchema(path string) {
+	b, err := json.MarshalIndent(getJsonSchema(), "", "  ")
+	handleError(err)
+	handleError(os.WriteFile(path, append(b, '\n'), 0644))
+}
+
+// getXsdType return the XSD type of a property, the lists are "List" elements with an "item" per value
+func getXsdType(value interface{}) string {
+	switch value.(type) {
+	case time.Time:
+		return "xs:dateTime"
+	case []string:
+		return "List"
+	case int, int64:
+		return "xs:long"
+	case float64:
+		return "xs:double"
+	case bool:
+		return "xs:boolean"
+	}
+	return "xs:string"
+}
+
+func WriteXsd(path string) {
+	file, err := os.Create(path)
+	handleError(err)
+	w := bufio.NewWriter(file)
+
+	w.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	w.WriteString("<xs:schema xmlns:xs=\"http://www.w3.org/2001/XMLSchema\" xmlns=\"" + caseNamespace + "\" xmlns:c=\"" + caseNamespace + "\" ")
+	w.WriteString("targetNamespace=\"" + caseNamespace + "\" elementFormDefault=\"qualified\" version=\"" + caseSchemaVersion + "\">\n\n")
+
+	// Case: the nodes then the relationships, which reference the id of the nodes
+	w.WriteString("  <xs:element name=\"Case\">\n")
+	w.WriteString("    <xs:complexType>\n      <xs:sequence>\n")
+	w.WriteString("        <xs:element name=\"Nodes\">\n          <xs:complexType>\n            <xs:choice minOccurs=\"0\" maxOccurs=\"unbounded\">\n")
+	for _, entity := range schemaEntities {
+		label := GetLabel(entity)
+		w.WriteString("              <xs:element name=\"" + label + "\" type=\"" + label + "\"/>\n")
+	}
+	w.WriteString("            </xs:choice>\n          </xs:complexType>\n        </xs:element>\n")
+	w.WriteString("        <xs:element name=\"Relationships\">\n          <xs:complexType>\n            <xs:sequence>\n")
+	w.WriteString("              <xs:element name=\"Relationship\" type=\"Relationship\" minOccurs=\"0\" maxOccurs=\"unbounded\"/>\n")
+	w.WriteString("            </xs:sequence>\n          </xs:complexType>\n        </xs:element>\n")
+	w.WriteString("      </xs:sequence>\n")
+	w.WriteString("      <xs:attribute name=\"version\" type=\"xs:string\" use=\"required\" fixed=\"" + caseSchemaVersion + "\"/>\n")
+	w.WriteString("    </xs:complexType>\n")
+	w.WriteString("    <xs:key name=\"NodeID\">\n      <xs:selector xpath=\"c:Nodes/*\"/>\n      <xs:field xpath=\"@id\"/>\n    </xs:key>\n")
+	w.WriteString("    <xs:keyref name=\"StartID\" refer=\"NodeID\">\n      <xs:selector xpath=\"c:Relationships/c:Relationship\"/>\n      <xs:field xpath=\"@start_id\"/>\n    </xs:keyref>\n")
+	w.WriteString("    <xs:keyref name=\"EndID\" refer=\"NodeID\">\n      <xs:selector xpath=\"c:Relationships/c:Relationship\"/>\n      <xs:field xpath=\"@end_id\"/>\n    </xs:keyref>\n")
+	w.WriteString("  </xs:element>\n\n")
+
+	w.WriteString("  <xs:complexType name=\"List\">\n    <xs:sequence>\n")
+	w.WriteString("      <xs:element name=\"item\" type=\"xs:string\" minOccurs=\"0\" maxOccurs=\"unbounded\"/>\n")
+	w.WriteString("    </xs:sequence>\n  </xs:complexType>\n\n")
+
+	w.WriteString("  <xs:complexType name=\"Relationship\">\n    <xs:sequence>\n")
+	w.WriteString("      <xs:element name=\"Property\" minOccurs=\"0\" maxOccurs=\"unbounded\">\n")
+	w.WriteString("        <xs:complexType>\n          <xs:simpleContent>\n            <xs:extension base=\"xs:string\">\n")
+	w.WriteString("              <xs:attribute name=\"name\" type=\"xs:string\" use=\"required\"/>\n")
+	w.WriteString("            </xs:extension>\n          </xs:simpleContent>\n        </xs:complexType>\n      </xs:element>\n")
+	w.WriteString("    </xs:sequence>\n")
+	for _, attribute := range []string{"type", "start_id", "end_id"} {
+		w.WriteString("    <xs:attribute name=\"" + attribute + "\" type=\"xs:string\" use=\"required\"/>\n")
+	}
+	w.WriteString("  </xs:complexType>\n")
+
+	// A node is the element of its label with its properties in the order of getProperties, unknown dates are omitted
+	for _, entity := range schemaEntities {
+		label := GetLabel(entity)
+		w.WriteString("\n  <xs:complexType name=\"" + label + "\">\n    <xs:sequence>\n")
+		for _, p := range getProperties(entity) {
+			w.WriteString("      <xs:element name=\"" + p.Name + "\" type=\"" + getXsdType(p.Value) + "\" minOccurs=\"0\"/>\n")
+		}
+		w.WriteString("    </xs:sequence>\n")
+		w.WriteString("    <xs:attribute name=\"id\" use=\"required\">\n      <xs:simpleType>\n        <xs:restriction base=\"xs:string\">\n")
+		w.WriteString("          <xs:pattern value=\"" + strings.Replace(nodeIDPattern, "[A-Za-z]+", label, 1) + "\"/>\n")
+		w.WriteString("        </xs:restriction>\n      </xs:simpleType>\n    </xs:attribute>\n")
+		w.WriteString("  </xs:complexType>\n")
+	}
+	w.WriteString("</xs:schema>\n")
+
+	handleError(w.Flush())
+	handleError(file.Close())
+}
+
+// getXmlValue write a property of a node as the content of its element
+func getXmlValue(value interface{}) string {
+	switch v := value.(type) {
+	case []string:
+		var b strings.Builder
+		for _, item := range v {
+			b.WriteString("<item>" + escapeXml(item) + "</item>")
+		}
+		return b.String()
+	case float64:
+		switch {
+		case math.IsInf(v, 1):
+			return "INF"
+		case math.IsInf(v, -1):
+			return "-INF"
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return escapeXml(formatValue(value))
+}
@@ -0,0 +1,166 @@
+package Extractor
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	. "plaso2graph/master/src/Linker"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The SQLite extractor writes the case in a single case.sqlite database: a "nodes" table with the id and the label of every node,
+// one table per label (process, user, task...) with the properties, a "relationships" table and an "evidence" table.
+// Every table references the nodes table, so the case can be queried with SQL and imported again in Neo4j.
+
+func InitializeSqliteExtractor(args map[string]interface{}) map[string]interface{} {
+	return initializeGraphExtractor(args)
+}
+
+func SqliteExtract(data []interface{}, args map[string]interface{}) {
+	addGraphEntities(data, args)
+}
+
+func SqlitePostProcessing(args map[string]interface{}) {
+	graph, _ := linkGraph(args)
+
+	fmt.Println("Writing case.sqlite...")
+	WriteSqlite(graph, filepath.Join(args["output"].(string), "case.sqlite"))
+}
+
+// getSqliteTable return the table of a label, named like the files of the csv extractor
+func getSqliteTable(label string) string {
+	return strings.TrimSuffix(getCsvFilename(label), ".csv")
+}
+
+func getSqliteType(value interface{}) string {
+	switch getGraphAttributeType(value) {
+	case "long", "boolean":
+		return "INTEGER"
+	case "double":
+		return "REAL"
+	}
+	return "TEXT"
+}
+
+// getSqliteValue convert a property to a column value, empty dates and lists are NULL
+func getSqliteValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return v.Format(time.RFC3339Nano)
+	case []string:
+		if len(v) == 0 {
+			return nil
+		}
+		return formatValue(v)
+	}
+	return value
+}
+
+func execSqlite(tx *sql.Tx, query string) {
+	_, err := tx.Exec(query)
+	handleError(err)
+}
+
+// createSqliteTables create the table of every label with the columns of its first node, and return the insert statements
+func createSqliteTables(tx *sql.Tx, nodes []Node) map[string]*sql.Stmt {
+	statements := map[string]*sql.Stmt{}
+
+	for _, n := range nodes {
+		if _, ok := statements[n.Label]; ok {
+			continue
+		}
+		table := getSqliteTable(n.Label)
+
+		columns := []string{"id TEXT PRIMARY KEY REFERENCES nodes(id)"}
+		names := []string{"id"}
+		var indexes []string
+		for _, p := range getProperties(n.Entity) {
+			if p.Name == "evidence" {
+				continue
+			}
+			columns = append(columns, "\""+p.Name+"\" "+getSqliteType(p.Value))
+			names = append(names, "\""+p.Name+"\"")
+			if p.Name == "computer" || p.Name == "timestamp" || p.Name == "user" {
+				indexes = append(indexes, p.Name)
+			}
+		}
+
+		execSqlite(tx, "CREATE TABLE \""+table+"\" ("+strings.Join(columns, ", ")+")")
+		for _, column := range indexes {
+			execSqlite(tx, "CREATE INDEX \""+table+"_"+column+"\" ON \""+table+"\" (\""+column+"\")")
+		}
+
+		statement, err := tx.Prepare("INSERT INTO \"" + table + "\" (" + strings.Join(names, ", ") + ") VALUES (?" + strings.Repeat(", ?", len(names)-1) + ")")
+		handleError(err)
+		statements[n.Label] = statement
+	}
+	return statements
+}
+
+func WriteSqlite(graph *Graph, path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		handleError(err)
+	}
+
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on")
+	handleError(err)
+	defer db.Close()
+
+	tx, err := db.Begin()
+	handleError(err)
+
+	execSqlite(tx, "CREATE TABLE nodes (id TEXT PRIMARY KEY, label TEXT NOT NULL)")
+	execSqlite(tx, "CREATE INDEX nodes_label ON nodes (label)")
+	execSqlite(tx, `CREATE TABLE relationships (id INTEGER PRIMARY KEY, type TEXT NOT NULL,
+		start_id TEXT NOT NULL REFERENCES nodes(id), end_id TEXT NOT NULL REFERENCES nodes(id), properties TEXT)`)
+	execSqlite(tx, "CREATE INDEX relationships_type ON relationships (type)")
+	execSqlite(tx, "CREATE INDEX relationships_start_id ON relationships (start_id)")
+	execSqlite(tx, "CREATE INDEX relationships_end_id ON relationships (end_id)")
+	execSqlite(tx, "CREATE TABLE evidence (id INTEGER PRIMARY KEY, node_id TEXT NOT NULL REFERENCES nodes(id), position INTEGER NOT NULL, evidence TEXT)")
+	execSqlite(tx, "CREATE INDEX evidence_node_id ON evidence (node_id)")
+
+	statements := createSqliteTables(tx, graph.Nodes)
+
+	insertNode, err := tx.Prepare("INSERT INTO nodes (id, label) VALUES (?, ?)")
+	handleError(err)
+	insertEvidence, err := tx.Prepare("INSERT INTO evidence (node_id, position, evidence) VALUES (?, ?, ?)")
+	handleError(err)
+	insertRelationship, err := tx.Prepare("INSERT INTO relationships (type, start_id, end_id, properties) VALUES (?, ?, ?, ?)")
+	handleError(err)
+
+	for _, n := range graph.Nodes {
+		_, err = insertNode.Exec(n.ID, n.Label)
+		handleError(err)
+
+		values := []interface{}{n.ID}
+		for _, p := range getProperties(n.Entity) {
+			if p.Name != "evidence" {
+				values = append(values, getSqliteValue(p.Value))
+				continue
+			}
+			for i, evidence := range p.Value.([]string) {
+				_, err = insertEvidence.Exec(n.ID, i, evidence)
+				handleError(err)
+			}
+		}
+		_, err = statements[n.Label].Exec(values...)
+		handleError(err)
+	}
+
+	for _, r := range graph.Relationships {
+		properties, err := json.Marshal(r.Properties)
+		handleError(err)
+		_, err = insertRelationship.Exec(r.Type, r.StartID, r.EndID, string(properties))
+		handleError(err)
+	}
+
+	handleError(tx.Commit())
+}
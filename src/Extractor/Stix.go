@@ -0,0 +1,454 @@
+package Extractor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	. "plaso2graph/master/src/Entity"
+	. "plaso2graph/master/src/Linker"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The STIX extractor writes the case as STIX 2.1 Cyber Observables: File (file and directory), Process (process),
+// User (user-account), Host (ipv4-addr / ipv6-addr and domain-name), Connection (network-traffic), Domain (domain-name),
+// WebHistory (url) and Registry (windows-registry-key). The relationships between them are SROs.
+// With "-stix-bundle computer" one bundle_<computer>.json is written per computer, otherwise bundle.json holds the case.
+
+// stixObject is a STIX object, marshalled with its keys sorted
+type stixObject map[string]interface{}
+
+// stixNamespace is the namespace of the deterministic identifiers of the Cyber Observables (STIX 2.1 section 2.9)
+var stixNamespace = []byte{0x00, 0xab, 0xed, 0xb4, 0xaa, 0x42, 0x46, 0x6c, 0x9c, 0x01, 0xfe, 0xd2, 0x33, 0x15, 0xa9, 0xb7}
+
+var stixFilenameRegexp = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+func InitializeStixExtractor(args map[string]interface{}) map[string]interface{} {
+	if args["stix-bundle"] == nil || args["stix-bundle"].(string) == "" {
+		args["stix-bundle"] = "case"
+	}
+	if args["stix-bundle"].(string) != "case" && args["stix-bundle"].(string) != "computer" {
+		log.Fatal("STIX bundle must be one from: case, computer.")
+	}
+
+	return initializeGraphExtractor(args)
+}
+
+func StixExtract(data []interface{}, args map[string]interface{}) {
+	addGraphEntities(data, args)
+}
+
+func StixPostProcessing(args map[string]interface{}) {
+	graph, _ := linkGraph(args)
+
+	WriteStix(graph, args["output"].(string), args["stix-bundle"].(string) == "computer")
+}
+
+// newStixUUID return a random (version 4) UUID, used by the objects without identifier contributing properties
+func newStixUUID() string {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	handleError(err)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// getStixID return the deterministic (version 5) identifier of a Cyber Observable from its identifier contributing properties
+func getStixID(objectType string, contributing map[string]interface{}) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	handleError(encoder.Encode(contributing))
+
+	h := sha1.New()
+	h.Write(stixNamespace)
+	h.Write(bytes.TrimSuffix(buffer.Bytes(), []byte("\n")))
+	b := h.Sum(nil)[:16]
+	b[6] = (b[6] & 0x0f) | 0x50
+	b[8] = (b[8] & 0x3f) | 0x80
+	return objectType + "--" + fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// newStixObservable create a Cyber Observable, its identifier is computed from the given properties
+// or random when the type has none (process, network-traffic)
+func newStixObservable(objectType string, properties stixObject, contributing ...string) stixObject {
+	object := stixObject{"type": objectType, "spec_version": "2.1"}
+	values := map[string]interface{}{}
+	for key, value := range properties {
+		object[key] = value
+	}
+	for _, key := range contributing {
+		if value, ok := properties[key]; ok {
+			values[key] = value
+		}
+	}
+	if len(contributing) == 0 {
+		object["id"] = objectType + "--" + newStixUUID()
+	} else {
+		object["id"] = getStixID(objectType, values)
+	}
+	return object
+}
+
+// getStixTime format a date with the millisecond precision of the STIX timestamps
+func getStixTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// setStixTime set a date property when the date is known
+func setStixTime(properties stixObject, name string, t time.Time) {
+	if !t.IsZero() {
+		properties[name] = getStixTime(t)
+	}
+}
+
+// getStixRelationshipType convert a relationship type to the lowercase and hyphenated STIX form (MEMORY_ACCESS -> memory-access, AddedTo -> added-to)
+func getStixRelationshipType(relType string) string {
+	var b strings.Builder
+	runes := []rune(relType)
+	for i, r := range runes {
+		if r == '_' {
+			b.WriteRune('-')
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(runes[i-1]) {
+			b.WriteRune('-')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func newStixIPAddress(ip string) stixObject {
+	if strings.Contains(ip, ":") {
+		return newStixObservable("ipv6-addr", stixObject{"value": ip}, "value")
+	}
+	return newStixObservable("ipv4-addr", stixObject{"value": ip}, "value")
+}
+
+// splitStixPath split a Windows or Unix path in its directory and its name
+func splitStixPath(path string) (string, string) {
+	i := strings.LastIndexAny(path, "\\/")
+	if i < 0 {
+		return "", path
+	}
+	return path[:i], path[i+1:]
+}
+
+// newStixFile create a file observable and its directory, the file is the last object
+func newStixFile(fullpath string, name string, hashes map[string]interface{}, properties stixObject) []stixObject {
+	var objects []stixObject
+	directory, filename := splitStixPath(fullpath)
+	if name == "" {
+		name = filename
+	}
+	if name == "" && len(hashes) == 0 {
+		return nil
+	}
+
+	if properties == nil {
+		properties = stixObject{}
+	}
+	if name != "" {
+		properties["name"] = name
+	}
+	if len(hashes) > 0 {
+		properties["hashes"] = hashes
+	}
+	if directory != "" {
+		parent := newStixObservable("directory", stixObject{"path": directory}, "path")
+		objects = append(objects, parent)
+		properties["parent_directory_ref"] = parent["id"]
+	}
+	return append(objects, newStixObservable("file", properties, "hashes", "name", "extension", "parent_directory_ref"))
+}
+
+// getStixObjects convert a node to its Cyber Observables, the first object represents the node
+// and the next ones are the objects it references. Nodes without STIX equivalent return nothing.
+func getStixObjects(n Node) []stixObject {
+	var objects []stixObject
+
+	switch e := n.Entity.(type) {
+	case File:
+		hashes := map[string]interface{}{}
+		if e.Sha1 != "" {
+			hashes["SHA-1"] = strings.ToLower(e.Sha1)
+		}
+		properties := stixObject{}
+		if e.Size > 0 {
+			properties["size"] = e.Size
+		}
+		if e.Hash != "" {
+			properties["x_imphash"] = e.Hash
+		}
+		setStixTime(properties, "ctime", e.CreationTime)
+		setStixTime(properties, "mtime", e.ModificationTime)
+		setStixTime(properties, "atime", e.AccessTime)
+		files := newStixFile(e.FullPath, e.Filename, hashes, properties)
+		if len(files) == 0 {
+			return nil
+		}
+		objects = append(objects, files[len(files)-1])
+		objects = append(objects, files[:len(files)-1]...)
+		break
+	case Process:
+		properties := stixObject{}
+		if e.PID > 0 {
+			properties["pid"] = e.PID
+		}
+		if e.Commandline != "" {
+			properties["command_line"] = e.Commandline
+		}
+		setStixTime(properties, "created_time", e.CreatedTime)
+
+		hashes := map[string]interface{}{}
+		if e.Sha256Hash != "" {
+			hashes["SHA-256"] = strings.ToLower(e.Sha256Hash)
+		}
+		image := newStixFile(e.FullPath, e.Filename, hashes, nil)
+		if len(image) > 0 {
+			properties["image_ref"] = image[len(image)-1]["id"]
+		}
+		objects = append(objects, newStixObservable("process", properties))
+		objects = append(objects, image...)
+		break
+	case User:
+		properties := stixObject{}
+		if e.Username != "" {
+			properties["account_login"] = e.Username
+		}
+		if e.FullName != "" {
+			properties["display_name"] = e.FullName
+		}
+		switch {
+		case e.SID != "":
+			properties["user_id"] = e.SID
+			break
+		case e.Username != "":
+			properties["user_id"] = e.Username
+			break
+		case e.FullName != "":
+			properties["user_id"] = e.FullName
+			break
+		default:
+			return nil
+		}
+		setStixTime(properties, "credential_last_changed", e.LastPasswordChange)
+		objects = append(objects, newStixObservable("user-account", properties, "account_type", "user_id", "account_login"))
+		break
+	case Host:
+		var domain stixObject
+		if e.Domain != "" {
+			domain = newStixObservable("domain-name", stixObject{"value": e.Domain}, "value")
+		}
+		if e.IP == "" {
+			if domain == nil {
+				return nil
+			}
+			return []stixObject{domain}
+		}
+		ip := newStixIPAddress(e.IP)
+		objects = append(objects, ip)
+		if domain != nil {
+			domain["resolves_to_refs"] = []interface{}{ip["id"]}
+			objects = append(objects, domain)
+		}
+		break
+	case Connection:
+		properties := stixObject{}
+		protocols := []string{"ipv4"}
+		if strings.Contains(e.SourceIP+e.DestinationIP, ":") {
+			protocols[0] = "ipv6"
+		}
+		if e.Protocol != "" {
+			protocols = append(protocols, strings.ToLower(e.Protocol))
+		}
+		properties["protocols"] = protocols
+		setStixTime(properties, "start", e.Date)
+		if e.SourcePort > 0 && e.SourcePort <= 65535 {
+			properties["src_port"] = e.SourcePort
+		}
+		if e.DestinationPort > 0 && e.DestinationPort <= 65535 {
+			properties["dst_port"] = e.DestinationPort
+		}
+
+		var addresses []stixObject
+		if e.SourceIP != "" {
+			source := newStixIPAddress(e.SourceIP)
+			properties["src_ref"] = source["id"]
+			addresses = append(addresses, source)
+		}
+		if e.DestinationIP != "" {
+			destination := newStixIPAddress(e.DestinationIP)
+			properties["dst_ref"] = destination["id"]
+			addresses = append(addresses, destination)
+		}
+		if len(addresses) == 0 {
+			return nil
+		}
+		objects = append(objects, newStixObservable("network-traffic", properties))
+		objects = append(objects, addresses...)
+		break
+	case Domain:
+		if e.Name == "" {
+			return nil
+		}
+		objects = append(objects, newStixObservable("domain-name", stixObject{"value": e.Name}, "value"))
+		break
+	case WebHistory:
+		if e.Url == "" {
+			return nil
+		}
+		objects = append(objects, newStixObservable("url", stixObject{"value": e.Url}, "value"))
+		break
+	case Registry:
+		if e.Path == "" {
+			return nil
+		}
+		properties := stixObject{"key": e.Path}
+		setStixTime(properties, "modified_time", e.LastModificationTime)
+		objects = append(objects, newStixObservable("windows-registry-key", properties, "key", "values"))
+		break
+	}
+
+	if len(objects) > 0 {
+		objects[0]["x_plaso2graph_id"] = n.ID
+	}
+	return objects
+}
+
+// getNodeComputer return the computer of a node, empty for the nodes shared by the computers (users, domains, hosts)
+func getNodeComputer(n Node) string {
+	for _, p := range getProperties(n.Entity) {
+		if p.Name == "computer" {
+			if computer, ok := p.Value.(string); ok {
+				return computer
+			}
+		}
+	}
+	return ""
+}
+
+// newStixRelationship create the SRO of a relationship between two observables
+func newStixRelationship(r Relationship, source string, target string, created string) stixObject {
+	object := stixObject{
+		"type":              "relationship",
+		"spec_version":      "2.1",
+		"id":                "relationship--" + newStixUUID(),
+		"created":           created,
+		"modified":          created,
+		"relationship_type": getStixRelationshipType(r.Type),
+		"source_ref":        source,
+		"target_ref":        target,
+	}
+	for _, key := range []string{"date", "first_connected", "start"} {
+		if t, ok := r.Properties[key].(time.Time); ok && !t.IsZero() {
+			object["start_time"] = getStixTime(t)
+			break
+		}
+	}
+	return object
+}
+
+func writeStixBundle(path string, objects []stixObject) {
+	file, err := os.Create(path)
+	handleError(err)
+
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	handleError(encoder.Encode(stixObject{"type": "bundle", "id": "bundle--" + newStixUUID(), "objects": objects}))
+	handleError(file.Close())
+}
+
+func WriteStix(graph *Graph, output string, perComputer bool) {
+	created := getStixTime(time.Now())
+
+	// Observables of every node, the first one represents the node
+	nodeObjects := map[string][]stixObject{}
+	for _, n := range graph.Nodes {
+		if objects := getStixObjects(n); len(objects) > 0 {
+			nodeObjects[n.ID] = objects
+		}
+	}
+
+	var relationships []Relationship
+	var sros []stixObject
+	for _, r := range graph.Relationships {
+		source, ok := nodeObjects[r.StartID]
+		if !ok {
+			continue
+		}
+		target, ok := nodeObjects[r.EndID]
+		if !ok || source[0]["id"] == target[0]["id"] {
+			continue
+		}
+		relationships = append(relationships, r)
+		sros = append(sros, newStixRelationship(r, source[0]["id"].(string), target[0]["id"].(string), created))
+	}
+
+	// bundles of the computers, the nodes without computer are written with the nodes they are related to
+	bundles := map[string][]string{}
+	var names []string
+	for _, n := range graph.Nodes {
+		if _, ok := nodeObjects[n.ID]; !ok {
+			continue
+		}
+		computer := ""
+		if perComputer {
+			computer = getNodeComputer(n)
+		}
+		if _, ok := bundles[computer]; !ok {
+			names = append(names, computer)
+		}
+		bundles[computer] = append(bundles[computer], n.ID)
+	}
+
+	for _, name := range names {
+		if perComputer && name == "" {
+			continue
+		}
+		nodes := map[string]bool{}
+		for _, id := range bundles[name] {
+			nodes[id] = true
+		}
+
+		var objects []stixObject
+		seen := map[interface{}]bool{}
+		addNode := func(id string) {
+			for _, object := range nodeObjects[id] {
+				if !seen[object["id"]] {
+					seen[object["id"]] = true
+					objects = append(objects, object)
+				}
+			}
+		}
+		for _, id := range bundles[name] {
+			addNode(id)
+		}
+
+		for i, r := range relationships {
+			if !nodes[r.StartID] && !nodes[r.EndID] {
+				continue
+			}
+			addNode(r.StartID)
+			addNode(r.EndID)
+			objects = append(objects, sros[i])
+		}
+
+		filename := "bundle.json"
+		if perComputer {
+			filename = "bundle_" + stixFilenameRegexp.ReplaceAllString(name, "_") + ".json"
+		}
+		fmt.Printf("Writing %s (%d objects)...\n", filename, len(objects))
+		writeStixBundle(filepath.Join(output, filename), objects)
+	}
+}
@@ -0,0 +1,348 @@
+package Extractor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	. "plaso2graph/master/src/Entity"
+	. "plaso2graph/master/src/Linker"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The Timesketch extractor writes timeline.jsonl, one line per date of every node (process creation, file times, visits, logons...)
+// with the message, datetime, timestamp and timestamp_desc fields of Timesketch and the properties of the node.
+// The lines are enriched with the graph: process_chain (parent processes), logon session (logon_id, logon_time, logon_user)
+// and user_sid / user_name of the user linked to the node.
+
+// timesketchReserved are the Timesketch fields, a property with the same name is written as "entity_<name>"
+var timesketchReserved = map[string]bool{"message": true, "datetime": true, "timestamp": true, "timestamp_desc": true, "data_type": true}
+
+// timesketchEvent is a line of the timeline, sorted by timestamp
+type timesketchEvent struct {
+	Timestamp int64
+	Fields    map[string]interface{}
+}
+
+// timesketchContext is the graph context of the nodes, computed from the relationships
+type timesketchContext struct {
+	graph    *Graph
+	index    map[string]int
+	parents  map[string]string   // Process -> parent Process (EXECUTE)
+	executed map[string]string   // ScriptBlock, Command... -> Process which executed it
+	users    map[string]string   // node -> User (BY, RUN_AS)
+	logons   map[string][]string // computer|logon id -> Logon Events
+}
+
+func InitializeTimesketchExtractor(args map[string]interface{}) map[string]interface{} {
+	return initializeGraphExtractor(args)
+}
+
+func TimesketchExtract(data []interface{}, args map[string]interface{}) {
+	addGraphEntities(data, args)
+}
+
+func TimesketchPostProcessing(args map[string]interface{}) {
+	graph, _ := linkGraph(args)
+
+	fmt.Println("Writing timeline.jsonl...")
+	WriteTimesketch(graph, filepath.Join(args["output"].(string), "timeline.jsonl"))
+}
+
+// parseLogonID parse the hexadecimal logon id of the Event Logs (0x3e7), 0 when there is none
+func parseLogonID(s string) int {
+	i, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		return 0
+	}
+	return int(i)
+}
+
+func logonKey(computer string, logonID int) string {
+	return computer + "|" + strconv.Itoa(logonID)
+}
+
+func newTimesketchContext(graph *Graph) *timesketchContext {
+	c := &timesketchContext{
+		graph:    graph,
+		index:    map[string]int{},
+		parents:  map[string]string{},
+		executed: map[string]string{},
+		users:    map[string]string{},
+		logons:   map[string][]string{},
+	}
+
+	for i, n := range graph.Nodes {
+		c.index[n.ID] = i
+		if e, ok := n.Entity.(Event); ok && e.Type == "Logon" {
+			if logonID := parseLogonID(e.UserSourceLogonID); logonID != 0 {
+				key := logonKey(e.Computer, logonID)
+				c.logons[key] = append(c.logons[key], n.ID)
+			}
+		}
+	}
+
+	for _, r := range graph.Relationships {
+		start := GetLabelFromID(r.StartID)
+		switch r.Type {
+		case "EXECUTE":
+			if start != "Process" {
+				break
+			}
+			if GetLabelFromID(r.EndID) == "Process" {
+				c.parents[r.EndID] = r.StartID
+			} else if _, ok := c.executed[r.EndID]; !ok {
+				c.executed[r.EndID] = r.StartID
+			}
+			break
+		case "BY", "RUN_AS":
+			if _, ok := c.users[r.EndID]; !ok && start == "User" {
+				c.users[r.EndID] = r.StartID
+			}
+			break
+		}
+	}
+	return c
+}
+
+func (c *timesketchContext) getNode(id string) Node {
+	return c.graph.Nodes[c.index[id]]
+}
+
+// getProcessChain return the names of the parents of a process and of the process, from the oldest one
+func (c *timesketchContext) getProcessChain(id string) string {
+	var chain []string
+	seen := map[string]bool{}
+	for id != "" && !seen[id] {
+		seen[id] = true
+		p := c.getNode(id).Entity.(Process)
+		name := p.Filename
+		if name == "" {
+			name = p.FullPath
+		}
+		chain = append([]string{name}, chain...)
+		id = c.parents[id]
+	}
+	return strings.Join(chain, " > ")
+}
+
+// getLogon return the Logon Event of the session of a process, the last one before the process when the logon id was reused
+func (c *timesketchContext) getLogon(p Process) (Event, bool) {
+	var logon Event
+	found := false
+	for _, id := range c.logons[logonKey(p.Computer, p.LogonID)] {
+		e := c.getNode(id).Entity.(Event)
+		if (!p.CreatedTime.IsZero() && e.Date.After(p.CreatedTime)) || (found && e.Date.Before(logon.Date)) {
+			continue
+		}
+		logon = e
+		found = true
+	}
+	return logon, found
+}
+
+// addContext add the graph context of a node to the fields of its lines
+func (c *timesketchContext) addContext(n Node, fields map[string]interface{}) {
+	process := ""
+	switch e := n.Entity.(type) {
+	case Process:
+		process = n.ID
+		if e.LogonID != 0 {
+			fields["logon_id"] = fmt.Sprintf("0x%x", e.LogonID)
+			if logon, ok := c.getLogon(e); ok {
+				if !logon.Date.IsZero() {
+					fields["logon_time"] = logon.Date.UTC().Format(time.RFC3339Nano)
+				}
+				fields["logon_user"] = logon.UserSource
+				if logon.UserSourceDomain != "" {
+					fields["logon_user"] = logon.UserSourceDomain + "\\" + logon.UserSource
+				}
+			}
+		}
+		break
+	case Event:
+		if e.Type == "Logon" || e.Type == "Logoff" {
+			if logonID := parseLogonID(e.UserSourceLogonID); logonID != 0 {
+				fields["logon_id"] = fmt.Sprintf("0x%x", logonID)
+			}
+		}
+		break
+	default:
+		process = c.executed[n.ID]
+		break
+	}
+
+	if process != "" {
+		fields["process_chain"] = c.getProcessChain(process)
+	}
+
+	if user, ok := c.users[n.ID]; ok {
+		u := c.getNode(user).Entity.(User)
+		if u.SID != "" {
+			fields["user_sid"] = u.SID
+		}
+		if u.Username != "" {
+			fields["user_name"] = u.Username
+		} else if u.FullName != "" {
+			fields["user_name"] = u.FullName
+		}
+	}
+}
+
+// getTimestampDesc return the description of a date property (creation_time -> Creation Time),
+// the "date" of the entities with a timestamp_desc (File) uses it
+func getTimestampDesc(name string, properties []property) string {
+	if name == "date" {
+		for _, p := range properties {
+			if p.Name == "timestamp_desc" && formatValue(p.Value) != "" {
+				return formatValue(p.Value)
+			}
+		}
+		return "Event Time"
+	}
+
+	words := strings.Split(name, "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// joinMessage join the non-empty parts of a message
+func joinMessage(parts ...string) string {
+	var message []string
+	for _, part := range parts {
+		if part != "" {
+			message = append(message, part)
+		}
+	}
+	return strings.Join(message, " ")
+}
+
+// getTimesketchMessage return the message of a node: a summary for the entities without a title,
+// otherwise its title or command line, or the name used by the graph extractors
+func getTimesketchMessage(n Node, properties []property) string {
+	message := ""
+	switch e := n.Entity.(type) {
+	case Connection:
+		message = e.SourceIP + ":" + strconv.Itoa(e.SourcePort) + " -> " + e.DestinationIP + ":" + strconv.Itoa(e.DestinationPort)
+		if e.Protocol != "" {
+			message += " (" + e.Protocol + ")"
+		}
+		break
+	case DnsQuery:
+		message = e.Query
+		if e.QueryType != "" {
+			message += " (" + e.QueryType + ")"
+		}
+		if len(e.Answers) > 0 {
+			message += " -> " + strings.Join(e.Answers, ", ")
+		}
+		break
+	case Device:
+		message = joinMessage(e.Vendor, e.Product, e.Serial, e.VolumeGUID, e.DriveLetter, e.VolumeLabel)
+		break
+	case Network:
+		message = joinMessage(e.InterfaceType, "interface "+strconv.Itoa(e.InterfaceLUID), "profile "+strconv.Itoa(e.ProfileID))
+		break
+	case ScheduledTask:
+		message = joinMessage(e.Application, e.Trigger)
+		break
+	case ScriptBlock:
+		message = joinMessage(e.Path, e.ScriptBlockID)
+		break
+	case Registry:
+		message = e.Path
+		break
+	}
+	if message != "" {
+		return "[" + n.Label + "] " + message
+	}
+
+	for _, name := range []string{"title", "commandline"} {
+		for _, p := range properties {
+			if p.Name == name && formatValue(p.Value) != "" {
+				return "[" + n.Label + "] " + formatValue(p.Value)
+			}
+		}
+	}
+	return "[" + n.Label + "] " + getNodeTitle(n, properties)
+}
+
+// getTimesketchEvents return a line for every date of a node
+func getTimesketchEvents(n Node, c *timesketchContext) []timesketchEvent {
+	properties := getProperties(n.Entity)
+
+	fields := map[string]interface{}{
+		"message":        getTimesketchMessage(n, properties),
+		"data_type":      "plaso2graph:" + strings.ToLower(n.Label),
+		"label":          n.Label,
+		"plaso2graph_id": n.ID,
+	}
+	var dates []property
+	for _, p := range properties {
+		if t, ok := p.Value.(time.Time); ok {
+			if !t.IsZero() {
+				dates = append(dates, p)
+			}
+			continue
+		}
+		if p.Name == "evidence" || p.Name == "timestamp" || p.Name == "timestamp_desc" || formatValue(p.Value) == "" {
+			continue
+		}
+		name := p.Name
+		if timesketchReserved[name] || name == "label" || name == "plaso2graph_id" {
+			name = "entity_" + name
+		}
+		fields[name] = p.Value
+	}
+	if len(dates) == 0 {
+		return nil
+	}
+	c.addContext(n, fields)
+
+	var events []timesketchEvent
+	for _, date := range dates {
+		t := date.Value.(time.Time).UTC()
+		event := timesketchEvent{Timestamp: t.UnixNano() / 1000, Fields: map[string]interface{}{}}
+		for key, value := range fields {
+			event.Fields[key] = value
+		}
+		event.Fields["datetime"] = t.Format(time.RFC3339Nano)
+		event.Fields["timestamp"] = event.Timestamp
+		event.Fields["timestamp_desc"] = getTimestampDesc(date.Name, properties)
+		events = append(events, event)
+	}
+	return events
+}
+
+func WriteTimesketch(graph *Graph, path string) {
+	c := newTimesketchContext(graph)
+
+	var events []timesketchEvent
+	for _, n := range graph.Nodes {
+		events = append(events, getTimesketchEvents(n, c)...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
+
+	file, err := os.Create(path)
+	handleError(err)
+	w := bufio.NewWriter(file)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, event := range events {
+		handleError(encoder.Encode(event.Fields))
+	}
+
+	handleError(w.Flush())
+	handleError(file.Close())
+}
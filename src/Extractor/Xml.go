@@ -2,149 +2,97 @@ package Extractor
 
 import (
 	"encoding/xml"
-	"log"
+	"fmt"
 	"os"
-	. "plaso2graph/master/src/Entity"
+	"path/filepath"
+	. "plaso2graph/master/src/Linker"
+	"strings"
 )
 
-func InitializeXmlExtractor(args map[string]interface{}) map[string]interface{} {
-	var err error
-	if args["output"] == nil {
-		log.Fatal("Output directory is required")
-	}
+// The XML extractor writes case.xml, a <Case> document with the <Nodes> (an element per node, named after its label,
+// with an element per property) and the <Relationships> between their ids. It is valid against the XSD written next to it.
 
-	output := args["output"].(string)
-	args["output_files"] = map[string]*os.File{}
-	args["output_files"].(map[string]*os.File)["process"], err = os.OpenFile(output+"/process.xml", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	handleError(err)
-	args["output_files"].(map[string]*os.File)["user"], err = os.OpenFile(output+"/user.xml", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	handleError(err)
-	args["output_files"].(map[string]*os.File)["file"], err = os.OpenFile(output+"/file.xml", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	handleError(err)
-	args["output_files"].(map[string]*os.File)["task"], err = os.OpenFile(output+"/task.xml", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	handleError(err)
-	args["output_files"].(map[string]*os.File)["computer"], err = os.OpenFile(output+"/computer.xml", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	handleError(err)
-	args["output_files"].(map[string]*os.File)["domain"], err = os.OpenFile(output+"/domain.xml", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	handleError(err)
-	args["output_files"].(map[string]*os.File)["webhistory"], err = os.OpenFile(output+"/webhistory.xml", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	handleError(err)
-	return args
+// xmlRelationship is a relationship computed by the Linker, its properties are sorted by name
+type xmlRelationship struct {
+	XMLName    xml.Name      `xml:"Relationship"`
+	Type       string        `xml:"type,attr"`
+	StartID    string        `xml:"start_id,attr"`
+	EndID      string        `xml:"end_id,attr"`
+	Properties []xmlProperty `xml:"Property"`
 }
 
-func XmlExtract(data []interface{}, args map[string]interface{}) {
-	if args["output"] == nil {
-		log.Fatal("Output directory is required")
-	}
-
-	if args["verbose"] == nil {
-		args["verbose"] = false
-	}
-
-	for _, d := range data {
-		switch d.(type) {
-		case []Process:
-			InsertProcessesXml(d.([]Process), args)
-			break
-		case []User:
-			InsertUsersXml(d.([]User), args)
-			break
-		case []File:
-			InsertFilesXml(d.([]File), args)
-			break
-		case []ScheduledTask:
-			InsertTasksXml(d.([]ScheduledTask), args)
-			break
-		case []Computer:
-			InsertComputersXml(d.([]Computer), args)
-			break
-		case []Domain:
-			InsertDomainsXml(d.([]Domain), args)
-			break
-		case []WebHistory:
-			InsertWebHistoryXml(d.([]WebHistory), args)
-			break
-		}
-	}
-
+type xmlProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
 }
 
-func InsertProcessesXml(processes []Process, args map[string]interface{}) {
-	file := args["output_files"].(map[string]*os.File)["process"]
-
-	for _, p := range processes {
-		str, err := xml.Marshal(p)
-		handleError(err)
-		_, err = file.WriteString(string(str) + "\n")
-		handleError(err)
-	}
-}
+func InitializeXmlExtractor(args map[string]interface{}) map[string]interface{} {
+	initializeStreamingGraphExtractor(args)
+	output := args["output"].(string)
 
-func InsertUsersXml(users []User, args map[string]interface{}) {
-	file := args["output_files"].(map[string]*os.File)["user"]
+	WriteXsd(filepath.Join(output, getSchemaFilename(".xsd")))
 
-	for _, u := range users {
-		str, err := xml.Marshal(u)
-		handleError(err)
-		_, err = file.WriteString(string(str) + "\n")
-		handleError(err)
-	}
-}
-
-func InsertFilesXml(files []File, args map[string]interface{}) {
-	file := args["output_files"].(map[string]*os.File)["file"]
+	file, err := os.OpenFile(filepath.Join(output, "case.xml"), os.O_APPEND|os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	handleError(err)
+	args["output_file"] = file
+	writeXml(args, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	writeXml(args, "<Case xmlns=\""+caseNamespace+"\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\" "+
+		"xsi:schemaLocation=\""+caseNamespace+" "+getSchemaFilename(".xsd")+"\" version=\""+caseSchemaVersion+"\">\n  <Nodes>\n")
 
-	for _, f := range files {
-		str, err := xml.Marshal(f)
-		handleError(err)
-		_, err = file.WriteString(string(str) + "\n")
-		handleError(err)
-	}
+	return args
 }
 
-func InsertTasksXml(tasks []ScheduledTask, args map[string]interface{}) {
-	file := args["output_files"].(map[string]*os.File)["file"]
-
-	for _, t := range tasks {
-		str, err := xml.Marshal(t)
-		handleError(err)
-		_, err = file.WriteString(string(str) + "\n")
-		handleError(err)
+func XmlExtract(data []interface{}, args map[string]interface{}) {
+	for _, n := range streamGraphEntities(data, args) {
+		InsertNodeXml(n, args)
 	}
 
 }
 
-func InsertComputersXml(computers []Computer, args map[string]interface{}) {
-	file := args["output_files"].(map[string]*os.File)["computer"]
+func XmlPostProcessing(args map[string]interface{}) {
+	graph, linked := linkStreamedGraph(args)
 
-	for _, c := range computers {
-		str, err := xml.Marshal(c)
-		handleError(err)
-		_, err = file.WriteString(string(str) + "\n")
-		handleError(err)
+	for _, n := range linked {
+		InsertNodeXml(n, args)
 	}
+	writeXml(args, "  </Nodes>\n  <Relationships>\n")
+
+	fmt.Println("Writing relationships...")
+	keys := getRelationshipKeys(graph.Relationships)
+	for _, r := range graph.Relationships {
+		relationship := xmlRelationship{Type: r.Type, StartID: r.StartID, EndID: r.EndID}
+		for _, key := range keys[r.Type] {
+			if value, ok := r.Properties[key]; ok {
+				relationship.Properties = append(relationship.Properties, xmlProperty{key, formatValue(value)})
+			}
+		}
 
-}
-
-func InsertDomainsXml(domains []Domain, args map[string]interface{}) {
-	file := args["output_files"].(map[string]*os.File)["domain"]
-
-	for _, d := range domains {
-		str, err := xml.Marshal(d)
-		handleError(err)
-		_, err = file.WriteString(string(str) + "\n")
+		str, err := xml.Marshal(relationship)
 		handleError(err)
+		writeXml(args, "    "+string(str)+"\n")
 	}
+	writeXml(args, "  </Relationships>\n</Case>\n")
 
+	handleError(args["output_file"].(*os.File).Close())
 }
 
-func InsertWebHistoryXml(webhistory []WebHistory, args map[string]interface{}) {
-	file := args["output_files"].(map[string]*os.File)["webhistory"]
+// writeXml write a part of case.xml in a single write, so the nodes of concurrent extractions are not mixed
+func writeXml(args map[string]interface{}, str string) {
+	_, err := args["output_file"].(*os.File).WriteString(str)
+	handleError(err)
+}
 
-	for _, w := range webhistory {
-		str, err := xml.Marshal(w)
-		handleError(err)
-		_, err = file.WriteString(string(str) + "\n")
-		handleError(err)
+// InsertNodeXml write a node as the element of its label with its id attribute, unknown dates are omitted
+func InsertNodeXml(n Node, args map[string]interface{}) {
+	var b strings.Builder
+	b.WriteString("    <" + n.Label + " id=\"" + n.ID + "\">")
+	for _, p := range getProperties(n.Entity) {
+		if getXsdType(p.Value) == "xs:dateTime" && formatValue(p.Value) == "" {
+			continue
+		}
+		b.WriteString("<" + p.Name + ">" + getXmlValue(p.Value) + "</" + p.Name + ">")
 	}
+	b.WriteString("</" + n.Label + ">\n")
+
+	writeXml(args, b.String())
 }
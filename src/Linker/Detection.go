@@ -0,0 +1,209 @@
+package Linker
+
+import (
+	. "plaso2graph/master/src/Entity"
+	"sort"
+	"time"
+)
+
+// linkSecurityControls link the changes of Windows Defender and Windows Firewall to the User and the Process that made them,
+// and the Defender detections to the File and the Processes they name
+func (g *Graph) linkSecurityControls() {
+	sids := map[string][]string{}
+	processes := map[string][]int{}
+	for i, n := range g.Nodes {
+		switch e := n.Entity.(type) {
+		case User:
+			if e.SID != "" {
+				sids[e.SID] = append(sids[e.SID], n.ID)
+			}
+			break
+		case Process:
+			key := fileKey(e.Computer, e.FullPath)
+			processes[key] = append(processes[key], i)
+			break
+		}
+	}
+
+	// getStartedProcesses return the Processes of this path started before the timestamp
+	getStartedProcesses := func(computer string, fullpath string, timestamp int) []string {
+		var ids []string
+		for _, i := range processes[fileKey(computer, fullpath)] {
+			if g.Nodes[i].Entity.(Process).Timestamp <= timestamp {
+				ids = append(ids, g.Nodes[i].ID)
+			}
+		}
+		return ids
+	}
+
+	for _, n := range g.Nodes {
+		switch e := n.Entity.(type) {
+		case SecurityControlChange:
+			// Firewall events only give the SID of the user who performed the change
+			if e.UserSID != "" {
+				for _, id := range sids[e.UserSID] {
+					g.addRelationship("BY", id, n.ID, nil)
+				}
+			}
+			if e.ProcessName != "" {
+				for _, id := range getStartedProcesses(e.Computer, e.ProcessName, e.Timestamp) {
+					g.addRelationship("CHANGE", id, n.ID, map[string]interface{}{
+						"timestamp": e.Timestamp,
+						"date":      e.Date,
+					})
+				}
+			}
+			break
+		case Detection:
+			if e.FullPath != "" {
+				file := g.getFile(File{
+					FullPath:      e.FullPath,
+					Filename:      e.Filename,
+					Timestamp:     e.Timestamp,
+					Date:          e.Date,
+					TimestampDesc: "Detection Time",
+					Computer:      e.Computer,
+				})
+				g.addRelationship("DETECTED", n.ID, file, nil)
+
+				for _, i := range processes[fileKey(e.Computer, e.FullPath)] {
+					g.addRelationship("DETECTED", n.ID, g.Nodes[i].ID, nil)
+				}
+			}
+			if e.ProcessName != "" {
+				for _, id := range getStartedProcesses(e.Computer, e.ProcessName, e.Timestamp) {
+					g.addRelationship("TRIGGER", id, n.ID, map[string]interface{}{
+						"timestamp": e.Timestamp,
+						"date":      e.Date,
+					})
+				}
+			}
+			break
+		}
+	}
+}
+
+// linkAlerts Alert -[DETECTED]-> the Events and Processes built from the detected evtx record
+// (record IDs are only unique per channel and computer)
+func (g *Graph) linkAlerts() {
+	type recordKey struct {
+		computer string
+		channel  string
+		recordID int
+	}
+
+	records := map[recordKey][]string{}
+	for _, n := range g.Nodes {
+		switch entity := n.Entity.(type) {
+		case Event:
+			if entity.RecordID != 0 {
+				key := recordKey{entity.Computer, entity.Channel, entity.RecordID}
+				records[key] = append(records[key], n.ID)
+			}
+			break
+		case Process:
+			if entity.RecordID != 0 {
+				key := recordKey{entity.Computer, entity.Channel, entity.RecordID}
+				records[key] = append(records[key], n.ID)
+			}
+			break
+		}
+	}
+
+	for _, n := range g.Nodes {
+		a, ok := n.Entity.(Alert)
+		if !ok || a.RecordID == 0 {
+			continue
+		}
+		for _, id := range records[recordKey{a.Computer, a.Channel, a.RecordID}] {
+			g.addRelationship("DETECTED", n.ID, id, nil)
+		}
+	}
+}
+
+// linkLogGaps compute the log coverage gaps of the AntiForensics and annotate the Computers with them:
+// a cleared log is missing every record since the previous clear of the same channel,
+// removing auditing of a subcategory stops logging until auditing is added back
+func (g *Graph) linkLogGaps() {
+	clears := map[string][]int{}
+	policies := map[string][]int{}
+	var afs []int
+	for i, n := range g.Nodes {
+		af, ok := n.Entity.(AntiForensics)
+		if !ok {
+			continue
+		}
+		afs = append(afs, i)
+		switch af.Type {
+		case "Log Cleared":
+			clears[af.Computer+"|"+af.Channel] = append(clears[af.Computer+"|"+af.Channel], i)
+			break
+		case "Audit Policy Changed":
+			policies[af.Computer+"|"+af.Setting] = append(policies[af.Computer+"|"+af.Setting], i)
+			break
+		}
+	}
+
+	byTimestamp := func(indexes []int) {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return g.Nodes[indexes[a]].Entity.(AntiForensics).Timestamp < g.Nodes[indexes[b]].Entity.(AntiForensics).Timestamp
+		})
+	}
+
+	for _, indexes := range clears {
+		byTimestamp(indexes)
+		for k, i := range indexes {
+			af := g.Nodes[i].Entity.(AntiForensics)
+			af.CreatesGap, af.GapSource, af.GapEnd = true, af.Channel+" log cleared", af.Date
+			for j := k - 1; j >= 0; j-- {
+				if prev := g.Nodes[indexes[j]].Entity.(AntiForensics); prev.Timestamp < af.Timestamp {
+					af.GapStart = prev.Date
+					break
+				}
+			}
+			g.Nodes[i].Entity = af
+		}
+	}
+
+	for _, indexes := range policies {
+		byTimestamp(indexes)
+		for k, i := range indexes {
+			af := g.Nodes[i].Entity.(AntiForensics)
+			if !af.AuditingRemoved {
+				continue
+			}
+			af.CreatesGap, af.GapSource, af.GapStart = true, "Audit "+af.Setting+" "+af.Changes, af.Date
+			for _, j := range indexes[k+1:] {
+				if next := g.Nodes[j].Entity.(AntiForensics); next.AuditingAdded && next.Timestamp > af.Timestamp {
+					af.GapEnd = next.Date
+					break
+				}
+			}
+			g.Nodes[i].Entity = af
+		}
+	}
+
+	// The gaps of a Computer are sorted by date
+	byTimestamp(afs)
+	gaps := map[string][]string{}
+	for _, i := range afs {
+		af := g.Nodes[i].Entity.(AntiForensics)
+		if af.CreatesGap {
+			gaps[af.Computer] = append(gaps[af.Computer], af.GapSource+": "+formatGapDate(af.GapStart, "beginning")+" -> "+formatGapDate(af.GapEnd, "end"))
+		}
+	}
+	for i, n := range g.Nodes {
+		if c, ok := n.Entity.(Computer); ok && gaps[c.Name] != nil {
+			c.LogGaps = gaps[c.Name]
+			g.Nodes[i].Entity = c
+		}
+	}
+}
+
+// formatGapDate return the date of a gap boundary, or the default when it is unknown
+func formatGapDate(date time.Time, unknown string) string {
+	if date.IsZero() {
+		return unknown
+	}
+	return date.Format(time.RFC3339Nano)
+}
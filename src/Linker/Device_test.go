@@ -0,0 +1,53 @@
+package Linker
+
+import (
+	. "plaso2graph/master/src/Entity"
+	"testing"
+)
+
+func TestLinkDevicesStoredOn(t *testing.T) {
+	devices := []Device{
+		{Serial: "20043512345678", DriveLetter: "E:", FirstConnectedTimestamp: 10, LastConnectedTimestamp: 20, Computer: "pc"},
+		{Serial: "001CC0EC3450", DriveLetter: "E:", FirstConnectedTimestamp: 30, LastConnectedTimestamp: 40, Computer: "pc"},
+		{Serial: "AA00000000000001", DriveLetter: "E:", FirstConnectedTimestamp: 35, Computer: "pc"},
+	}
+
+	tests := []struct {
+		name   string
+		file   File
+		device int // Index of the expected device, -1 if none
+	}{
+		{"first device", File{FullPath: `E:\report.docx`, Timestamp: 15, Computer: "pc"}, 0},
+		{"second device", File{FullPath: `E:\report.docx`, Timestamp: 32, Computer: "pc"}, 1},
+		{"both connected, the last one wins", File{FullPath: `E:\report.docx`, Timestamp: 38, Computer: "pc"}, 2},
+		{"between two connections", File{FullPath: `E:\report.docx`, Timestamp: 25, Computer: "pc"}, -1},
+		{"before the first connection", File{FullPath: `E:\report.docx`, Timestamp: 5, Computer: "pc"}, -1},
+		{"another drive", File{FullPath: `F:\report.docx`, Timestamp: 15, Computer: "pc"}, -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGraph()
+			nodes := g.AddEntities([]interface{}{devices})
+			file := g.AddEntities([]interface{}{[]File{test.file}})[0]
+			g.Link()
+
+			var found []string
+			for _, r := range g.Relationships {
+				if r.Type == "STORED_ON" && r.StartID == file.ID {
+					found = append(found, r.EndID)
+				}
+			}
+
+			if test.device == -1 {
+				if len(found) != 0 {
+					t.Errorf("file stored on %v, want no device", found)
+				}
+				return
+			}
+			if len(found) != 1 || found[0] != nodes[test.device].ID {
+				t.Errorf("file stored on %v, want %s", found, nodes[test.device].ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package Linker
+
+import (
+	. "plaso2graph/master/src/Entity"
+	"strings"
+)
+
+// userEventTypes are the Events converted to actor -> target relationships between Users
+var userEventTypes = map[string]string{
+	"User Account Created":  "CREATE",
+	"User Account Deleted":  "DELETE",
+	"User Account Enabled":  "ENABLE",
+	"User Account Disabled": "DISABLE",
+	"User Account Changed":  "CHANGE",
+	"Logon":                 "LOGON",
+}
+
+// getEventUsers return the Users acting (destination user) and the Users targeted (source user) by an Event
+func (g *Graph) getEventUsers(e Event) ([]string, []string) {
+	return g.getUsers(e.UserDestination), g.getUsers(e.UserSource)
+}
+
+// linkEvents create the Files of the Sysmon events, link them to their Process and link the Users of the Events
+func (g *Graph) linkEvents() {
+	for _, n := range g.Nodes {
+		e, ok := n.Entity.(Event)
+		if !ok {
+			continue
+		}
+
+		switch e.Type {
+		case "File Created", "File Deleted":
+			file := File{
+				FullPath:  e.FullPath,
+				Filename:  e.Filename,
+				Extension: e.Extension,
+				Timestamp: e.Timestamp,
+				Date:      e.Date,
+				Computer:  e.Computer,
+			}
+			relType := "CREATE"
+			file.TimestampDesc = "Creation Time"
+			if e.Type == "File Deleted" {
+				relType = "DELETE"
+				file.TimestampDesc = "Deletion Time"
+			}
+			id := g.addNode(file)
+			for _, i := range g.processes[processKey{e.Computer, e.ProcessSourceId}] {
+				if g.Nodes[i].Entity.(Process).FullPath == e.ProcessSource {
+					g.addRelationship(relType, g.Nodes[i].ID, id, nil)
+				}
+			}
+			break
+		case "Raw Access Read", "Image Loaded":
+			id := g.addNode(File{FullPath: e.FullPath, Filename: e.Filename, Computer: e.Computer})
+			if p := g.getProcess(e.Computer, e.ProcessSourceId, e.ProcessSource, e.Timestamp); p != -1 {
+				g.addRelationship("LOAD", g.Nodes[p].ID, id, map[string]interface{}{
+					"timestamp": e.Timestamp,
+					"date":      e.Date,
+				})
+			}
+			break
+		case "Process's Memory Access":
+			source := g.getProcess(e.Computer, e.ProcessSourceId, e.ProcessSource, e.Timestamp)
+			target := g.getProcess(e.Computer, e.ProcessTargetId, e.ProcessTarget, e.Timestamp)
+			if source != -1 && target != -1 {
+				g.addRelationship("MEMORY_ACCESS", g.Nodes[source].ID, g.Nodes[target].ID, map[string]interface{}{
+					"timestamp": e.Timestamp,
+					"date":      e.Date,
+				})
+			}
+			break
+		}
+
+		actors, targets := g.getEventUsers(e)
+		for _, id := range actors {
+			g.addRelationship("ACTS", id, n.ID, nil)
+		}
+		for _, id := range targets {
+			g.addRelationship("ON", n.ID, id, nil)
+		}
+
+		if relType, ok := userEventTypes[e.Type]; ok {
+			properties := map[string]interface{}{
+				"timestamp": e.Timestamp,
+				"date":      e.Date,
+			}
+			for _, actor := range actors {
+				if e.Type == "Logon" && g.anonymous[actor] {
+					continue
+				}
+				for _, target := range targets {
+					g.addRelationship(relType, actor, target, properties)
+				}
+			}
+		}
+
+		if e.Type == "Logon" || e.Type == "Logoff" {
+			if computer, ok := g.computers[e.Computer]; ok {
+				for _, target := range targets {
+					g.addRelationship(strings.ToUpper(e.Type), target, computer, map[string]interface{}{
+						"timestamp": e.Timestamp,
+						"date":      e.Date,
+					})
+				}
+			}
+		}
+	}
+}
+
+// linkGroups Event -[ABOUT]-> Group, and the membership and state changes of the Groups
+func (g *Graph) linkGroups() {
+	groups := map[string][]string{}
+	for _, n := range g.Nodes {
+		if group, ok := n.Entity.(Group); ok {
+			key := group.Name + "|" + group.Domain
+			groups[key] = append(groups[key], n.ID)
+		}
+	}
+
+	for _, n := range g.Nodes {
+		e, ok := n.Entity.(Event)
+		if !ok || e.GroupName == "" {
+			continue
+		}
+
+		eventType := strings.ToLower(e.Type)
+		actors, targets := g.getEventUsers(e)
+		properties := map[string]interface{}{
+			"timestamp": e.Timestamp,
+			"date":      e.Date,
+		}
+
+		for _, group := range groups[e.GroupName+"|"+e.GroupDomain] {
+			g.addRelationship("ABOUT", n.ID, group, nil)
+
+			for _, actor := range actors {
+				if strings.Contains(eventType, "enable") {
+					g.addRelationship("ENABLE", actor, group, properties)
+				}
+				if strings.Contains(eventType, "disable") {
+					g.addRelationship("DISABLE", actor, group, properties)
+				}
+			}
+
+			// The members are only linked when the Event names who changed the group
+			if len(actors) == 0 {
+				continue
+			}
+			for _, target := range targets {
+				if strings.Contains(eventType, "added") {
+					g.addRelationship("AddedTo", target, group, properties)
+				}
+				if strings.Contains(eventType, "removed") {
+					g.addRelationship("RemovedFrom", target, group, properties)
+				}
+			}
+		}
+	}
+}
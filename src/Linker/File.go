@@ -0,0 +1,144 @@
+package Linker
+
+import (
+	. "plaso2graph/master/src/Entity"
+	"sort"
+	"strings"
+)
+
+// linkWebHistories WebHistory -[DOWNLOADED]-> File for the downloads and User -[COOKIE]-> Domain for the cookies
+func (g *Graph) linkWebHistories() {
+	for _, n := range g.Nodes {
+		switch e := n.Entity.(type) {
+		case WebHistory:
+			if e.Type != "Download" || e.DownloadPath == "" {
+				continue
+			}
+			parts := strings.Split(strings.ReplaceAll(e.DownloadPath, "/", "\\"), "\\")
+			file := g.getFile(File{FullPath: e.DownloadPath, Filename: parts[len(parts)-1], Computer: e.Computer})
+			g.addRelationship("DOWNLOADED", n.ID, file, map[string]interface{}{
+				"referrer":  e.Referrer,
+				"timestamp": e.Timestamp,
+				"date":      e.LastTimeVisited,
+			})
+			break
+		case Cookie:
+			users := g.getUsers(e.User)
+			if len(users) == 0 {
+				continue
+			}
+			domain := g.getDomain(e.Host)
+			for _, id := range users {
+				g.addRelationship("COOKIE", id, domain, map[string]interface{}{
+					"name":      e.Name,
+					"path":      e.Path,
+					"browser":   e.Browser,
+					"timestamp": e.Timestamp,
+					"date":      e.Date,
+				})
+			}
+			break
+		}
+	}
+}
+
+// linkFileAccesses convert the FileAccesses to User -[ACCESSED]-> File or Folder,
+// and the explorer searches (WordWheelQuery) to User -[SEARCHED]-> Computer
+func (g *Graph) linkFileAccesses() {
+	for _, n := range g.Nodes {
+		a, ok := n.Entity.(FileAccess)
+		if !ok {
+			continue
+		}
+		users := g.getUsers(a.User)
+		if len(users) == 0 {
+			continue
+		}
+
+		if a.Source == "WordWheelQuery" {
+			computer, ok := g.computers[a.Computer]
+			if !ok {
+				continue
+			}
+			for _, id := range users {
+				g.addRelationship("SEARCHED", id, computer, map[string]interface{}{
+					"term":      a.Filename,
+					"mru_order": a.MruOrder,
+					"timestamp": a.Timestamp,
+					"date":      a.Date,
+				})
+			}
+			continue
+		}
+
+		var target string
+		if a.IsFolder {
+			target = g.getFolder(Folder{FullPath: a.FullPath, Filename: a.Filename, Computer: a.Computer})
+		} else {
+			target = g.getFile(File{FullPath: a.FullPath, Filename: a.Filename, Computer: a.Computer})
+		}
+		for _, id := range users {
+			g.addRelationship("ACCESSED", id, target, map[string]interface{}{
+				"source":    a.Source,
+				"mru_order": a.MruOrder,
+				"timestamp": a.Timestamp,
+				"date":      a.Date,
+			})
+		}
+	}
+}
+
+// linkLinkFiles User -[OPENED]-> File for the Lnk and Jump Lists, File -[STORED_ON]-> Computer from the tracker machine ID
+func (g *Graph) linkLinkFiles() {
+	var names []string
+	for name := range g.computers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, n := range g.Nodes {
+		f, ok := n.Entity.(File)
+		if !ok {
+			continue
+		}
+
+		if f.LinkPath != "" {
+			for _, id := range g.getUsers(f.User) {
+				g.addRelationship("OPENED", id, n.ID, map[string]interface{}{
+					"timestamp": f.Timestamp,
+					"date":      f.Date,
+					"link_path": f.LinkPath,
+				})
+			}
+		}
+
+		if f.MachineID != "" {
+			for _, name := range names {
+				if isSameComputer(name, f.MachineID) {
+					g.addRelationship("STORED_ON", n.ID, g.computers[name], nil)
+				}
+			}
+		}
+	}
+}
+
+// linkProcessImages Process -[IMAGE]-> File described by Amcache (SHA1, size, publisher)
+func (g *Graph) linkProcessImages() {
+	processes := map[string][]string{}
+	for _, n := range g.Nodes {
+		if p, ok := n.Entity.(Process); ok {
+			key := fileKey(p.Computer, p.FullPath)
+			processes[key] = append(processes[key], n.ID)
+		}
+	}
+
+	for _, n := range g.Nodes {
+		f, ok := n.Entity.(File)
+		if !ok || f.Sha1 == "" {
+			continue
+		}
+		for _, id := range processes[fileKey(f.Computer, strings.ToLower(f.FullPath))] {
+			g.addRelationship("IMAGE", id, n.ID, nil)
+		}
+	}
+}
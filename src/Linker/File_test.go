@@ -0,0 +1,89 @@
+package Linker
+
+import (
+	. "plaso2graph/master/src/Entity"
+	"testing"
+)
+
+func TestLinkFileAccessesTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		accesses []FileAccess
+		files    []string // Filename of the File accessed by each FileAccess
+	}{
+		{
+			"same path",
+			[]FileAccess{
+				{FullPath: `C:\Users\bob\secret.docx`, Filename: "secret.docx", Source: "OpenSaveMRU"},
+				{FullPath: `C:\Users\bob\secret.docx`, Filename: "secret.docx", Source: "MRU"},
+			},
+			[]string{"secret.docx", "secret.docx"},
+		},
+		{
+			"filenames without path",
+			[]FileAccess{
+				{Filename: "secret.docx", Source: "RecentDocs"},
+				{Filename: "payroll.xlsx", Source: "RecentDocs"},
+				{Filename: "secret.docx", Source: "RecentDocs"},
+			},
+			[]string{"secret.docx", "payroll.xlsx", "secret.docx"},
+		},
+		{
+			"filename and path",
+			[]FileAccess{
+				{FullPath: `C:\Users\bob\secret.docx`, Filename: "secret.docx", Source: "OpenSaveMRU"},
+				{Filename: "secret.docx", Source: "RecentDocs"},
+			},
+			[]string{"secret.docx", "secret.docx"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var accesses []FileAccess
+			for _, a := range test.accesses {
+				a.User, a.Computer = "bob", "pc"
+				accesses = append(accesses, a)
+			}
+
+			g := NewGraph()
+			g.AddEntities([]interface{}{[]User{{FullName: "bob", Username: "bob"}}, accesses})
+			g.Link()
+
+			files := map[string]File{}
+			for _, n := range g.Nodes {
+				if f, ok := n.Entity.(File); ok {
+					files[n.ID] = f
+				}
+			}
+
+			var targets []string
+			ids := map[string]bool{}
+			for _, r := range g.Relationships {
+				if r.Type == "ACCESSED" {
+					targets = append(targets, files[r.EndID].Filename)
+					ids[r.EndID] = true
+				}
+			}
+
+			if len(targets) != len(test.files) {
+				t.Fatalf("ACCESSED %v, want %v", targets, test.files)
+			}
+			for i := range targets {
+				if targets[i] != test.files[i] {
+					t.Errorf("ACCESSED %v, want %v", targets, test.files)
+					break
+				}
+			}
+
+			// A File node per path, or per filename when the path is unknown
+			want := map[string]bool{}
+			for _, a := range test.accesses {
+				want[a.FullPath+"|"+a.Filename] = true
+			}
+			if len(ids) != len(want) {
+				t.Errorf("%d File nodes accessed, want %d", len(ids), len(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,434 @@
+package Linker
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	. "plaso2graph/master/src/Entity"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Contains the Graph which accumulates the entities extracted by batch and computes their relationships in Go.
+// Every extractor uses the same relationships, the Neo4j extractor creates them from the Relationship values.
+
+// Node is an entity of the case, identified by the case ID, its label and its index ("<case>:Process:42")
+type Node struct {
+	ID     string
+	Label  string
+	Entity interface{}
+}
+
+// Relationship is an edge between two nodes, the type and the properties are the ones of the Neo4j graph
+type Relationship struct {
+	Type       string
+	StartID    string
+	EndID      string
+	Properties map[string]interface{}
+}
+
+// Folder is a folder accessed by a user (ShellBags, TypedPaths, LastVisitedMRU), created when linking the FileAccesses
+type Folder struct {
+	FullPath string
+	Filename string
+	Computer string
+}
+
+type Graph struct {
+	// CaseID is a random UUID prefixing the node IDs, so the runs imported in the same database do not collide
+	CaseID        string
+	Nodes         []Node
+	Relationships []Relationship
+
+	// Compact graphs keep their nodes without evidence, for the extractors writing the nodes of every batch.
+	// The nodes of the DeferredLabels are kept whole, they are written once linked.
+	Compact bool
+
+	mutex    sync.Mutex
+	counters map[string]int
+
+	// Indexes built by Link, updated when nodes are created while linking
+	computers map[string]string
+	names     map[string][]string // Computers of the names of the entities, filled by getComputers
+	users     map[string][]string
+	anonymous map[string]bool // Users named "-" (Windows events without a user)
+	processes map[processKey][]int
+	files     map[string]string
+	folders   map[string]string
+	hosts     map[string]string
+	domains   map[string]string
+}
+
+// processKey identify the processes of a computer by PID
+type processKey struct {
+	computer string
+	pid      int
+}
+
+// DeferredLabels are the labels of the nodes updated by Link (log coverage gaps, domain of the Hosts)
+var DeferredLabels = map[string]bool{"Computer": true, "AntiForensics": true, "Host": true}
+
+// Labels list the labels of the nodes
+var Labels = []string{
+	"Process", "ScriptBlock", "File", "Folder", "User", "Group", "Computer", "ScheduledTask", "Service", "Domain", "Host",
+	"WebHistory", "Connection", "Event", "Registry", "SecurityControlChange", "Detection", "AntiForensics", "Device",
+	"FileAccess", "Network", "Command", "Cookie", "Alert", "DnsQuery",
+}
+
+func NewGraph() *Graph {
+	return &Graph{CaseID: newCaseID(), counters: map[string]int{}}
+}
+
+// newCaseID return a random (version 4) UUID
+func newCaseID() string {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		log.Panicln(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// GetLabel return the node label of an entity, as created by the Neo4j extractor
+func GetLabel(entity interface{}) string {
+	switch entity.(type) {
+	case Process:
+		return "Process"
+	case ScriptBlock:
+		return "ScriptBlock"
+	case File:
+		return "File"
+	case Folder:
+		return "Folder"
+	case User:
+		return "User"
+	case Group:
+		return "Group"
+	case Computer:
+		return "Computer"
+	case ScheduledTask:
+		return "ScheduledTask"
+	case Service:
+		return "Service"
+	case Domain:
+		return "Domain"
+	case Host:
+		return "Host"
+	case WebHistory:
+		return "WebHistory"
+	case Connection:
+		return "Connection"
+	case Event:
+		return "Event"
+	case Registry:
+		return "Registry"
+	case SecurityControlChange:
+		return "SecurityControlChange"
+	case Detection:
+		return "Detection"
+	case AntiForensics:
+		return "AntiForensics"
+	case Device:
+		return "Device"
+	case FileAccess:
+		return "FileAccess"
+	case Network:
+		return "Network"
+	case Command:
+		return "Command"
+	case Cookie:
+		return "Cookie"
+	case Alert:
+		return "Alert"
+	case DnsQuery:
+		return "DnsQuery"
+	}
+	return ""
+}
+
+// GetLabelFromID return the label of a node from its ID
+func GetLabelFromID(id string) string {
+	return id[strings.Index(id, ":")+1 : strings.LastIndex(id, ":")]
+}
+
+// AddEntities add every entity of a batch to the graph and return the new nodes. Batches are extracted by concurrent goroutines.
+func (g *Graph) AddEntities(data []interface{}) []Node {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var nodes []Node
+	for _, d := range data {
+		v := reflect.ValueOf(d)
+		if v.Kind() != reflect.Slice {
+			continue
+		}
+		for i := 0; i < v.Len(); i++ {
+			entity := v.Index(i).Interface()
+			if g.addNode(entity) == "" {
+				continue
+			}
+			n := g.Nodes[len(g.Nodes)-1]
+			nodes = append(nodes, n)
+			if g.Compact && !DeferredLabels[n.Label] {
+				g.Nodes[len(g.Nodes)-1].Entity = compactEntity(entity)
+			}
+		}
+	}
+	return nodes
+}
+
+// compactEntity return a copy of an entity without its evidence, which the Linker does not use
+func compactEntity(entity interface{}) interface{} {
+	v := reflect.New(reflect.TypeOf(entity)).Elem()
+	v.Set(reflect.ValueOf(entity))
+	if evidence := v.FieldByName("Evidence"); evidence.IsValid() {
+		evidence.Set(reflect.Zero(evidence.Type()))
+	}
+	return v.Interface()
+}
+
+func (g *Graph) addNode(entity interface{}) string {
+	label := GetLabel(entity)
+	if label == "" {
+		return ""
+	}
+
+	id := g.CaseID + ":" + label + ":" + strconv.Itoa(g.counters[label])
+	g.counters[label] += 1
+	g.Nodes = append(g.Nodes, Node{ID: id, Label: label, Entity: entity})
+	return id
+}
+
+func (g *Graph) addRelationship(relType string, start string, end string, properties map[string]interface{}) {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	g.Relationships = append(g.Relationships, Relationship{Type: relType, StartID: start, EndID: end, Properties: properties})
+}
+
+// getStringField return a string field of any entity ("" if the entity has no such field),
+// like the Cypher queries matching every node with a "computer" or "user" property
+func getStringField(entity interface{}, name string) string {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	field := v.FieldByName(name)
+	if field.IsValid() && field.Kind() == reflect.String {
+		return field.String()
+	}
+	return ""
+}
+
+// Link compute the relationships between the nodes and return the number of nodes it created.
+// Host, Domain, File and Folder nodes are created from the Connections, DNS queries, Events, FileAccesses, downloads, cookies and detections.
+func (g *Graph) Link() int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	start := len(g.Nodes)
+	g.Relationships = nil
+	g.buildIndexes()
+
+	g.linkProcesses()
+	g.linkScriptBlocks()
+	g.linkCommands()
+	g.linkConnections()
+	g.linkDnsQueries()
+	g.linkNetworks()
+	g.linkEvents()
+	g.linkGroups()
+	g.linkWebHistories()
+	g.linkFileAccesses()
+	g.linkLinkFiles()
+	g.linkProcessImages()
+	g.linkSecurityControls()
+	g.linkDevices()
+	g.linkAlerts()
+	g.linkLogGaps()
+
+	// After the other linkers, the nodes they created get their Computer and User too
+	g.linkComputers()
+	g.linkUsers()
+
+	return len(g.Nodes) - start
+}
+
+func fileKey(computer string, fullpath string) string {
+	return computer + "|" + fullpath
+}
+
+// nodeFileKey identify a File or Folder node by its path, or by its filename when the path is unknown (RecentDocs)
+func nodeFileKey(computer string, fullpath string, filename string) string {
+	if fullpath == "" {
+		return computer + "||" + filename
+	}
+	return fileKey(computer, fullpath)
+}
+
+func (g *Graph) buildIndexes() {
+	g.computers = map[string]string{}
+	g.names = map[string][]string{}
+	g.users = map[string][]string{}
+	g.anonymous = map[string]bool{}
+	g.processes = map[processKey][]int{}
+	g.files = map[string]string{}
+	g.folders = map[string]string{}
+	g.hosts = map[string]string{}
+	g.domains = map[string]string{}
+
+	for i, n := range g.Nodes {
+		switch e := n.Entity.(type) {
+		case Computer:
+			if _, found := g.computers[e.Name]; !found {
+				g.computers[e.Name] = n.ID
+			}
+			break
+		case User:
+			g.users[e.FullName] = append(g.users[e.FullName], n.ID)
+			if e.Username != "" && e.Username != e.FullName {
+				g.users[e.Username] = append(g.users[e.Username], n.ID)
+			}
+			if e.FullName == "-" {
+				g.anonymous[n.ID] = true
+			}
+			break
+		case Process:
+			if e.PID != 0 {
+				key := processKey{e.Computer, e.PID}
+				g.processes[key] = append(g.processes[key], i)
+			}
+			break
+		case File:
+			if _, found := g.files[nodeFileKey(e.Computer, e.FullPath, e.Filename)]; !found {
+				g.files[nodeFileKey(e.Computer, e.FullPath, e.Filename)] = n.ID
+			}
+			break
+		case Folder:
+			g.folders[nodeFileKey(e.Computer, e.FullPath, e.Filename)] = n.ID
+			break
+		case Host:
+			g.hosts[e.IP] = n.ID
+			break
+		case Domain:
+			g.domains[e.Name] = n.ID
+			break
+		}
+	}
+}
+
+// getComputers return the Computers of a name, the FQDNs of the Event Logs for a NetBIOS name (transcripts, Lnk tracker)
+func (g *Graph) getComputers(name string) []string {
+	if name == "" {
+		return nil
+	}
+	if ids, ok := g.names[name]; ok {
+		return ids
+	}
+
+	var names []string
+	for computer := range g.computers {
+		if isSameComputer(computer, name) {
+			names = append(names, computer)
+		}
+	}
+	sort.Strings(names)
+
+	var ids []string
+	for _, computer := range names {
+		ids = append(ids, g.computers[computer])
+	}
+	g.names[name] = ids
+	return ids
+}
+
+// getUsers return the Users matching a name on their full name or their username
+func (g *Graph) getUsers(name string) []string {
+	if name == "" {
+		return nil
+	}
+	return g.users[name]
+}
+
+// getProcess return the latest Process with this path and PID started before the timestamp (PIDs are reused), -1 if none
+func (g *Graph) getProcess(computer string, pid int, fullpath string, timestamp int) int {
+	process := -1
+	for _, i := range g.processes[processKey{computer, pid}] {
+		p := g.Nodes[i].Entity.(Process)
+		if p.FullPath != fullpath || p.Timestamp > timestamp {
+			continue
+		}
+		if process == -1 || p.Timestamp > g.Nodes[process].Entity.(Process).Timestamp {
+			process = i
+		}
+	}
+	return process
+}
+
+// getHost return the Host node of an IP address, created on first use
+func (g *Graph) getHost(ip string) string {
+	if id, ok := g.hosts[ip]; ok {
+		return id
+	}
+	g.hosts[ip] = g.addNode(Host{IP: ip})
+	return g.hosts[ip]
+}
+
+// getDomain return the Domain node of a name, created on first use
+func (g *Graph) getDomain(name string) string {
+	if id, ok := g.domains[name]; ok {
+		return id
+	}
+	g.domains[name] = g.addNode(Domain{Name: name})
+	return g.domains[name]
+}
+
+// getFile return the File node of a path (or of a filename without path), created on first use
+func (g *Graph) getFile(file File) string {
+	key := nodeFileKey(file.Computer, file.FullPath, file.Filename)
+	if id, ok := g.files[key]; ok {
+		return id
+	}
+	g.files[key] = g.addNode(file)
+	return g.files[key]
+}
+
+// getFolder return the Folder node of a path, created on first use
+func (g *Graph) getFolder(folder Folder) string {
+	key := nodeFileKey(folder.Computer, folder.FullPath, folder.Filename)
+	if id, ok := g.folders[key]; ok {
+		return id
+	}
+	g.folders[key] = g.addNode(folder)
+	return g.folders[key]
+}
+
+// linkComputers Computer -[ON]-> every node of the computer, the nodes named by NetBIOS are linked to the FQDN
+func (g *Graph) linkComputers() {
+	for _, n := range g.Nodes {
+		if n.Label == "Computer" {
+			continue
+		}
+		for _, id := range g.getComputers(getStringField(n.Entity, "Computer")) {
+			g.addRelationship("ON", id, n.ID, nil)
+		}
+	}
+}
+
+// linkUsers User -[BY]-> every node of the user
+func (g *Graph) linkUsers() {
+	for _, n := range g.Nodes {
+		linked := map[string]bool{}
+		for _, id := range g.getUsers(getStringField(n.Entity, "User")) {
+			if !linked[id] && !g.anonymous[id] {
+				linked[id] = true
+				g.addRelationship("BY", id, n.ID, nil)
+			}
+		}
+	}
+}
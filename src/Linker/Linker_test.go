@@ -0,0 +1,48 @@
+package Linker
+
+import (
+	. "plaso2graph/master/src/Entity"
+	"testing"
+)
+
+func TestLinkComputers(t *testing.T) {
+	computers := []Computer{{Name: "WS01.corp.local"}, {Name: "WS02.corp.local"}, {Name: "SRV01"}}
+
+	tests := []struct {
+		name     string
+		computer string
+		linked   []int // Indexes of the Computers linked to the node
+	}{
+		{"fqdn", "WS01.corp.local", []int{0}},
+		{"netbios name", "WS01", []int{0}},
+		{"netbios name in lower case", "ws02", []int{1}},
+		{"computer without fqdn", "SRV01", []int{2}},
+		{"prefix of another name", "WS", nil},
+		{"unknown computer", "WS03", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGraph()
+			nodes := g.AddEntities([]interface{}{computers})
+			task := g.AddEntities([]interface{}{[]ScheduledTask{{Application: "backup.exe", Computer: test.computer}}})[0]
+			g.Link()
+
+			var found []string
+			for _, r := range g.Relationships {
+				if r.Type == "ON" && r.EndID == task.ID {
+					found = append(found, r.StartID)
+				}
+			}
+
+			if len(found) != len(test.linked) {
+				t.Fatalf("node on %v, want %d computers", found, len(test.linked))
+			}
+			for i, j := range test.linked {
+				if found[i] != nodes[j].ID {
+					t.Errorf("node on %v, want %s", found, nodes[j].ID)
+				}
+			}
+		})
+	}
+}
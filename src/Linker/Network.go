@@ -0,0 +1,216 @@
+package Linker
+
+import (
+	. "plaso2graph/master/src/Entity"
+	"strconv"
+	"strings"
+)
+
+// linkConnections convert the Connections to CONNECT relationships:
+// Process -> Host for endpoint telemetry, Host -> Host for network telemetry and Host -> Computer for inbound logons
+func (g *Graph) linkConnections() {
+	for _, n := range g.Nodes {
+		c, ok := n.Entity.(Connection)
+		if !ok {
+			continue
+		}
+
+		// Every destination is a Host, even when no Process is found
+		if c.DestinationIP != "" {
+			g.getHost(c.DestinationIP)
+		}
+
+		if c.ProcessName != "" && c.DestinationIP != "" {
+			properties := map[string]interface{}{
+				"port_source":      c.SourcePort,
+				"port_destination": c.DestinationPort,
+				"ip_source":        c.SourceIP,
+				"timestamp":        c.Timestamp,
+				"date":             c.Date,
+			}
+			// Connection timestamps are in nanoseconds, Process ones in microseconds
+			if i := g.getProcess(c.Computer, c.ProcessId, c.ProcessName, c.Timestamp/1000); i != -1 {
+				g.addRelationship("CONNECT", g.Nodes[i].ID, g.getHost(c.DestinationIP), properties)
+			}
+		}
+
+		// Network telemetry (Zeek, firewall) without a Process
+		if c.ProcessName == "" && c.Initiated && c.SourceIP != "" && c.DestinationIP != "" {
+			g.addRelationship("CONNECT", g.getHost(c.SourceIP), g.getHost(c.DestinationIP), map[string]interface{}{
+				"port_source":      c.SourcePort,
+				"port_destination": c.DestinationPort,
+				"protocol":         c.Protocol,
+				"timestamp":        c.Timestamp,
+				"date":             c.Date,
+				"source":           c.Source,
+				"action":           c.Action,
+				"bytes_sent":       c.BytesSent,
+				"bytes_received":   c.BytesReceived,
+			})
+		}
+
+		// Inbound connections (ssh and utmp logons)
+		if computer, ok := g.computers[c.Computer]; ok && !c.Initiated && c.SourceIP != "" {
+			g.addRelationship("CONNECT", g.getHost(c.SourceIP), computer, map[string]interface{}{
+				"port_source":      c.SourcePort,
+				"port_destination": c.DestinationPort,
+				"user":             c.User,
+				"timestamp":        c.Timestamp,
+				"date":             c.Date,
+			})
+		}
+	}
+}
+
+// linkDnsQueries Host -[RESOLVED]-> Domain for the client and Domain -[RESOLVES_TO]-> Host for the answers
+func (g *Graph) linkDnsQueries() {
+	answers := map[string]string{}
+	resolved := map[string]bool{}
+
+	for _, n := range g.Nodes {
+		q, ok := n.Entity.(DnsQuery)
+		if !ok {
+			continue
+		}
+		domain := g.getDomain(q.Query)
+
+		if q.SourceIP != "" {
+			g.addRelationship("RESOLVED", g.getHost(q.SourceIP), domain, map[string]interface{}{
+				"timestamp":     q.Timestamp,
+				"date":          q.Date,
+				"query_type":    q.QueryType,
+				"response_code": q.ResponseCode,
+			})
+		}
+
+		for _, answer := range q.Answers {
+			if _, found := answers[answer]; !found {
+				answers[answer] = q.Query
+			}
+			host, ok := g.hosts[answer]
+			if !ok || resolved[domain+host] {
+				continue
+			}
+			resolved[domain+host] = true
+			g.addRelationship("RESOLVES_TO", domain, host, nil)
+		}
+	}
+
+	// DNS answers give their domain to the Hosts
+	for i, n := range g.Nodes {
+		if h, ok := n.Entity.(Host); ok && h.Domain == "" && answers[h.IP] != "" {
+			h.Domain = answers[h.IP]
+			g.Nodes[i].Entity = h
+		}
+	}
+}
+
+// linkNetworks Computer -[CONNECTED_TO]-> Network and Process -[USED]-> Network (SRUM bytes sent and received)
+func (g *Graph) linkNetworks() {
+	networks := map[string][]string{}
+	for _, n := range g.Nodes {
+		network, ok := n.Entity.(Network)
+		if !ok {
+			continue
+		}
+		key := network.Computer + "|" + strconv.Itoa(network.InterfaceLUID)
+		networks[key] = append(networks[key], n.ID)
+
+		if computer, ok := g.computers[network.Computer]; ok {
+			g.addRelationship("CONNECTED_TO", computer, n.ID, map[string]interface{}{
+				"first_connected": network.FirstConnected,
+				"last_seen":       network.LastSeen,
+			})
+		}
+	}
+
+	for _, n := range g.Nodes {
+		p, ok := n.Entity.(Process)
+		if !ok || p.InterfaceLUID == 0 {
+			continue
+		}
+		// A relationship per hour, so the traffic of a given time can be queried
+		for _, id := range networks[p.Computer+"|"+strconv.Itoa(p.InterfaceLUID)] {
+			for _, u := range p.NetworkUsages {
+				g.addRelationship("USED", n.ID, id, map[string]interface{}{
+					"bytes_sent":     u.BytesSent,
+					"bytes_received": u.BytesReceived,
+					"start":          u.Start,
+					"end":            u.End,
+					"timestamp":      u.StartTimestamp,
+				})
+			}
+		}
+	}
+}
+
+// linkDevices Computer -[CONNECTED]-> Device, User -[MOUNTED]-> Device (MountPoints2)
+// and File or Process -[STORED_ON]-> Device for the paths on the drive letter of the device
+func (g *Graph) linkDevices() {
+	var devices []int
+	for i, n := range g.Nodes {
+		if _, ok := n.Entity.(Device); ok {
+			devices = append(devices, i)
+		}
+	}
+
+	for _, i := range devices {
+		d := g.Nodes[i].Entity.(Device)
+		if computer, ok := g.computers[d.Computer]; ok {
+			g.addRelationship("CONNECTED", computer, g.Nodes[i].ID, map[string]interface{}{
+				"first_connected": d.FirstConnected,
+				"last_connected":  d.LastConnected,
+			})
+		}
+
+		// MountPoints2 tells which users mounted the volume
+		for _, user := range d.Users {
+			for _, id := range g.getUsers(user) {
+				g.addRelationship("MOUNTED", id, g.Nodes[i].ID, map[string]interface{}{
+					"timestamp": d.LastConnectedTimestamp,
+					"date":      d.LastConnected,
+				})
+			}
+		}
+	}
+
+	for _, n := range g.Nodes {
+		var fullpath, computer string
+		var timestamp int
+		switch e := n.Entity.(type) {
+		case File:
+			fullpath, computer, timestamp = e.FullPath, e.Computer, e.Timestamp
+			break
+		case Process:
+			fullpath, computer, timestamp = e.FullPath, e.Computer, e.Timestamp
+			break
+		default:
+			continue
+		}
+
+		// Drive letters are reused, the path is on the device connected at the time of the file,
+		// the last one connected when several were
+		found := -1
+		for _, i := range devices {
+			d := g.Nodes[i].Entity.(Device)
+			if d.DriveLetter == "" || d.DriveLetter == "C:" || d.Computer != computer {
+				continue
+			}
+			if !strings.HasPrefix(strings.ToLower(fullpath), strings.ToLower(d.DriveLetter)) {
+				continue
+			}
+			if d.FirstConnectedTimestamp != 0 && timestamp < d.FirstConnectedTimestamp {
+				continue
+			}
+			if d.LastConnectedTimestamp != 0 && timestamp > d.LastConnectedTimestamp {
+				continue
+			}
+			if found == -1 || d.FirstConnectedTimestamp > g.Nodes[found].Entity.(Device).FirstConnectedTimestamp {
+				found = i
+			}
+		}
+		if found != -1 {
+			g.addRelationship("STORED_ON", n.ID, g.Nodes[found].ID, nil)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package Linker
+
+import (
+	. "plaso2graph/master/src/Entity"
+	"strings"
+)
+
+// linkProcesses Process -[EXECUTE]-> Process, the closest parent is kept when PIDs were reused
+func (g *Graph) linkProcesses() {
+	for _, n := range g.Nodes {
+		p, ok := n.Entity.(Process)
+		if !ok || p.PID == 0 || p.PPID == 0 {
+			continue
+		}
+
+		if parent := g.getProcess(p.Computer, p.PPID, p.ParentProcessName, p.Timestamp-1); parent != -1 {
+			g.addRelationship("EXECUTE", g.Nodes[parent].ID, n.ID, nil)
+		}
+	}
+}
+
+// linkScriptBlocks Process -[EXECUTE]-> ScriptBlock, by PID or by the command line of the PowerShell host
+// (4103 ContextInfo, Windows PowerShell log)
+func (g *Graph) linkScriptBlocks() {
+	for _, n := range g.Nodes {
+		s, ok := n.Entity.(ScriptBlock)
+		if !ok || s.ProcessID == 0 {
+			continue
+		}
+
+		parent := -1
+		for _, i := range g.processes[processKey{s.Computer, s.ProcessID}] {
+			p := g.Nodes[i].Entity.(Process)
+			if p.Timestamp > s.Timestamp {
+				continue
+			}
+			if parent == -1 || p.Timestamp > g.Nodes[parent].Entity.(Process).Timestamp {
+				parent = i
+			}
+		}
+
+		if parent == -1 && s.HostApplication != "" {
+			for _, i := range g.processes[processKey{s.Computer, s.ProcessID}] {
+				if g.Nodes[i].Entity.(Process).Commandline == s.HostApplication {
+					parent = i
+					break
+				}
+			}
+		}
+		if parent != -1 {
+			g.addRelationship("EXECUTE", g.Nodes[parent].ID, n.ID, nil)
+		}
+	}
+}
+
+// isSameComputer compare a NetBIOS name (transcripts, Lnk tracker) with the FQDN of the Event Logs
+func isSameComputer(fqdn string, name string) bool {
+	fqdn = strings.ToUpper(fqdn)
+	name = strings.ToUpper(name)
+	return name != "" && (fqdn == name || strings.HasPrefix(fqdn, name+"."))
+}
+
+// linkCommands link the PowerShell transcripts: User -[RUN_AS]-> Command and Process -[EXECUTE]-> Command (linkComputers links their Computer)
+func (g *Graph) linkCommands() {
+	for _, n := range g.Nodes {
+		cmd, ok := n.Entity.(Command)
+		if !ok {
+			continue
+		}
+
+		if cmd.RunAsUser != "" && cmd.RunAsUser != cmd.User {
+			for _, id := range g.getUsers(cmd.RunAsUser) {
+				g.addRelationship("RUN_AS", id, n.ID, nil)
+			}
+		}
+
+		if cmd.ProcessID == 0 {
+			continue
+		}
+		// A local time can not be compared with the UTC times of the processes, the process is linked only if the PID was not reused
+		parent := -1
+		candidates := 0
+		for key, processes := range g.processes {
+			if key.pid != cmd.ProcessID || !isSameComputer(key.computer, cmd.Computer) {
+				continue
+			}
+			for _, i := range processes {
+				p := g.Nodes[i].Entity.(Process)
+				if (!cmd.LocalTime && p.Timestamp > cmd.Timestamp) || (p.Commandline != cmd.HostApplication && p.FullPath != strings.ToLower(cmd.HostApplication)) {
+					continue
+				}
+				candidates += 1
+				if parent == -1 || p.Timestamp > g.Nodes[parent].Entity.(Process).Timestamp {
+					parent = i
+				}
+			}
+		}
+		if parent != -1 && (!cmd.LocalTime || candidates == 1) {
+			g.addRelationship("EXECUTE", g.Nodes[parent].ID, n.ID, nil)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package Linker
+
+import (
+	. "plaso2graph/master/src/Entity"
+	"testing"
+)
+
+func TestLinkProcessesParent(t *testing.T) {
+	explorer := `C:\Windows\explorer.exe`
+	cmd := `C:\Windows\System32\cmd.exe`
+
+	tests := []struct {
+		name      string
+		processes []Process // Candidate parents
+		child     Process
+		parent    int // Index of the expected parent in processes, -1 if none
+	}{
+		{
+			"single parent",
+			[]Process{{FullPath: explorer, PID: 100, Timestamp: 10, Computer: "pc"}},
+			Process{FullPath: cmd, PID: 200, PPID: 100, ParentProcessName: explorer, Timestamp: 20, Computer: "pc"},
+			0,
+		},
+		{
+			"reused PID, latest parent started before the child",
+			[]Process{
+				{FullPath: explorer, PID: 100, Timestamp: 10, Computer: "pc"},
+				{FullPath: explorer, PID: 100, Timestamp: 50, Computer: "pc"},
+			},
+			Process{FullPath: cmd, PID: 200, PPID: 100, ParentProcessName: explorer, Timestamp: 60, Computer: "pc"},
+			1,
+		},
+		{
+			"reused PID, child started before the second parent",
+			[]Process{
+				{FullPath: explorer, PID: 100, Timestamp: 50, Computer: "pc"},
+				{FullPath: explorer, PID: 100, Timestamp: 10, Computer: "pc"},
+			},
+			Process{FullPath: cmd, PID: 200, PPID: 100, ParentProcessName: explorer, Timestamp: 30, Computer: "pc"},
+			1,
+		},
+		{
+			"PID reused by another image",
+			[]Process{
+				{FullPath: explorer, PID: 100, Timestamp: 10, Computer: "pc"},
+				{FullPath: `C:\Windows\System32\notepad.exe`, PID: 100, Timestamp: 50, Computer: "pc"},
+			},
+			Process{FullPath: cmd, PID: 200, PPID: 100, ParentProcessName: explorer, Timestamp: 60, Computer: "pc"},
+			0,
+		},
+		{
+			"parent started with the child",
+			[]Process{{FullPath: explorer, PID: 100, Timestamp: 20, Computer: "pc"}},
+			Process{FullPath: cmd, PID: 200, PPID: 100, ParentProcessName: explorer, Timestamp: 20, Computer: "pc"},
+			-1,
+		},
+		{
+			"parent on another computer",
+			[]Process{{FullPath: explorer, PID: 100, Timestamp: 10, Computer: "other"}},
+			Process{FullPath: cmd, PID: 200, PPID: 100, ParentProcessName: explorer, Timestamp: 20, Computer: "pc"},
+			-1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGraph()
+			parents := g.AddEntities([]interface{}{test.processes})
+			child := g.AddEntities([]interface{}{[]Process{test.child}})[0]
+			g.Link()
+
+			var found []string
+			for _, r := range g.Relationships {
+				if r.Type == "EXECUTE" && r.EndID == child.ID {
+					found = append(found, r.StartID)
+				}
+			}
+
+			if test.parent == -1 {
+				if len(found) != 0 {
+					t.Errorf("child executed by %v, want no parent", found)
+				}
+				return
+			}
+			if len(found) != 1 || found[0] != parents[test.parent].ID {
+				t.Errorf("child executed by %v, want %s", found, parents[test.parent].ID)
+			}
+		})
+	}
+}
+
+func TestLinkConnectionsProcess(t *testing.T) {
+	chrome := `C:\Program Files\Google\Chrome\Application\chrome.exe`
+
+	tests := []struct {
+		name      string
+		processes []Process // Candidate processes, timestamps in microseconds
+		process   int       // Index of the expected process in processes, -1 if none
+	}{
+		{
+			"single process",
+			[]Process{{FullPath: chrome, PID: 100, Timestamp: 10, Computer: "pc"}},
+			0,
+		},
+		{
+			"reused PID, latest process started before the connection",
+			[]Process{
+				{FullPath: chrome, PID: 100, Timestamp: 10, Computer: "pc"},
+				{FullPath: chrome, PID: 100, Timestamp: 30, Computer: "pc"},
+			},
+			1,
+		},
+		{
+			"process started after the connection",
+			[]Process{{FullPath: chrome, PID: 100, Timestamp: 60, Computer: "pc"}},
+			-1,
+		},
+		{
+			"PID reused by another image",
+			[]Process{{FullPath: `C:\Windows\System32\notepad.exe`, PID: 100, Timestamp: 10, Computer: "pc"}},
+			-1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGraph()
+			processes := g.AddEntities([]interface{}{test.processes})
+			g.AddEntities([]interface{}{[]Connection{{ProcessName: chrome, ProcessId: 100, DestinationIP: "93.184.216.34", Timestamp: 50000, Computer: "pc"}}})
+			g.Link()
+
+			var found []string
+			for _, r := range g.Relationships {
+				if r.Type == "CONNECT" {
+					found = append(found, r.StartID)
+				}
+			}
+
+			if test.process == -1 {
+				if len(found) != 0 {
+					t.Errorf("connection made by %v, want no process", found)
+				}
+				return
+			}
+			if len(found) != 1 || found[0] != processes[test.process].ID {
+				t.Errorf("connection made by %v, want %s", found, processes[test.process].ID)
+			}
+		})
+	}
+}